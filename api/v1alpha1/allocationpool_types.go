@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AllocationPoolSpec defines the desired state of AllocationPool
+type AllocationPoolSpec struct {
+	// Size is the number of idle allocations which the pool keeps ready for
+	// pods.
+	// +kubebuilder:validation:Minimum=0
+	Size int32 `json:"size"`
+
+	// IdleTimeout is how long an allocation in excess of the size of the pool
+	// may stay idle, after its pods ended, before it is released.
+	// +optional
+	IdleTimeout *metav1.Duration `json:"idleTimeout,omitempty"`
+
+	// Nodes is the number of nodes of each allocation.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Nodes int32 `json:"nodes,omitempty"`
+
+	// Partition of the allocations, or the configured partition if unset.
+	// +optional
+	Partition string `json:"partition,omitempty"`
+
+	// Account of the allocations.
+	// +optional
+	Account string `json:"account,omitempty"`
+
+	// QOS of the allocations.
+	// +optional
+	QOS string `json:"qos,omitempty"`
+
+	// Reservation of the allocations.
+	// +optional
+	Reservation string `json:"reservation,omitempty"`
+
+	// Constraints are the node features required by the allocations.
+	// +optional
+	Constraints string `json:"constraints,omitempty"`
+
+	// Gres are the generic resources of each node (e.g. "gres/gpu=8").
+	// +optional
+	Gres string `json:"gres,omitempty"`
+
+	// MemPerNode is the memory of each node.
+	// +optional
+	MemPerNode *resource.Quantity `json:"memPerNode,omitempty"`
+
+	// TimeLimit of the allocations, in minutes.
+	// +optional
+	TimeLimit *int32 `json:"timeLimit,omitempty"`
+}
+
+// AllocationState is the state of an allocation of an AllocationPool.
+// +kubebuilder:validation:Enum=Pending;Idle;InUse
+type AllocationState string
+
+const (
+	// AllocationPending is an allocation which Slurm has not yet started.
+	AllocationPending AllocationState = "Pending"
+	// AllocationIdle is a running allocation which pods may claim.
+	AllocationIdle AllocationState = "Idle"
+	// AllocationInUse is a running allocation claimed by pods.
+	AllocationInUse AllocationState = "InUse"
+)
+
+// Allocation is a Slurm job of an AllocationPool.
+type Allocation struct {
+	// JobId is the ID of the Slurm job.
+	JobId int32 `json:"jobId"`
+
+	// Nodes are the Slurm nodes of a running allocation.
+	// +optional
+	Nodes string `json:"nodes,omitempty"`
+
+	// State of the allocation.
+	State AllocationState `json:"state"`
+
+	// IdleSince is when the allocation last became idle.
+	// +optional
+	IdleSince *metav1.Time `json:"idleSince,omitempty"`
+}
+
+// AllocationPoolStatus defines the observed state of AllocationPool
+type AllocationPoolStatus struct {
+	// Pending is the number of pending allocations.
+	// +optional
+	Pending int32 `json:"pending,omitempty"`
+
+	// Idle is the number of idle allocations.
+	// +optional
+	Idle int32 `json:"idle,omitempty"`
+
+	// InUse is the number of allocations claimed by pods.
+	// +optional
+	InUse int32 `json:"inUse,omitempty"`
+
+	// Allocations are the Slurm jobs of the pool.
+	// +optional
+	Allocations []Allocation `json:"allocations,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="SIZE",type="integer",JSONPath=".spec.size"
+// +kubebuilder:printcolumn:name="PENDING",type="integer",JSONPath=".status.pending"
+// +kubebuilder:printcolumn:name="IDLE",type="integer",JSONPath=".status.idle"
+// +kubebuilder:printcolumn:name="IN USE",type="integer",JSONPath=".status.inUse"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// AllocationPool keeps warm Slurm allocations, which the pods of its namespace
+// claim instead of submitting a placeholder job.
+type AllocationPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AllocationPoolSpec   `json:"spec,omitempty"`
+	Status AllocationPoolStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AllocationPoolList contains a list of AllocationPool
+type AllocationPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AllocationPool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AllocationPool{}, &AllocationPoolList{})
+}
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SlurmJobSpec defines the desired state of SlurmJob
+type SlurmJobSpec struct {
+	// Script is the batch script of the job, starting with a shebang line.
+	// +kubebuilder:validation:MinLength=1
+	Script string `json:"script"`
+
+	// Environment of the job, as NAME=value.
+	// +optional
+	Environment []string `json:"environment,omitempty"`
+
+	// WorkingDirectory of the job, or /tmp if unset.
+	// +optional
+	WorkingDirectory string `json:"workingDirectory,omitempty"`
+
+	// StandardOutput is the path of the file the job writes its output to.
+	// +optional
+	StandardOutput string `json:"standardOutput,omitempty"`
+
+	// StandardError is the path of the file the job writes its errors to.
+	// +optional
+	StandardError string `json:"standardError,omitempty"`
+
+	// JobName of the job, or the name of the SlurmJob if unset.
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// Nodes is the number of nodes of the job.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Nodes *int32 `json:"nodes,omitempty"`
+
+	// Tasks is the number of tasks of the job.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Tasks *int32 `json:"tasks,omitempty"`
+
+	// TasksPerNode is the number of tasks of each node.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TasksPerNode *int32 `json:"tasksPerNode,omitempty"`
+
+	// CpusPerTask is the number of CPUs of each task.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CpusPerTask *int32 `json:"cpusPerTask,omitempty"`
+
+	// MemPerNode is the memory of each node.
+	// +optional
+	MemPerNode *resource.Quantity `json:"memPerNode,omitempty"`
+
+	// Gres are the generic resources of each node (e.g. "gres/gpu=8").
+	// +optional
+	Gres string `json:"gres,omitempty"`
+
+	// TimeLimit of the job, in minutes.
+	// +optional
+	TimeLimit *int32 `json:"timeLimit,omitempty"`
+}
+
+// SlurmJobStatus defines the observed state of SlurmJob
+type SlurmJobStatus struct {
+	// JobId is the ID of the submitted Slurm job.
+	// +optional
+	JobId int32 `json:"jobId,omitempty"`
+
+	// State is the state of the Slurm job (e.g. PENDING, RUNNING, COMPLETED),
+	// or Unknown once Slurm no longer knows the job.
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// Reason is why the Slurm job is pending or failed.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// ExitCode of the batch script, once the Slurm job ended.
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+
+	// Nodes are the Slurm nodes allocated to the job.
+	// +optional
+	Nodes string `json:"nodes,omitempty"`
+
+	// StartTime is when the Slurm job started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// EndTime is when the Slurm job ended.
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="JOBID",type="integer",JSONPath=".status.jobId"
+// +kubebuilder:printcolumn:name="STATE",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="NODES",type="string",JSONPath=".status.nodes"
+// +kubebuilder:printcolumn:name="EXIT CODE",type="integer",JSONPath=".status.exitCode",priority=1
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// SlurmJob is a Slurm batch job submitted from Kubernetes. The job description
+// is completed by the slinky.slurm.net annotations of the SlurmJob and the
+// defaults of its namespace, like those of pods.
+type SlurmJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+	Spec   SlurmJobSpec   `json:"spec,omitempty"`
+	Status SlurmJobStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SlurmJobList contains a list of SlurmJob
+type SlurmJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SlurmJob `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SlurmJob{}, &SlurmJobList{})
+}
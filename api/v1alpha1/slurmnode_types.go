@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SlurmNodeStatus defines the observed state of SlurmNode
+type SlurmNodeStatus struct {
+	// State of the Slurm node, its base state followed by its flags
+	// (e.g. IDLE+DRAIN).
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// Reason the Slurm node is down or drained.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// CPUs is the number of CPUs of the Slurm node.
+	// +optional
+	CPUs int32 `json:"cpus,omitempty"`
+
+	// AllocatedCPUs is the number of CPUs allocated to jobs.
+	// +optional
+	AllocatedCPUs int32 `json:"allocatedCpus,omitempty"`
+
+	// Memory is the memory of the Slurm node.
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+
+	// AllocatedMemory is the memory allocated to jobs.
+	// +optional
+	AllocatedMemory *resource.Quantity `json:"allocatedMemory,omitempty"`
+
+	// Gres are the generic resources of the Slurm node (e.g. "gpu:h100:8").
+	// +optional
+	Gres string `json:"gres,omitempty"`
+
+	// AllocatedGres are the generic resources allocated to jobs.
+	// +optional
+	AllocatedGres string `json:"allocatedGres,omitempty"`
+
+	// Features are the active features of the Slurm node.
+	// +optional
+	Features []string `json:"features,omitempty"`
+
+	// Partitions are the Slurm partitions the node belongs to.
+	// +optional
+	Partitions []string `json:"partitions,omitempty"`
+
+	// KubernetesNode is the name of the Kubernetes node bridged to the Slurm
+	// node, if any.
+	// +optional
+	KubernetesNode string `json:"kubernetesNode,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="STATE",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="CPUS",type="integer",JSONPath=".status.cpus"
+// +kubebuilder:printcolumn:name="ALLOC CPUS",type="integer",JSONPath=".status.allocatedCpus"
+// +kubebuilder:printcolumn:name="MEMORY",type="string",JSONPath=".status.memory"
+// +kubebuilder:printcolumn:name="ALLOC MEMORY",type="string",JSONPath=".status.allocatedMemory"
+// +kubebuilder:printcolumn:name="GRES",type="string",JSONPath=".status.gres",priority=1
+// +kubebuilder:printcolumn:name="REASON",type="string",JSONPath=".status.reason",priority=1
+// +kubebuilder:printcolumn:name="KUBERNETES NODE",type="string",JSONPath=".status.kubernetesNode"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// SlurmNode mirrors a Slurm node. It is read-only, its status is kept in sync
+// with Slurm by the controllers.
+type SlurmNode struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status SlurmNodeStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SlurmNodeList contains a list of SlurmNode
+type SlurmNodeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SlurmNode `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SlurmNode{}, &SlurmNodeList{})
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SlurmPartitionStatus defines the observed state of SlurmPartition
+type SlurmPartitionStatus struct {
+	// State of the Slurm partition (e.g. UP, DOWN, DRAIN, INACTIVE).
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// Nodes are the Slurm nodes of the partition, as a host list
+	// (e.g. "node[1-4]").
+	// +optional
+	Nodes string `json:"nodes,omitempty"`
+
+	// NodeCount is the number of Slurm nodes of the partition.
+	// +optional
+	NodeCount int32 `json:"nodeCount,omitempty"`
+
+	// CPUs is the number of CPUs of the nodes of the partition.
+	// +optional
+	CPUs int32 `json:"cpus,omitempty"`
+
+	// AllocatedCPUs is the number of CPUs of the nodes of the partition
+	// allocated to jobs.
+	// +optional
+	AllocatedCPUs int32 `json:"allocatedCpus,omitempty"`
+
+	// Memory is the memory of the nodes of the partition.
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+
+	// AllocatedMemory is the memory of the nodes of the partition allocated
+	// to jobs.
+	// +optional
+	AllocatedMemory *resource.Quantity `json:"allocatedMemory,omitempty"`
+
+	// Gres are the generic resources of the nodes of the partition, as TRES
+	// (e.g. "gres/gpu=16").
+	// +optional
+	Gres string `json:"gres,omitempty"`
+
+	// AllocatedGres are the generic resources of the nodes of the partition
+	// allocated to jobs, as TRES.
+	// +optional
+	AllocatedGres string `json:"allocatedGres,omitempty"`
+
+	// KubernetesNodes are the names of the Kubernetes nodes bridged to the
+	// Slurm nodes of the partition.
+	// +optional
+	KubernetesNodes []string `json:"kubernetesNodes,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="STATE",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="NODES",type="integer",JSONPath=".status.nodeCount"
+// +kubebuilder:printcolumn:name="CPUS",type="integer",JSONPath=".status.cpus"
+// +kubebuilder:printcolumn:name="ALLOC CPUS",type="integer",JSONPath=".status.allocatedCpus"
+// +kubebuilder:printcolumn:name="MEMORY",type="string",JSONPath=".status.memory"
+// +kubebuilder:printcolumn:name="ALLOC MEMORY",type="string",JSONPath=".status.allocatedMemory"
+// +kubebuilder:printcolumn:name="GRES",type="string",JSONPath=".status.gres",priority=1
+// +kubebuilder:printcolumn:name="NODELIST",type="string",JSONPath=".status.nodes",priority=1
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// SlurmPartition mirrors a Slurm partition. It is read-only, its status is kept
+// in sync with Slurm by the controllers.
+type SlurmPartition struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status SlurmPartitionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SlurmPartitionList contains a list of SlurmPartition
+type SlurmPartitionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SlurmPartition `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SlurmPartition{}, &SlurmPartitionList{})
+}
@@ -18,13 +18,16 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	//+kubebuilder:scaffold:imports
 
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
 	"github.com/SlinkyProject/slurm-bridge/internal/admission"
 	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/metrics"
 )
 
 var (
@@ -34,6 +37,7 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -96,12 +100,14 @@ func main() {
 		os.Exit(1)
 	}
 	cfg := config.UnmarshalOrDie(data)
+	metrics.Register(ctrlmetrics.Registry)
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
 		Metrics: server.Options{
-			BindAddress: "0",
-			TLSOpts:     tlsOpts,
+			BindAddress:   flags.metricsAddr,
+			SecureServing: flags.secureMetrics,
+			TLSOpts:       tlsOpts,
 		},
 		WebhookServer: webhook.NewServer(webhook.Options{
 			TLSOpts: tlsOpts,
@@ -129,11 +135,21 @@ func main() {
 		ManagedNamespaces:        cfg.ManagedNamespaces,
 		ManagedNamespaceSelector: cfg.ManagedNamespaceSelector,
 		SchedulerName:            cfg.SchedulerName,
+		Policies:                 cfg.Policies,
 	}
 	if err := podAdmission.SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Pod")
 		os.Exit(1)
 	}
+	slurmJobAdmission := admission.SlurmJobAdmission{
+		Client:            mgr.GetClient(),
+		NamespaceDefaults: cfg.NamespaceDefaults,
+		Policies:          cfg.Policies,
+	}
+	if err := slurmJobAdmission.SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "SlurmJob")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -7,6 +7,7 @@ import (
 	"context"
 	"crypto/tls"
 	"flag"
+	"net/http"
 	"os"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -21,13 +22,21 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
 
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
 	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/allocationpool"
 	"github.com/SlinkyProject/slurm-bridge/internal/controller/node"
 	"github.com/SlinkyProject/slurm-bridge/internal/controller/pod"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/slurmjob"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/slurmnode"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/slurmpartition"
+	"github.com/SlinkyProject/slurm-bridge/internal/metrics"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjwt"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -38,6 +47,7 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
 
 	//+kubebuilder:scaffold:scheme
 }
@@ -118,13 +128,25 @@ func main() {
 		os.Exit(1)
 	}
 	cfg := config.UnmarshalOrDie(data)
+	metrics.Register(ctrlmetrics.Registry)
 
+	tokenSource, err := slurmjwt.NewTokenSource(cfg.SlurmJwt, mgr.GetAPIReader())
+	if err != nil {
+		setupLog.Error(err, "unable to create slurm token source")
+		os.Exit(1)
+	}
+	token, err := tokenSource.Token(context.Background())
+	if err != nil {
+		setupLog.Error(err, "unable to read slurm token")
+		os.Exit(1)
+	}
+	slurmHTTPClient := &http.Client{
+		Transport: slurmjwt.NewTransport(tokenSource, metrics.NewTransport(nil)),
+	}
 	clientConfig := &slurmclient.Config{
-		Server: cfg.SlurmRestApi,
-		AuthToken: func() string {
-			token, _ := os.LookupEnv("SLURM_JWT")
-			return token
-		}(),
+		Server:     cfg.SlurmRestApi,
+		AuthToken:  token,
+		HTTPClient: slurmHTTPClient,
 	}
 	slurmClient, err := slurmclient.NewClient(clientConfig)
 	if err != nil {
@@ -133,26 +155,72 @@ func main() {
 	}
 	go slurmClient.Start(context.Background())
 
+	slurmNodeEventCh := make(chan event.GenericEvent, 100)
 	if err = (&node.NodeReconciler{
-		Client:        mgr.GetClient(),
-		SchedulerName: cfg.SchedulerName,
-		Scheme:        mgr.GetScheme(),
-		SlurmClient:   slurmClient,
-		EventCh:       make(chan event.GenericEvent, 100),
+		Client:           mgr.GetClient(),
+		SchedulerName:    cfg.SchedulerName,
+		Scheme:           mgr.GetScheme(),
+		SlurmClient:      slurmClient,
+		EventCh:          make(chan event.GenericEvent, 100),
+		SlurmNodeEventCh: slurmNodeEventCh,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Node")
 		os.Exit(1)
 	}
 	if err = (&pod.PodReconciler{
-		Client:        mgr.GetClient(),
-		SchedulerName: cfg.SchedulerName,
-		Scheme:        mgr.GetScheme(),
-		SlurmClient:   slurmClient,
-		EventCh:       make(chan event.GenericEvent, 100),
+		Client:          mgr.GetClient(),
+		SchedulerName:   cfg.SchedulerName,
+		Teardown:        cfg.Teardown,
+		Scheme:          mgr.GetScheme(),
+		SlurmClient:     slurmClient,
+		SlurmHTTPClient: slurmHTTPClient,
+		EventCh:         make(chan event.GenericEvent, 100),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Pod")
 		os.Exit(1)
 	}
+	if err = (&allocationpool.AllocationPoolReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		MCSLabel:    cfg.MCSLabel,
+		Partition:   cfg.Partition,
+		Policies:    cfg.Policies,
+		SlurmClient: slurmClient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AllocationPool")
+		os.Exit(1)
+	}
+	if err = (&slurmjob.SlurmJobReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		MCSLabel:          cfg.MCSLabel,
+		Partition:         cfg.Partition,
+		NamespaceDefaults: cfg.NamespaceDefaults,
+		Policies:          cfg.Policies,
+		Impersonate:       cfg.SlurmJwt.KeySecretRef != nil,
+		SlurmClient:       slurmClient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SlurmJob")
+		os.Exit(1)
+	}
+	if err = (&slurmnode.SlurmNodeReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		SlurmClient: slurmClient,
+		EventCh:     slurmNodeEventCh,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SlurmNode")
+		os.Exit(1)
+	}
+	if err = (&slurmpartition.SlurmPartitionReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		SlurmClient: slurmClient,
+		EventCh:     make(chan event.GenericEvent, 100),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SlurmPartition")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"slices"
 
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/metrics"
 	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -25,6 +27,7 @@ type PodAdmission struct {
 	SchedulerName            string
 	ManagedNamespaces        []string
 	ManagedNamespaceSelector *metav1.LabelSelector
+	Policies                 []config.Policy
 }
 
 func (r *PodAdmission) SetupWebhookWithManager(mgr ctrl.Manager) error {
@@ -56,6 +59,7 @@ func (r *PodAdmission) Default(ctx context.Context, obj runtime.Object) error {
 	if pod.Spec.SchedulerName == corev1.DefaultSchedulerName {
 		pod.Spec.SchedulerName = r.SchedulerName
 	}
+	applyPolicyDefaults(pod, r.policyFor(pod))
 	return nil
 }
 
@@ -77,13 +81,22 @@ func (r *PodAdmission) ValidateCreate(ctx context.Context, obj runtime.Object) (
 	if !isManaged {
 		return nil, nil
 	}
+	err = validatePodCreate(pod)
+	if err == nil {
+		err = validatePolicy(pod, nil, r.policyFor(pod))
+	}
+	recordDecision("create", pod, err)
+	return nil, err
+}
+
+func validatePodCreate(pod *corev1.Pod) error {
 	if pod.Labels[wellknown.LabelPlaceholderJobId] != "" {
-		return nil, fmt.Errorf("can't create a pod with a slurm placeholder jobid label")
+		return fmt.Errorf("can't create a pod with a slurm placeholder jobid label")
 	}
 	if pod.Annotations[wellknown.AnnotationPlaceholderNode] != "" {
-		return nil, fmt.Errorf("can't create a pod with a slurm placeholder node annotation")
+		return fmt.Errorf("can't create a pod with a slurm placeholder node annotation")
 	}
-	return nil, nil
+	return nil
 }
 
 func (r *PodAdmission) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
@@ -98,19 +111,38 @@ func (r *PodAdmission) ValidateUpdate(ctx context.Context, oldObj runtime.Object
 	if !isManaged {
 		return nil, nil
 	}
+	err = validatePodUpdate(newPod, oldPod)
+	if err == nil {
+		err = validatePolicy(newPod, oldPod, r.policyFor(newPod))
+	}
+	recordDecision("update", newPod, err)
+	return nil, err
+}
+
+func validatePodUpdate(newPod, oldPod *corev1.Pod) error {
 	// Once a pod has been placed by the Slurm bridge scheduler the jobid and
 	// node annotations should not be modified.
 	if newPod.Status.Phase == corev1.PodRunning {
 		if newPod.Labels[wellknown.LabelPlaceholderJobId] !=
 			oldPod.Labels[wellknown.LabelPlaceholderJobId] {
-			return nil, fmt.Errorf("can't update a running pod's placeholder jobid label")
+			return fmt.Errorf("can't update a running pod's placeholder jobid label")
 		}
 		if newPod.Annotations[wellknown.AnnotationPlaceholderNode] !=
 			oldPod.Annotations[wellknown.AnnotationPlaceholderNode] {
-			return nil, fmt.Errorf("can't update a running pod's placeholder node annotation")
+			return fmt.Errorf("can't update a running pod's placeholder node annotation")
 		}
 	}
-	return nil, nil
+	return nil
+}
+
+// recordDecision records the admission decision for a pod in a managed
+// namespace.
+func recordDecision(operation string, pod *corev1.Pod, err error) {
+	decision := metrics.DecisionAllowed
+	if err != nil {
+		decision = metrics.DecisionDenied
+	}
+	metrics.AdmissionDecisions.WithLabelValues(operation, decision, metrics.WorkloadKind(pod)).Inc()
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
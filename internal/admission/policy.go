@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package admission
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+// policyFallbacks return the value of an annotation restricted by a policy
+// which is used when the pod does not set it.
+var policyFallbacks = map[string]func(pod *corev1.Pod) *int64{
+	wellknown.AnnotationUserId:  utils.PodRunAsUser,
+	wellknown.AnnotationGroupId: utils.PodRunAsGroup,
+}
+
+// policyFor returns the first policy which applies to the pod, or nil if
+// none does.
+func (r *PodAdmission) policyFor(pod *corev1.Pod) *config.Policy {
+	if pod.Spec.SchedulerName != r.SchedulerName {
+		return nil
+	}
+	return config.PolicyFor(r.Policies, pod.Namespace, utils.PodServiceAccountName(pod))
+}
+
+// applyPolicyDefaults sets the default value of each annotation restricted by
+// the policy which the pod does not set.
+func applyPolicyDefaults(pod *corev1.Pod, policy *config.Policy) {
+	if policy == nil {
+		return
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	policy.ApplyDefaults(pod.Annotations)
+	if len(pod.Annotations) == 0 {
+		pod.Annotations = nil
+	}
+}
+
+// validatePolicy returns an error for each annotation of the pod with a value
+// which the policy does not allow. The user and group which the pod runs as
+// are validated when their annotation is not set. When a pod is updated, only
+// the annotations which changed from the old pod are validated.
+func validatePolicy(pod, oldPod *corev1.Pod, policy *config.Policy) error {
+	if policy == nil {
+		return nil
+	}
+	errs := []error{}
+	for _, rule := range policy.Rules() {
+		value, ok := policyValue(pod, rule.Annotation)
+		if !ok {
+			continue
+		}
+		if oldPod != nil {
+			if oldValue, _ := policyValue(oldPod, rule.Annotation); oldValue == value {
+				continue
+			}
+		}
+		for _, v := range rule.Disallowed(value) {
+			errs = append(errs, fmt.Errorf("%s %q is not allowed for ServiceAccount %s/%s, allowed values are: %s",
+				rule.Annotation, v, pod.Namespace, utils.PodServiceAccountName(pod), strings.Join(rule.Allowed, ", ")))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// policyValue returns the value of the annotation, or its fallback value.
+func policyValue(pod *corev1.Pod, annotation string) (string, bool) {
+	if value, ok := pod.Annotations[annotation]; ok {
+		return value, true
+	}
+	if fallback, ok := policyFallbacks[annotation]; ok {
+		if id := fallback(pod); id != nil {
+			return strconv.FormatInt(*id, 10), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package admission
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+func newPolicyPod(namespace, serviceAccount string, annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Spec: corev1.PodSpec{
+			SchedulerName:      SchedulerName,
+			ServiceAccountName: serviceAccount,
+		},
+	}
+}
+
+func TestPodAdmission_policyFor(t *testing.T) {
+	policies := []config.Policy{
+		{
+			Namespaces:      []string{"team-a"},
+			ServiceAccounts: []string{"trainer"},
+			Account:         config.PolicyRule{Default: "trainer"},
+		},
+		{
+			Namespaces: []string{"team-a"},
+			Account:    config.PolicyRule{Default: "team-a"},
+		},
+		{
+			Account: config.PolicyRule{Default: "other"},
+		},
+	}
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want *config.Policy
+	}{
+		{
+			name: "ServiceAccount",
+			pod:  newPolicyPod("team-a", "trainer", nil),
+			want: &policies[0],
+		},
+		{
+			name: "Namespace",
+			pod:  newPolicyPod("team-a", "", nil),
+			want: &policies[1],
+		},
+		{
+			name: "Any",
+			pod:  newPolicyPod("team-b", "trainer", nil),
+			want: &policies[2],
+		},
+		{
+			name: "Other scheduler",
+			pod: func() *corev1.Pod {
+				pod := newPolicyPod("team-a", "trainer", nil)
+				pod.Spec.SchedulerName = corev1.DefaultSchedulerName
+				return pod
+			}(),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &PodAdmission{
+				SchedulerName: SchedulerName,
+				Policies:      policies,
+			}
+			if got := r.policyFor(tt.pod); got != tt.want {
+				t.Errorf("PodAdmission.policyFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_applyPolicyDefaults(t *testing.T) {
+	policy := &config.Policy{
+		UserId:  config.PolicyRule{Default: "1000"},
+		Account: config.PolicyRule{Allowed: []string{"team-a", "team-b"}, Default: "team-a"},
+	}
+	tests := []struct {
+		name   string
+		pod    *corev1.Pod
+		policy *config.Policy
+		want   map[string]string
+	}{
+		{
+			name:   "No policy",
+			pod:    newPolicyPod("team-a", "", nil),
+			policy: nil,
+			want:   nil,
+		},
+		{
+			name:   "Defaults",
+			pod:    newPolicyPod("team-a", "", nil),
+			policy: policy,
+			want: map[string]string{
+				wellknown.AnnotationUserId:  "1000",
+				wellknown.AnnotationAccount: "team-a",
+			},
+		},
+		{
+			name: "Annotation is kept",
+			pod: newPolicyPod("team-a", "", map[string]string{
+				wellknown.AnnotationAccount: "team-b",
+			}),
+			policy: policy,
+			want: map[string]string{
+				wellknown.AnnotationUserId:  "1000",
+				wellknown.AnnotationAccount: "team-b",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applyPolicyDefaults(tt.pod, tt.policy)
+			if !apiequality.Semantic.DeepEqual(tt.pod.Annotations, tt.want) {
+				t.Errorf("applyPolicyDefaults() = %v, want %v", tt.pod.Annotations, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validatePolicy(t *testing.T) {
+	policy := &config.Policy{
+		UserId:    config.PolicyRule{Allowed: []string{"1000"}},
+		Account:   config.PolicyRule{Allowed: []string{"team-a"}},
+		QOS:       config.PolicyRule{},
+		Partition: config.PolicyRule{Allowed: []string{"gpu", "debug"}},
+	}
+	tests := []struct {
+		name    string
+		pod     *corev1.Pod
+		oldPod  *corev1.Pod
+		policy  *config.Policy
+		wantErr bool
+	}{
+		{
+			name: "No policy",
+			pod: newPolicyPod("team-a", "", map[string]string{
+				wellknown.AnnotationUserId: "0",
+			}),
+			policy:  nil,
+			wantErr: false,
+		},
+		{
+			name: "Allowed",
+			pod: newPolicyPod("team-a", "", map[string]string{
+				wellknown.AnnotationUserId:    "1000",
+				wellknown.AnnotationAccount:   "team-a",
+				wellknown.AnnotationQOS:       "high",
+				wellknown.AnnotationPartition: "gpu,debug",
+			}),
+			policy:  policy,
+			wantErr: false,
+		},
+		{
+			name:    "Unset",
+			pod:     newPolicyPod("team-a", "", nil),
+			policy:  policy,
+			wantErr: false,
+		},
+		{
+			name: "User not allowed",
+			pod: newPolicyPod("team-a", "", map[string]string{
+				wellknown.AnnotationUserId: "0",
+			}),
+			policy:  policy,
+			wantErr: true,
+		},
+		{
+			name: "Account not allowed",
+			pod: newPolicyPod("team-a", "", map[string]string{
+				wellknown.AnnotationAccount: "team-b",
+			}),
+			policy:  policy,
+			wantErr: true,
+		},
+		{
+			name: "Partition not allowed",
+			pod: newPolicyPod("team-a", "", map[string]string{
+				wellknown.AnnotationPartition: "gpu,cpu",
+			}),
+			policy:  policy,
+			wantErr: true,
+		},
+		{
+			name: "RunAsUser not allowed",
+			pod: func() *corev1.Pod {
+				pod := newPolicyPod("team-a", "", nil)
+				pod.Spec.SecurityContext = &corev1.PodSecurityContext{RunAsUser: ptr.To[int64](0)}
+				return pod
+			}(),
+			policy:  policy,
+			wantErr: true,
+		},
+		{
+			name: "Annotation takes precedence over RunAsUser",
+			pod: func() *corev1.Pod {
+				pod := newPolicyPod("team-a", "", map[string]string{
+					wellknown.AnnotationUserId: "1000",
+				})
+				pod.Spec.SecurityContext = &corev1.PodSecurityContext{RunAsUser: ptr.To[int64](0)}
+				return pod
+			}(),
+			policy:  policy,
+			wantErr: false,
+		},
+		{
+			name: "Unchanged on update",
+			pod: newPolicyPod("team-a", "", map[string]string{
+				wellknown.AnnotationAccount: "team-b",
+			}),
+			oldPod: newPolicyPod("team-a", "", map[string]string{
+				wellknown.AnnotationAccount: "team-b",
+			}),
+			policy:  policy,
+			wantErr: false,
+		},
+		{
+			name: "Changed on update",
+			pod: newPolicyPod("team-a", "", map[string]string{
+				wellknown.AnnotationAccount: "team-b",
+			}),
+			oldPod: newPolicyPod("team-a", "", map[string]string{
+				wellknown.AnnotationAccount: "team-a",
+			}),
+			policy:  policy,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePolicy(tt.pod, tt.oldPod, tt.policy); (err != nil) != tt.wantErr {
+				t.Errorf("validatePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package admission
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjobir"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SlurmJobAdmission validates the identity of SlurmJobs, whose batch script
+// is submitted with the token of the bridge.
+type SlurmJobAdmission struct {
+	client.Client
+	NamespaceDefaults []config.NamespaceDefaults
+	Policies          []config.Policy
+}
+
+func (r *SlurmJobAdmission) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.SlurmJob{}).
+		WithValidator(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-bridge-slinky-slurm-net-v1alpha1-slurmjob,mutating=false,failurePolicy=fail,sideEffects=None,groups=bridge.slinky.slurm.net,resources=slurmjobs,verbs=create;update,versions=v1alpha1,name=vslurmjob.kb.io,admissionReviewVersions=v1
+
+var _ webhook.CustomValidator = &SlurmJobAdmission{}
+
+func (r *SlurmJobAdmission) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	logger := log.FromContext(ctx)
+	slurmJob, ok := obj.(*v1alpha1.SlurmJob)
+	if !ok {
+		return nil, fmt.Errorf("expected a SlurmJob but got a %T", obj)
+	}
+	logger.V(1).Info("ValidateCreate", "slurmJob", klog.KObj(slurmJob))
+	return nil, r.validateSlurmJob(ctx, slurmJob)
+}
+
+func (r *SlurmJobAdmission) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	logger := log.FromContext(ctx)
+	newSlurmJob := newObj.(*v1alpha1.SlurmJob)
+	oldSlurmJob := oldObj.(*v1alpha1.SlurmJob)
+	logger.V(1).Info("ValidateUpdate", "newSlurmJob", klog.KObj(newSlurmJob), "oldSlurmJob", klog.KObj(oldSlurmJob))
+	// The finalizer of a SlurmJob must be removable even if the policies
+	// changed since it was created.
+	if apiequality.Semantic.DeepEqual(newSlurmJob.Annotations, oldSlurmJob.Annotations) {
+		return nil, nil
+	}
+	return nil, r.validateSlurmJob(ctx, newSlurmJob)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *SlurmJobAdmission) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateSlurmJob returns an error unless the annotations of the SlurmJob
+// resolve to a user which the policy of its namespace allows. A SlurmJob has
+// no ServiceAccount, so the policy of the default ServiceAccount applies.
+func (r *SlurmJobAdmission) validateSlurmJob(ctx context.Context, slurmJob *v1alpha1.SlurmJob) error {
+	jobInfo, err := slurmjobir.TranslateAnnotationsToJobInfo(r.Client, ctx, slurmJob, r.NamespaceDefaults)
+	if err != nil {
+		return fmt.Errorf("invalid annotations: %w", err)
+	}
+	policy := config.PolicyFor(r.Policies, slurmJob.Namespace, "default")
+	if err := slurmjobir.ApplyUserPolicy(jobInfo, policy); err != nil {
+		return fmt.Errorf("SlurmJob %s/%s is not allowed for ServiceAccount %s/default: %w",
+			slurmJob.Namespace, slurmJob.Name, slurmJob.Namespace, err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package admission
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+func newSlurmJob(namespace string, annotations map[string]string) *v1alpha1.SlurmJob {
+	return &v1alpha1.SlurmJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "hello",
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Spec: v1alpha1.SlurmJobSpec{
+			Script: "#!/bin/sh\nhostname\n",
+		},
+	}
+}
+
+func newSlurmJobAdmission(t *testing.T, policies []config.Policy) *SlurmJobAdmission {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return &SlurmJobAdmission{
+		Client:   fake.NewClientBuilder().WithScheme(s).Build(),
+		Policies: policies,
+	}
+}
+
+func TestSlurmJobAdmission_ValidateCreate(t *testing.T) {
+	policies := []config.Policy{
+		{
+			Namespaces: []string{"team-a"},
+			UserId:     config.PolicyRule{Allowed: []string{"1000", "1001"}, Default: "1000"},
+			Account:    config.PolicyRule{Allowed: []string{"team-a"}},
+		},
+		{
+			Namespaces: []string{"team-b"},
+			UserId:     config.PolicyRule{Allowed: []string{"2000"}},
+		},
+		{
+			Namespaces: []string{"team-c"},
+			Account:    config.PolicyRule{Allowed: []string{"team-c"}},
+		},
+	}
+	tests := []struct {
+		name     string
+		slurmJob *v1alpha1.SlurmJob
+		wantErr  bool
+	}{
+		{
+			name:     "Default user",
+			slurmJob: newSlurmJob("team-a", nil),
+			wantErr:  false,
+		},
+		{
+			name: "Allowed",
+			slurmJob: newSlurmJob("team-a", map[string]string{
+				wellknown.AnnotationUserId:  "1001",
+				wellknown.AnnotationAccount: "team-a",
+			}),
+			wantErr: false,
+		},
+		{
+			name: "User not allowed",
+			slurmJob: newSlurmJob("team-a", map[string]string{
+				wellknown.AnnotationUserId: "0",
+			}),
+			wantErr: true,
+		},
+		{
+			name: "Account not allowed",
+			slurmJob: newSlurmJob("team-a", map[string]string{
+				wellknown.AnnotationAccount: "team-b",
+			}),
+			wantErr: true,
+		},
+		{
+			name:     "No user",
+			slurmJob: newSlurmJob("team-b", nil),
+			wantErr:  true,
+		},
+		{
+			name: "User not restricted by the policy",
+			slurmJob: newSlurmJob("team-c", map[string]string{
+				wellknown.AnnotationUserId: "1000",
+			}),
+			wantErr: true,
+		},
+		{
+			name: "No policy",
+			slurmJob: newSlurmJob("team-d", map[string]string{
+				wellknown.AnnotationUserId: "1000",
+			}),
+			wantErr: true,
+		},
+		{
+			name: "Invalid annotations",
+			slurmJob: newSlurmJob("team-a", map[string]string{
+				wellknown.AnnotationTimeLimit: "foo",
+			}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newSlurmJobAdmission(t, policies)
+			if _, err := r.ValidateCreate(context.Background(), tt.slurmJob); (err != nil) != tt.wantErr {
+				t.Errorf("SlurmJobAdmission.ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSlurmJobAdmission_ValidateUpdate(t *testing.T) {
+	policies := []config.Policy{
+		{
+			Namespaces: []string{"team-a"},
+			UserId:     config.PolicyRule{Allowed: []string{"1000"}},
+		},
+	}
+	tests := []struct {
+		name        string
+		oldSlurmJob *v1alpha1.SlurmJob
+		newSlurmJob *v1alpha1.SlurmJob
+		wantErr     bool
+	}{
+		{
+			name:        "Annotations unchanged",
+			oldSlurmJob: newSlurmJob("team-a", map[string]string{wellknown.AnnotationUserId: "0"}),
+			newSlurmJob: func() *v1alpha1.SlurmJob {
+				slurmJob := newSlurmJob("team-a", map[string]string{wellknown.AnnotationUserId: "0"})
+				slurmJob.Finalizers = []string{wellknown.FinalizerSlurmJob}
+				return slurmJob
+			}(),
+			wantErr: false,
+		},
+		{
+			name:        "User allowed",
+			oldSlurmJob: newSlurmJob("team-a", nil),
+			newSlurmJob: newSlurmJob("team-a", map[string]string{wellknown.AnnotationUserId: "1000"}),
+			wantErr:     false,
+		},
+		{
+			name:        "User not allowed",
+			oldSlurmJob: newSlurmJob("team-a", map[string]string{wellknown.AnnotationUserId: "1000"}),
+			newSlurmJob: newSlurmJob("team-a", map[string]string{wellknown.AnnotationUserId: "0"}),
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newSlurmJobAdmission(t, policies)
+			if _, err := r.ValidateUpdate(context.Background(), tt.oldSlurmJob, tt.newSlurmJob); (err != nil) != tt.wantErr {
+				t.Errorf("SlurmJobAdmission.ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -15,10 +15,119 @@ const (
 type Config struct {
 	SchedulerName            string                `yaml:"schedulerName"`
 	SlurmRestApi             string                `yaml:"slurmRestApi"`
+	SlurmJwt                 SlurmJwt              `yaml:"slurmJwt"`
 	ManagedNamespaces        []string              `yaml:"managedNamespaces"`
 	ManagedNamespaceSelector *metav1.LabelSelector `yaml:"managedNamespaceSelector"`
 	MCSLabel                 string                `yaml:"mcsLabel"`
 	Partition                string                `yaml:"partition"`
+	Shared                   string                `yaml:"shared"`
+	PermitTimeoutSeconds     int64                 `yaml:"permitTimeoutSeconds"`
+	GresMappings             []GresMapping         `yaml:"gresMappings"`
+	Teardown                 Teardown              `yaml:"teardown"`
+	Policies                 []Policy              `yaml:"policies"`
+	NamespaceDefaults        []NamespaceDefaults   `yaml:"namespaceDefaults"`
+}
+
+// SlurmJwt configures where the Slurm REST API token is read from. The token
+// is reloaded when its source changes. If no source is set, the token is read
+// once from the SLURM_JWT environment variable.
+type SlurmJwt struct {
+	// File is the path of a file containing the token (e.g. a mounted Secret).
+	File string `yaml:"file"`
+	// SecretRef references the key of a Secret containing the token.
+	SecretRef *SecretKeyRef `yaml:"secretRef"`
+	// KeySecretRef references the key of a Secret containing the Slurm
+	// jwt_hs256.key. Short-lived tokens are minted for Username, and
+	// placeholder jobs are submitted as their user through impersonation.
+	KeySecretRef *SecretKeyRef `yaml:"keySecretRef"`
+	// Username is the user which minted tokens are issued for. It must be the
+	// SlurmUser or root to impersonate other users. Defaults to slurm.
+	Username string `yaml:"username"`
+	// LifetimeSeconds is how long a minted token is valid. Defaults to 300.
+	LifetimeSeconds int64 `yaml:"lifetimeSeconds"`
+}
+
+// SecretKeyRef references a key of a Secret.
+type SecretKeyRef struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+	Key       string `yaml:"key"`
+}
+
+// Teardown configures how pods and Slurm jobs are terminated once either ends.
+type Teardown struct {
+	// Evict terminates pods through the Eviction API instead of deleting them.
+	Evict bool `yaml:"evict"`
+	// Signal is sent to a Slurm job before it is cancelled (e.g. SIGTERM). If
+	// empty, the job is cancelled immediately.
+	Signal string `yaml:"signal"`
+	// SignalGracePeriodSeconds is how long a signaled Slurm job has to end
+	// before it is cancelled.
+	SignalGracePeriodSeconds int64 `yaml:"signalGracePeriodSeconds"`
+}
+
+// NamespaceDefaults sets default Slurm annotations for the workloads of a
+// namespace. The annotations of the namespace override them, and the
+// annotations of the workload override both.
+type NamespaceDefaults struct {
+	// Namespace the defaults apply to. If empty, they apply to all namespaces
+	// and are overridden by the defaults of a specific namespace.
+	Namespace string `yaml:"namespace"`
+	// Annotations are the default annotations (e.g. slinky.slurm.net/account).
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// Policy restricts the Slurm identity and resources which the pods of a set of
+// namespaces and ServiceAccounts may request through annotations. A pod is
+// subject to the first policy which matches it.
+type Policy struct {
+	// Namespaces the policy applies to. If empty, it applies to all.
+	Namespaces []string `yaml:"namespaces"`
+	// ServiceAccounts the policy applies to, by name within the pod's
+	// namespace. If empty, it applies to all.
+	ServiceAccounts []string `yaml:"serviceAccounts"`
+	// UserId restricts the slinky.slurm.net/user-id annotation.
+	UserId PolicyRule `yaml:"userId"`
+	// GroupId restricts the slinky.slurm.net/group-id annotation.
+	GroupId PolicyRule `yaml:"groupId"`
+	// Account restricts the slinky.slurm.net/account annotation.
+	Account PolicyRule `yaml:"account"`
+	// QOS restricts the slinky.slurm.net/qos annotation.
+	QOS PolicyRule `yaml:"qos"`
+	// Partition restricts the slinky.slurm.net/partition annotation.
+	Partition PolicyRule `yaml:"partition"`
+}
+
+// PolicyRule restricts the value of an annotation.
+type PolicyRule struct {
+	// Allowed are the values the annotation may have. If empty, any value is
+	// allowed.
+	Allowed []string `yaml:"allowed"`
+	// Default is set as the value of the annotation when a pod does not set
+	// it.
+	Default string `yaml:"default"`
+}
+
+// GresMapping maps a Kubernetes extended resource, or the devices of a DRA
+// DeviceClass, to a Slurm GRES.
+type GresMapping struct {
+	// ResourceName is the Kubernetes extended resource (e.g. nvidia.com/gpu).
+	ResourceName string `yaml:"resourceName"`
+	// DeviceClassName is the DRA DeviceClass (e.g. gpu.nvidia.com).
+	DeviceClassName string `yaml:"deviceClassName"`
+	// Name is the Slurm GRES name (e.g. gpu).
+	Name string `yaml:"name"`
+	// Type is the optional Slurm GRES type (e.g. a100).
+	Type string `yaml:"type"`
+	// Multiplier scales the resource quantity into the GRES count. A value
+	// of zero is treated as one.
+	Multiplier int64 `yaml:"multiplier"`
+}
+
+// DefaultGresMappings are applied unless the config maps the same resource.
+var DefaultGresMappings = []GresMapping{
+	{ResourceName: "nvidia.com/gpu", Name: "gpu"},
+	{ResourceName: "amd.com/gpu", Name: "gpu"},
 }
 
 func Unmarshal(in []byte) (*Config, error) {
@@ -80,6 +80,70 @@ func TestUnmarshal(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Test shared",
+			args: args{
+				in: []byte(`shared: oversubscribe`),
+			},
+			want: &Config{
+				Shared: "oversubscribe",
+			},
+			wantErr: false,
+		},
+		{
+			name: "Test permitTimeoutSeconds",
+			args: args{
+				in: []byte(`permitTimeoutSeconds: 120`),
+			},
+			want: &Config{
+				PermitTimeoutSeconds: 120,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Test gresMappings",
+			args: args{
+				in: []byte(`
+gresMappings:
+- resourceName: nvidia.com/mig-1g.10gb
+  name: gpu
+  type: 1g.10gb
+- resourceName: habana.ai/gaudi
+  name: gpu
+  type: gaudi
+  multiplier: 2
+- deviceClassName: gpu.nvidia.com
+  name: gpu
+`),
+			},
+			want: &Config{
+				GresMappings: []GresMapping{
+					{ResourceName: "nvidia.com/mig-1g.10gb", Name: "gpu", Type: "1g.10gb"},
+					{ResourceName: "habana.ai/gaudi", Name: "gpu", Type: "gaudi", Multiplier: 2},
+					{DeviceClassName: "gpu.nvidia.com", Name: "gpu"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Test teardown",
+			args: args{
+				in: []byte(`
+teardown:
+  evict: true
+  signal: SIGTERM
+  signalGracePeriodSeconds: 30
+`),
+			},
+			want: &Config{
+				Teardown: Teardown{
+					Evict:                    true,
+					Signal:                   "SIGTERM",
+					SignalGracePeriodSeconds: 30,
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "Test managedNamespaceSelector",
 			args: args{
@@ -96,6 +160,42 @@ managedNamespaceSelector:
 			},
 			wantErr: false,
 		},
+		{
+			name: "Test policies",
+			args: args{
+				in: []byte(`
+policies:
+- namespaces:
+  - team-a
+  serviceAccounts:
+  - trainer
+  account:
+    allowed:
+    - team-a
+    default: team-a
+  partition:
+    allowed:
+    - gpu
+    - debug
+`),
+			},
+			want: &Config{
+				Policies: []Policy{
+					{
+						Namespaces:      []string{"team-a"},
+						ServiceAccounts: []string{"trainer"},
+						Account: PolicyRule{
+							Allowed: []string{"team-a"},
+							Default: "team-a",
+						},
+						Partition: PolicyRule{
+							Allowed: []string{"gpu", "debug"},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+var ErrorPolicyNotAllowed = errors.New("not allowed")
+
+// AnnotationRule is a rule of a policy and the annotation it restricts.
+type AnnotationRule struct {
+	PolicyRule
+	Annotation string
+	// List is true if the annotation holds a comma separated list.
+	List bool
+}
+
+// Rules returns the rules of the policy with the annotations they restrict.
+func (p *Policy) Rules() []AnnotationRule {
+	return []AnnotationRule{
+		{PolicyRule: p.UserId, Annotation: wellknown.AnnotationUserId},
+		{PolicyRule: p.GroupId, Annotation: wellknown.AnnotationGroupId},
+		{PolicyRule: p.Account, Annotation: wellknown.AnnotationAccount},
+		{PolicyRule: p.QOS, Annotation: wellknown.AnnotationQOS},
+		{PolicyRule: p.Partition, Annotation: wellknown.AnnotationPartition, List: true},
+	}
+}
+
+// Disallowed returns the values of the annotation which the rule does not
+// allow.
+func (r AnnotationRule) Disallowed(value string) []string {
+	if len(r.Allowed) == 0 {
+		return nil
+	}
+	values := []string{value}
+	if r.List {
+		values = strings.Split(value, ",")
+	}
+	return slices.DeleteFunc(values, func(v string) bool {
+		return slices.Contains(r.Allowed, v)
+	})
+}
+
+// PinsUser returns true if the policy restricts the users which its workloads
+// run as, so the user of a workload which passed the policy was granted by the
+// configuration rather than chosen by the author of the workload.
+func (p *Policy) PinsUser() bool {
+	return p != nil && len(p.UserId.Allowed) > 0
+}
+
+// ApplyDefaults sets the default value of each annotation restricted by the
+// policy which is not set.
+func (p *Policy) ApplyDefaults(annotations map[string]string) {
+	if p == nil {
+		return
+	}
+	for _, r := range p.Rules() {
+		if r.Default == "" || annotations[r.Annotation] != "" {
+			continue
+		}
+		annotations[r.Annotation] = r.Default
+	}
+}
+
+// Validate returns an error for each annotation with a value which the policy
+// does not allow.
+func (p *Policy) Validate(annotations map[string]string) error {
+	if p == nil {
+		return nil
+	}
+	errs := []error{}
+	for _, r := range p.Rules() {
+		value, ok := annotations[r.Annotation]
+		if !ok {
+			continue
+		}
+		for _, v := range r.Disallowed(value) {
+			errs = append(errs, fmt.Errorf("%s %q is %w, allowed values are: %s",
+				r.Annotation, v, ErrorPolicyNotAllowed, strings.Join(r.Allowed, ", ")))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// PolicyFor returns the first policy which applies to the ServiceAccount of
+// the namespace, or nil if none does.
+func PolicyFor(policies []Policy, namespace, serviceAccount string) *Policy {
+	for i := range policies {
+		policy := &policies[i]
+		if len(policy.Namespaces) > 0 && !slices.Contains(policy.Namespaces, namespace) {
+			continue
+		}
+		if len(policy.ServiceAccounts) > 0 && !slices.Contains(policy.ServiceAccounts, serviceAccount) {
+			continue
+		}
+		return policy
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+func TestPolicyFor(t *testing.T) {
+	policies := []Policy{
+		{
+			Namespaces:      []string{"team-a"},
+			ServiceAccounts: []string{"trainer"},
+		},
+		{
+			Namespaces: []string{"team-a"},
+		},
+		{},
+	}
+	tests := []struct {
+		name           string
+		namespace      string
+		serviceAccount string
+		policies       []Policy
+		want           *Policy
+	}{
+		{
+			name:           "ServiceAccount",
+			namespace:      "team-a",
+			serviceAccount: "trainer",
+			policies:       policies,
+			want:           &policies[0],
+		},
+		{
+			name:           "Namespace",
+			namespace:      "team-a",
+			serviceAccount: "default",
+			policies:       policies,
+			want:           &policies[1],
+		},
+		{
+			name:           "Any",
+			namespace:      "team-b",
+			serviceAccount: "trainer",
+			policies:       policies,
+			want:           &policies[2],
+		},
+		{
+			name:           "None",
+			namespace:      "team-b",
+			serviceAccount: "trainer",
+			policies:       policies[:2],
+			want:           nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PolicyFor(tt.policies, tt.namespace, tt.serviceAccount); got != tt.want {
+				t.Errorf("PolicyFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_PinsUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *Policy
+		want   bool
+	}{
+		{
+			name:   "No policy",
+			policy: nil,
+			want:   false,
+		},
+		{
+			name:   "Default only",
+			policy: &Policy{UserId: PolicyRule{Default: "1000"}},
+			want:   false,
+		},
+		{
+			name:   "Allowed",
+			policy: &Policy{UserId: PolicyRule{Allowed: []string{"1000"}}},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.PinsUser(); got != tt.want {
+				t.Errorf("Policy.PinsUser() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_ApplyDefaults(t *testing.T) {
+	policy := &Policy{
+		UserId:  PolicyRule{Default: "1000"},
+		Account: PolicyRule{Allowed: []string{"team-a", "team-b"}, Default: "team-a"},
+	}
+	tests := []struct {
+		name        string
+		policy      *Policy
+		annotations map[string]string
+		want        map[string]string
+	}{
+		{
+			name:        "No policy",
+			policy:      nil,
+			annotations: map[string]string{},
+			want:        map[string]string{},
+		},
+		{
+			name:        "Defaults",
+			policy:      policy,
+			annotations: map[string]string{},
+			want: map[string]string{
+				wellknown.AnnotationUserId:  "1000",
+				wellknown.AnnotationAccount: "team-a",
+			},
+		},
+		{
+			name:   "Annotation is kept",
+			policy: policy,
+			annotations: map[string]string{
+				wellknown.AnnotationAccount: "team-b",
+			},
+			want: map[string]string{
+				wellknown.AnnotationUserId:  "1000",
+				wellknown.AnnotationAccount: "team-b",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.policy.ApplyDefaults(tt.annotations)
+			if !apiequality.Semantic.DeepEqual(tt.annotations, tt.want) {
+				t.Errorf("Policy.ApplyDefaults() = %v, want %v", tt.annotations, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	policy := &Policy{
+		UserId:    PolicyRule{Allowed: []string{"1000"}},
+		Account:   PolicyRule{Allowed: []string{"team-a"}},
+		Partition: PolicyRule{Allowed: []string{"gpu", "debug"}},
+	}
+	tests := []struct {
+		name        string
+		policy      *Policy
+		annotations map[string]string
+		wantErr     bool
+	}{
+		{
+			name:   "No policy",
+			policy: nil,
+			annotations: map[string]string{
+				wellknown.AnnotationUserId: "0",
+			},
+			wantErr: false,
+		},
+		{
+			name:   "Allowed",
+			policy: policy,
+			annotations: map[string]string{
+				wellknown.AnnotationUserId:    "1000",
+				wellknown.AnnotationAccount:   "team-a",
+				wellknown.AnnotationQOS:       "high",
+				wellknown.AnnotationPartition: "gpu,debug",
+			},
+			wantErr: false,
+		},
+		{
+			name:        "Unset",
+			policy:      policy,
+			annotations: map[string]string{},
+			wantErr:     false,
+		},
+		{
+			name:   "User not allowed",
+			policy: policy,
+			annotations: map[string]string{
+				wellknown.AnnotationUserId: "0",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Partition not allowed",
+			policy: policy,
+			annotations: map[string]string{
+				wellknown.AnnotationPartition: "gpu,cpu",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Policy.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrorPolicyNotAllowed) {
+				t.Errorf("Policy.Validate() error = %v, want %v", err, ErrorPolicyNotAllowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package allocationpool
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/allocationpool/slurmcontrol"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/durationstore"
+)
+
+const (
+	// SyncInterval is how often a pool is synced with Slurm. The Slurm job
+	// informer is owned by the pod controller, and the end of the pods which
+	// claimed an allocation does not change its Slurm job.
+	SyncInterval = 30 * time.Second
+)
+
+func init() {
+	flag.IntVar(&maxConcurrentReconciles, "allocationpool-workers", maxConcurrentReconciles, "Max concurrent workers for AllocationPool controller.")
+}
+
+var (
+	maxConcurrentReconciles = 1
+
+	// this is a short cut for any sub-functions to notify the reconcile how long to wait to requeue
+	durationStore = durationstore.NewDurationStore(durationstore.Less)
+)
+
+// AllocationPoolReconciler reconciles an AllocationPool object
+type AllocationPoolReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	MCSLabel  string
+	Partition string
+	// Policies restrict the account, QOS and partition of the pools.
+	Policies    []config.Policy
+	SlurmClient slurmclient.Client
+
+	slurmControl  slurmcontrol.SlurmControlInterface
+	eventRecorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=bridge.slinky.slurm.net,resources=allocationpools,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=bridge.slinky.slurm.net,resources=allocationpools/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=bridge.slinky.slurm.net,resources=allocationpools/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *AllocationPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, retErr error) {
+	logger := log.FromContext(ctx)
+
+	logger.Info("Started syncing AllocationPool", "request", req)
+
+	startTime := time.Now()
+	defer func() {
+		if retErr == nil {
+			if res.RequeueAfter > 0 {
+				logger.Info("Finished syncing AllocationPool", "duration", time.Since(startTime), "result", res)
+			} else {
+				logger.Info("Finished syncing AllocationPool", "duration", time.Since(startTime))
+			}
+		} else {
+			logger.Info("Finished syncing AllocationPool", "duration", time.Since(startTime), "error", retErr)
+		}
+		// clean the duration store
+		_ = durationStore.Pop(req.String())
+	}()
+
+	retErr = r.Sync(ctx, req)
+	res = reconcile.Result{
+		RequeueAfter: durationStore.Pop(req.String()),
+	}
+	return res, retErr
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AllocationPoolReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.eventRecorder == nil {
+		r.eventRecorder = mgr.GetEventRecorderFor("allocationpool-controller")
+	}
+	r.setupInternal()
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("allocationpool-controller").
+		For(&v1alpha1.AllocationPool{}).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: maxConcurrentReconciles,
+		}).
+		Complete(r)
+}
+
+func (r *AllocationPoolReconciler) setupInternal() {
+	if r.eventRecorder == nil {
+		r.eventRecorder = record.NewBroadcaster().NewRecorder(r.Scheme, corev1.EventSource{Component: "allocationpool-controller"})
+	}
+	if r.slurmControl == nil {
+		r.slurmControl = slurmcontrol.NewControl(r.SlurmClient, r.MCSLabel, r.Partition)
+	}
+}
+
+func New(client client.Client, scheme *runtime.Scheme, mcsLabel, partition string, policies []config.Policy, slurmClient slurmclient.Client) *AllocationPoolReconciler {
+	r := &AllocationPoolReconciler{
+		Client:      client,
+		Scheme:      scheme,
+		MCSLabel:    mcsLabel,
+		Partition:   partition,
+		Policies:    policies,
+		SlurmClient: slurmClient,
+	}
+	r.setupInternal()
+	return r
+}
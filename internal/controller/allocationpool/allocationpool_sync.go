@@ -0,0 +1,296 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package allocationpool
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/allocationpool/slurmcontrol"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+func (r *AllocationPoolReconciler) Sync(ctx context.Context, req reconcile.Request) error {
+	pool := &v1alpha1.AllocationPool{}
+	if err := r.Get(ctx, req.NamespacedName, pool); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	jobs, err := r.slurmControl.GetPoolJobs(ctx, req.String())
+	if err != nil {
+		return err
+	}
+
+	if !pool.DeletionTimestamp.IsZero() {
+		return r.syncDelete(ctx, pool, jobs)
+	}
+	if controllerutil.AddFinalizer(pool, wellknown.FinalizerAllocationPool) {
+		if err := r.Update(ctx, pool); err != nil {
+			return err
+		}
+	}
+
+	allocations, err := r.syncAllocations(ctx, pool, jobs)
+	if err != nil {
+		return err
+	}
+	if policyPool, err := r.applyPolicy(pool); err != nil {
+		r.eventRecorder.Eventf(pool, corev1.EventTypeWarning, wellknown.ReasonPolicyViolation,
+			"Not submitting allocations: %v", err)
+	} else {
+		allocations, err = r.syncSize(ctx, req, policyPool, allocations)
+		if err != nil {
+			return err
+		}
+	}
+	durationStore.Push(req.String(), SyncInterval)
+
+	return r.syncStatus(ctx, pool, allocations)
+}
+
+// syncDelete cancels all allocations of the pool, then removes its finalizer.
+func (r *AllocationPoolReconciler) syncDelete(ctx context.Context, pool *v1alpha1.AllocationPool, jobs []slurmcontrol.PoolJob) error {
+	logger := log.FromContext(ctx)
+
+	var errs []error
+	for _, job := range jobs {
+		logger.Info("Cancelling allocation of deleted pool", "jobId", job.JobId)
+		if err := r.slurmControl.TerminateJob(ctx, job.JobId); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	if controllerutil.RemoveFinalizer(pool, wellknown.FinalizerAllocationPool) {
+		if err := r.Update(ctx, pool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncAllocations determines the state of each allocation of the pool. An
+// allocation whose pods all ended is released back to the pool.
+func (r *AllocationPoolReconciler) syncAllocations(ctx context.Context, pool *v1alpha1.AllocationPool, jobs []slurmcontrol.PoolJob) ([]v1alpha1.Allocation, error) {
+	logger := log.FromContext(ctx)
+
+	now := metav1.Now()
+	allocations := make([]v1alpha1.Allocation, 0, len(jobs))
+	for _, job := range jobs {
+		allocation := v1alpha1.Allocation{
+			JobId: job.JobId,
+			Nodes: job.Nodes,
+		}
+		switch {
+		case job.Pending:
+			allocation.State = v1alpha1.AllocationPending
+		case len(job.Pods) == 0:
+			allocation.State = v1alpha1.AllocationIdle
+		default:
+			active, err := r.podsActive(ctx, job.Pods)
+			if err != nil {
+				return nil, err
+			}
+			if active {
+				allocation.State = v1alpha1.AllocationInUse
+				break
+			}
+			logger.Info("Releasing allocation of ended pods", "jobId", job.JobId, "pods", job.Pods)
+			if err := r.slurmControl.ReleasePoolJob(ctx, job.JobId, job.Pods); err != nil {
+				return nil, err
+			}
+			r.eventRecorder.Eventf(pool, corev1.EventTypeNormal, wellknown.ReasonAllocationReleased,
+				"Released Slurm job %d, its pods ended", job.JobId)
+			allocation.State = v1alpha1.AllocationIdle
+		}
+		if allocation.State == v1alpha1.AllocationIdle {
+			allocation.IdleSince = &now
+			if prev := findAllocation(pool.Status.Allocations, job.JobId); prev != nil &&
+				prev.State == v1alpha1.AllocationIdle && prev.IdleSince != nil {
+				allocation.IdleSince = prev.IdleSince
+			}
+		}
+		allocations = append(allocations, allocation)
+	}
+	return allocations, nil
+}
+
+// syncSize submits allocations until the pool has as many idle or pending
+// allocations as its size. Surplus pending allocations are cancelled, and
+// surplus idle allocations once they were idle for the idle timeout, oldest
+// first.
+func (r *AllocationPoolReconciler) syncSize(ctx context.Context, req reconcile.Request, pool *v1alpha1.AllocationPool, allocations []v1alpha1.Allocation) ([]v1alpha1.Allocation, error) {
+	logger := log.FromContext(ctx)
+
+	var pending, idle []v1alpha1.Allocation
+	for _, allocation := range allocations {
+		switch allocation.State {
+		case v1alpha1.AllocationPending:
+			pending = append(pending, allocation)
+		case v1alpha1.AllocationIdle:
+			idle = append(idle, allocation)
+		}
+	}
+
+	for range int(pool.Spec.Size) - len(idle) - len(pending) {
+		jobId, err := r.slurmControl.SubmitPoolJob(ctx, pool)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Submitted allocation of pool", "jobId", jobId)
+		r.eventRecorder.Eventf(pool, corev1.EventTypeNormal, wellknown.ReasonAllocationSubmitted,
+			"Submitted Slurm job %d", jobId)
+		allocations = append(allocations, v1alpha1.Allocation{
+			JobId: jobId,
+			State: v1alpha1.AllocationPending,
+		})
+	}
+
+	surplus := len(idle) + len(pending) - int(pool.Spec.Size)
+	if surplus <= 0 {
+		return allocations, nil
+	}
+	// The newest pending allocations are the furthest from starting.
+	slices.SortFunc(pending, func(a, b v1alpha1.Allocation) int {
+		return cmp.Compare(b.JobId, a.JobId)
+	})
+	cancel := []int32{}
+	for _, allocation := range pending[:min(surplus, len(pending))] {
+		cancel = append(cancel, allocation.JobId)
+	}
+	surplus -= len(cancel)
+
+	slices.SortFunc(idle, func(a, b v1alpha1.Allocation) int {
+		return a.IdleSince.Compare(b.IdleSince.Time)
+	})
+	now := time.Now()
+	idleTimeout := time.Duration(0)
+	if pool.Spec.IdleTimeout != nil {
+		idleTimeout = pool.Spec.IdleTimeout.Duration
+	}
+	for _, allocation := range idle[:max(surplus, 0)] {
+		expiry := allocation.IdleSince.Add(idleTimeout)
+		if now.Before(expiry) {
+			durationStore.Push(req.String(), expiry.Sub(now))
+			continue
+		}
+		cancel = append(cancel, allocation.JobId)
+	}
+
+	for _, jobId := range cancel {
+		logger.Info("Cancelling surplus allocation of pool", "jobId", jobId)
+		if err := r.slurmControl.TerminateJob(ctx, jobId); err != nil {
+			return nil, err
+		}
+		r.eventRecorder.Eventf(pool, corev1.EventTypeNormal, wellknown.ReasonAllocationCancelled,
+			"Cancelled surplus Slurm job %d", jobId)
+	}
+	allocations = slices.DeleteFunc(allocations, func(allocation v1alpha1.Allocation) bool {
+		return slices.Contains(cancel, allocation.JobId)
+	})
+	return allocations, nil
+}
+
+// applyPolicy returns a copy of the pool with the account, QOS and partition
+// which it does not set defaulted by its policy, or an error if the policy does
+// not allow them. A pool has no ServiceAccount, so the policy of the default
+// ServiceAccount of its namespace applies.
+func (r *AllocationPoolReconciler) applyPolicy(pool *v1alpha1.AllocationPool) (*v1alpha1.AllocationPool, error) {
+	policy := config.PolicyFor(r.Policies, pool.Namespace, "default")
+	if policy == nil {
+		return pool, nil
+	}
+	policyPool := pool.DeepCopy()
+	fields := map[string]*string{
+		wellknown.AnnotationAccount:   &policyPool.Spec.Account,
+		wellknown.AnnotationPartition: &policyPool.Spec.Partition,
+		wellknown.AnnotationQOS:       &policyPool.Spec.QOS,
+	}
+	values := map[string]string{}
+	for annotation, field := range fields {
+		if *field != "" {
+			values[annotation] = *field
+		}
+	}
+	policy.ApplyDefaults(values)
+	for annotation, field := range fields {
+		*field = values[annotation]
+	}
+	return policyPool, policy.Validate(values)
+}
+
+// syncStatus updates the status of the pool from its allocations.
+func (r *AllocationPoolReconciler) syncStatus(ctx context.Context, pool *v1alpha1.AllocationPool, allocations []v1alpha1.Allocation) error {
+	slices.SortFunc(allocations, func(a, b v1alpha1.Allocation) int {
+		return cmp.Compare(a.JobId, b.JobId)
+	})
+	status := v1alpha1.AllocationPoolStatus{
+		Allocations: allocations,
+	}
+	for _, allocation := range allocations {
+		switch allocation.State {
+		case v1alpha1.AllocationPending:
+			status.Pending++
+		case v1alpha1.AllocationIdle:
+			status.Idle++
+		case v1alpha1.AllocationInUse:
+			status.InUse++
+		}
+	}
+	if apiequality.Semantic.DeepEqual(pool.Status, status) {
+		return nil
+	}
+	toUpdate := pool.DeepCopy()
+	toUpdate.Status = status
+	return r.Status().Update(ctx, toUpdate)
+}
+
+// podsActive returns true if any of the pods, given as namespace/name, exists
+// and has not ended.
+func (r *AllocationPoolReconciler) podsActive(ctx context.Context, pods []string) (bool, error) {
+	for _, p := range pods {
+		namespace, name, _ := strings.Cut(p, "/")
+		pod := &corev1.Pod{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, pod); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// findAllocation returns the allocation of the Slurm job, or nil.
+func findAllocation(allocations []v1alpha1.Allocation, jobId int32) *v1alpha1.Allocation {
+	for i := range allocations {
+		if allocations[i].JobId == jobId {
+			return &allocations[i]
+		}
+	}
+	return nil
+}
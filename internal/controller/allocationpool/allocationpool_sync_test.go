@@ -0,0 +1,396 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package allocationpool
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+	slurmclientfake "github.com/SlinkyProject/slurm-client/pkg/client/fake"
+	"github.com/SlinkyProject/slurm-client/pkg/client/interceptor"
+	"github.com/SlinkyProject/slurm-client/pkg/object"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/allocationpool/slurmcontrol"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/placeholderinfo"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newPoolJob(jobId int32, state v0043.V0043JobInfoJobState, pods ...string) *slurmtypes.V0043JobInfo {
+	phInfo := placeholderinfo.PlaceholderInfo{Pods: pods, Attached: true, Pool: "slurm/pool"}
+	return &slurmtypes.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{
+		AdminComment: ptr.To(phInfo.ToString()),
+		JobId:        ptr.To(jobId),
+		JobState:     &[]v0043.V0043JobInfoJobState{state},
+		Nodes:        ptr.To("node1"),
+	}}
+}
+
+func newPod(name string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "slurm", Name: name},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestAllocationPoolReconciler_Sync(t *testing.T) {
+	newPool := func(size int32, idleTimeout time.Duration, allocations ...v1alpha1.Allocation) *v1alpha1.AllocationPool {
+		return &v1alpha1.AllocationPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  "slurm",
+				Name:       "pool",
+				Finalizers: []string{wellknown.FinalizerAllocationPool},
+			},
+			Spec: v1alpha1.AllocationPoolSpec{
+				Size:        size,
+				IdleTimeout: &metav1.Duration{Duration: idleTimeout},
+				Nodes:       1,
+			},
+			Status: v1alpha1.AllocationPoolStatus{Allocations: allocations},
+		}
+	}
+	idleSince := func(d time.Duration) v1alpha1.Allocation {
+		return v1alpha1.Allocation{
+			JobId:     1,
+			Nodes:     "node1",
+			State:     v1alpha1.AllocationIdle,
+			IdleSince: ptr.To(metav1.NewTime(time.Now().Add(-d))),
+		}
+	}
+	tests := []struct {
+		name           string
+		pool           *v1alpha1.AllocationPool
+		objs           []client.Object
+		jobs           []object.Object
+		policies       []config.Policy
+		wantStatus     v1alpha1.AllocationPoolStatus
+		wantSubmitted  int
+		wantReleased   []int32
+		wantTerminated []int32
+		wantDeleted    bool
+	}{
+		{
+			name:          "Submit allocations",
+			pool:          newPool(2, 0),
+			wantStatus:    v1alpha1.AllocationPoolStatus{Pending: 2},
+			wantSubmitted: 2,
+		},
+		{
+			name: "Submit allocations allowed by the policy",
+			pool: func() *v1alpha1.AllocationPool {
+				pool := newPool(1, 0)
+				pool.Spec.Account = "team-a"
+				return pool
+			}(),
+			policies: []config.Policy{
+				{Account: config.PolicyRule{Allowed: []string{"team-a"}}},
+			},
+			wantStatus:    v1alpha1.AllocationPoolStatus{Pending: 1},
+			wantSubmitted: 1,
+		},
+		{
+			name: "Account is not allowed by the policy",
+			pool: func() *v1alpha1.AllocationPool {
+				pool := newPool(1, 0)
+				pool.Spec.Account = "team-b"
+				return pool
+			}(),
+			policies: []config.Policy{
+				{Account: config.PolicyRule{Allowed: []string{"team-a"}}},
+			},
+			wantStatus: v1alpha1.AllocationPoolStatus{},
+		},
+		{
+			name:       "Keep idle allocation",
+			pool:       newPool(1, 0),
+			jobs:       []object.Object{newPoolJob(1, v0043.V0043JobInfoJobStateRUNNING)},
+			wantStatus: v1alpha1.AllocationPoolStatus{Idle: 1},
+		},
+		{
+			name:          "Replace allocation in use",
+			pool:          newPool(1, 0),
+			objs:          []client.Object{newPod("foo", corev1.PodRunning)},
+			jobs:          []object.Object{newPoolJob(1, v0043.V0043JobInfoJobStateRUNNING, "slurm/foo")},
+			wantStatus:    v1alpha1.AllocationPoolStatus{Pending: 1, InUse: 1},
+			wantSubmitted: 1,
+		},
+		{
+			name:         "Release allocation of ended pods",
+			pool:         newPool(1, 0),
+			objs:         []client.Object{newPod("foo", corev1.PodSucceeded)},
+			jobs:         []object.Object{newPoolJob(1, v0043.V0043JobInfoJobStateRUNNING, "slurm/foo", "slurm/bar")},
+			wantStatus:   v1alpha1.AllocationPoolStatus{Idle: 1},
+			wantReleased: []int32{1},
+		},
+		{
+			name:           "Cancel surplus idle allocation",
+			pool:           newPool(0, 10*time.Minute, idleSince(time.Hour)),
+			jobs:           []object.Object{newPoolJob(1, v0043.V0043JobInfoJobStateRUNNING)},
+			wantStatus:     v1alpha1.AllocationPoolStatus{},
+			wantTerminated: []int32{1},
+		},
+		{
+			name:       "Keep surplus idle allocation until idle timeout",
+			pool:       newPool(0, 10*time.Minute, idleSince(time.Minute)),
+			jobs:       []object.Object{newPoolJob(1, v0043.V0043JobInfoJobStateRUNNING)},
+			wantStatus: v1alpha1.AllocationPoolStatus{Idle: 1},
+		},
+		{
+			name: "Cancel surplus pending allocation",
+			pool: newPool(1, 0),
+			jobs: []object.Object{
+				newPoolJob(1, v0043.V0043JobInfoJobStatePENDING),
+				newPoolJob(2, v0043.V0043JobInfoJobStatePENDING),
+			},
+			wantStatus:     v1alpha1.AllocationPoolStatus{Pending: 1},
+			wantTerminated: []int32{2},
+		},
+		{
+			name: "Cancel allocations of deleted pool",
+			pool: func() *v1alpha1.AllocationPool {
+				pool := newPool(1, 0)
+				pool.DeletionTimestamp = ptr.To(metav1.Now())
+				return pool
+			}(),
+			jobs: []object.Object{
+				newPoolJob(1, v0043.V0043JobInfoJobStatePENDING),
+				newPoolJob(2, v0043.V0043JobInfoJobStateRUNNING, "slurm/foo"),
+			},
+			wantTerminated: []int32{1, 2},
+			wantDeleted:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := fake.NewClientBuilder().
+				WithScheme(newScheme(t)).
+				WithObjects(append(tt.objs, tt.pool)...).
+				WithStatusSubresource(&v1alpha1.AllocationPool{}).
+				Build()
+			var submitted int
+			var released, terminated []int32
+			slurmClient := slurmclientfake.NewClientBuilder().
+				WithObjects(tt.jobs...).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Create: func(ctx context.Context, obj object.Object, req any, opts ...slurmclient.CreateOption) error {
+						submitted++
+						obj.(*slurmtypes.V0043JobInfo).JobId = ptr.To(int32(100 + submitted)) //nolint:gosec // disable G115
+						return nil
+					},
+					Update: func(ctx context.Context, obj object.Object, req any, opts ...slurmclient.UpdateOption) error {
+						released = append(released, ptr.Deref(obj.(*slurmtypes.V0043JobInfo).JobId, 0))
+						return nil
+					},
+					Delete: func(ctx context.Context, obj object.Object, opts ...slurmclient.DeleteOption) error {
+						terminated = append(terminated, ptr.Deref(obj.(*slurmtypes.V0043JobInfo).JobId, 0))
+						return nil
+					},
+				}).Build()
+			r := &AllocationPoolReconciler{
+				Client:        c,
+				Scheme:        c.Scheme(),
+				Policies:      tt.policies,
+				slurmControl:  slurmcontrol.NewControl(slurmClient, "", "slurm-bridge"),
+				eventRecorder: record.NewFakeRecorder(10),
+			}
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "slurm", Name: "pool"}}
+			if err := r.Sync(ctx, req); err != nil {
+				t.Fatalf("AllocationPoolReconciler.Sync() error = %v", err)
+			}
+			_ = durationStore.Pop(req.String())
+
+			if submitted != tt.wantSubmitted {
+				t.Errorf("AllocationPoolReconciler.Sync() submitted = %v, want %v", submitted, tt.wantSubmitted)
+			}
+			slices.Sort(terminated)
+			if !slices.Equal(released, tt.wantReleased) {
+				t.Errorf("AllocationPoolReconciler.Sync() released = %v, want %v", released, tt.wantReleased)
+			}
+			if !slices.Equal(terminated, tt.wantTerminated) {
+				t.Errorf("AllocationPoolReconciler.Sync() terminated = %v, want %v", terminated, tt.wantTerminated)
+			}
+
+			pool := &v1alpha1.AllocationPool{}
+			err := c.Get(ctx, req.NamespacedName, pool)
+			if tt.wantDeleted {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("AllocationPoolReconciler.Sync() pool = %v, want deleted", pool)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if pool.Status.Pending != tt.wantStatus.Pending ||
+				pool.Status.Idle != tt.wantStatus.Idle ||
+				pool.Status.InUse != tt.wantStatus.InUse {
+				t.Errorf("AllocationPoolReconciler.Sync() status = %+v, want %+v", pool.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAllocationPoolReconciler_Sync_Finalizer(t *testing.T) {
+	ctx := context.Background()
+	pool := &v1alpha1.AllocationPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "slurm", Name: "pool"},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(newScheme(t)).
+		WithObjects(pool).
+		WithStatusSubresource(&v1alpha1.AllocationPool{}).
+		Build()
+	r := &AllocationPoolReconciler{
+		Client:        c,
+		Scheme:        c.Scheme(),
+		slurmControl:  slurmcontrol.NewControl(slurmclientfake.NewFakeClient(), "", "slurm-bridge"),
+		eventRecorder: record.NewFakeRecorder(10),
+	}
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pool)}
+	if err := r.Sync(ctx, req); err != nil {
+		t.Fatalf("AllocationPoolReconciler.Sync() error = %v", err)
+	}
+	_ = durationStore.Pop(req.String())
+	if err := c.Get(ctx, req.NamespacedName, pool); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(pool.Finalizers, wellknown.FinalizerAllocationPool) {
+		t.Errorf("AllocationPoolReconciler.Sync() finalizers = %v, want %v", pool.Finalizers, wellknown.FinalizerAllocationPool)
+	}
+}
+
+func TestAllocationPoolReconciler_podsActive(t *testing.T) {
+	tests := []struct {
+		name string
+		objs []client.Object
+		pods []string
+		want bool
+	}{
+		{
+			name: "Running pod",
+			objs: []client.Object{newPod("foo", corev1.PodRunning)},
+			pods: []string{"slurm/foo"},
+			want: true,
+		},
+		{
+			name: "Ended pods",
+			objs: []client.Object{newPod("foo", corev1.PodSucceeded), newPod("bar", corev1.PodFailed)},
+			pods: []string{"slurm/foo", "slurm/bar"},
+			want: false,
+		},
+		{
+			name: "Deleted pod",
+			pods: []string{"slurm/foo"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &AllocationPoolReconciler{
+				Client: fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(tt.objs...).Build(),
+			}
+			got, err := r.podsActive(context.Background(), tt.pods)
+			if err != nil {
+				t.Fatalf("AllocationPoolReconciler.podsActive() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("AllocationPoolReconciler.podsActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocationPoolReconciler_applyPolicy(t *testing.T) {
+	newPool := func(account, qos string) *v1alpha1.AllocationPool {
+		return &v1alpha1.AllocationPool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "slurm", Name: "pool"},
+			Spec:       v1alpha1.AllocationPoolSpec{Account: account, QOS: qos},
+		}
+	}
+	policies := []config.Policy{
+		{
+			Namespaces:      []string{"slurm"},
+			ServiceAccounts: []string{"trainer"},
+			Account:         config.PolicyRule{Allowed: []string{"trainer"}},
+		},
+		{
+			Namespaces: []string{"slurm"},
+			Account:    config.PolicyRule{Allowed: []string{"team-a"}, Default: "team-a"},
+			QOS:        config.PolicyRule{Allowed: []string{"normal"}},
+		},
+	}
+	tests := []struct {
+		name     string
+		pool     *v1alpha1.AllocationPool
+		policies []config.Policy
+		want     v1alpha1.AllocationPoolSpec
+		wantErr  bool
+	}{
+		{
+			name:     "No policy",
+			pool:     newPool("team-b", ""),
+			policies: nil,
+			want:     v1alpha1.AllocationPoolSpec{Account: "team-b"},
+		},
+		{
+			name:     "Defaults",
+			pool:     newPool("", "normal"),
+			policies: policies,
+			want:     v1alpha1.AllocationPoolSpec{Account: "team-a", QOS: "normal"},
+		},
+		{
+			name:     "Policy of the default ServiceAccount applies",
+			pool:     newPool("trainer", ""),
+			policies: policies,
+			wantErr:  true,
+		},
+		{
+			name:     "QOS not allowed",
+			pool:     newPool("team-a", "high"),
+			policies: policies,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &AllocationPoolReconciler{Policies: tt.policies}
+			got, err := r.applyPolicy(tt.pool)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AllocationPoolReconciler.applyPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Spec != tt.want {
+				t.Errorf("AllocationPoolReconciler.applyPolicy() = %+v, want %+v", got.Spec, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmcontrol
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"k8s.io/utils/ptr"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	"github.com/SlinkyProject/slurm-client/pkg/client"
+	"github.com/SlinkyProject/slurm-client/pkg/object"
+	"github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/placeholderinfo"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjobir"
+)
+
+// PoolJob is a Slurm job of an AllocationPool.
+type PoolJob struct {
+	JobId   int32
+	Nodes   string
+	Pending bool
+	// Pods are the pods which claimed the allocation, if any.
+	Pods []string
+}
+
+type SlurmControlInterface interface {
+	// GetPoolJobs returns the pending and running Slurm jobs of the pool,
+	// given as namespace/name.
+	GetPoolJobs(ctx context.Context, pool string) ([]PoolJob, error)
+	// SubmitPoolJob submits a new allocation of the pool.
+	SubmitPoolJob(ctx context.Context, pool *v1alpha1.AllocationPool) (int32, error)
+	// ReleasePoolJob returns an allocation claimed by the pods to its pool.
+	ReleasePoolJob(ctx context.Context, jobId int32, pods []string) error
+	// TerminateJob cancels the Slurm job by JobId
+	TerminateJob(ctx context.Context, jobId int32) error
+}
+
+// realSlurmControl is the default implementation of SlurmControlInterface.
+type realSlurmControl struct {
+	client.Client
+	mcsLabel  string
+	partition string
+}
+
+// GetPoolJobs implements SlurmControlInterface.
+func (r *realSlurmControl) GetPoolJobs(ctx context.Context, pool string) ([]PoolJob, error) {
+	list := &types.V0043JobInfoList{}
+	// Skip the informer cache, it may not yet include the pods of an
+	// allocation which the scheduler just claimed, which would look idle and
+	// could be cancelled as surplus while its pods are binding.
+	if err := r.List(ctx, list, &client.ListOptions{SkipCache: true}); err != nil {
+		return nil, err
+	}
+	jobs := []PoolJob{}
+	for _, j := range list.Items {
+		phInfo := placeholderinfo.PlaceholderInfo{}
+		if err := placeholderinfo.ParseIntoPlaceholderInfo(j.AdminComment, &phInfo); err != nil || phInfo.Pool != pool {
+			continue
+		}
+		states := j.GetStateAsSet()
+		if !states.Has(v0043.V0043JobInfoJobStatePENDING) && !states.Has(v0043.V0043JobInfoJobStateRUNNING) {
+			continue
+		}
+		jobs = append(jobs, PoolJob{
+			JobId:   ptr.Deref(j.JobId, 0),
+			Nodes:   ptr.Deref(j.Nodes, ""),
+			Pending: states.Has(v0043.V0043JobInfoJobStatePENDING),
+			Pods:    phInfo.Pods,
+		})
+	}
+	return jobs, nil
+}
+
+// SubmitPoolJob implements SlurmControlInterface.
+func (r *realSlurmControl) SubmitPoolJob(ctx context.Context, pool *v1alpha1.AllocationPool) (int32, error) {
+	phInfo := placeholderinfo.PlaceholderInfo{
+		Attached: true,
+		Pool:     pool.Namespace + "/" + pool.Name,
+	}
+	spec := pool.Spec
+	nodes := max(spec.Nodes, 1)
+	jobDesc := &v0043.V0043JobDescMsg{
+		Account:                 toOptString(spec.Account),
+		AdminComment:            ptr.To(phInfo.ToString()),
+		Constraints:             toOptString(spec.Constraints),
+		CurrentWorkingDirectory: ptr.To("/tmp"),
+		Environment: &v0043.V0043StringArray{
+			"/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin",
+		},
+		Flags: &[]v0043.V0043JobDescMsgFlags{
+			v0043.V0043JobDescMsgFlagsEXTERNALJOB,
+		},
+		MaximumNodes: ptr.To(nodes),
+		McsLabel:     ptr.To(r.mcsLabel),
+		MemoryPerNode: func() *v0043.V0043Uint64NoValStruct {
+			if spec.MemPerNode != nil {
+				return &v0043.V0043Uint64NoValStruct{
+					Infinite: ptr.To(false),
+					Number:   ptr.To(slurmjobir.GetMemoryFromQuantity(spec.MemPerNode)),
+					Set:      ptr.To(true),
+				}
+			} else {
+				return &v0043.V0043Uint64NoValStruct{Set: ptr.To(false)}
+			}
+		}(),
+		MinimumNodes: ptr.To(nodes),
+		Name:         ptr.To(pool.Name),
+		Partition:    ptr.To(ptr.Deref(toOptString(spec.Partition), r.partition)),
+		Qos:          toOptString(spec.QOS),
+		Reservation:  toOptString(spec.Reservation),
+		Shared:       &[]v0043.V0043JobDescMsgShared{v0043.V0043JobDescMsgSharedNone},
+		TimeLimit: func() *v0043.V0043Uint32NoValStruct {
+			if spec.TimeLimit != nil {
+				return &v0043.V0043Uint32NoValStruct{
+					Infinite: ptr.To(false),
+					Number:   spec.TimeLimit,
+					Set:      ptr.To(true),
+				}
+			} else {
+				return &v0043.V0043Uint32NoValStruct{Set: ptr.To(false)}
+			}
+		}(),
+		TresPerNode: toOptString(spec.Gres),
+	}
+	job := &types.V0043JobInfo{}
+	if err := r.Create(ctx, job, v0043.V0043JobSubmitReq{Job: jobDesc}); err != nil {
+		return 0, err
+	}
+	return ptr.Deref(job.JobId, 0), nil
+}
+
+// ReleasePoolJob implements SlurmControlInterface.
+func (r *realSlurmControl) ReleasePoolJob(ctx context.Context, jobId int32, pods []string) error {
+	job := &types.V0043JobInfo{}
+	key := object.ObjectKey(strconv.Itoa(int(jobId)))
+	if err := r.Get(ctx, key, job, &client.GetOptions{SkipCache: true}); err != nil {
+		if tolerateError(err) {
+			return nil
+		}
+		return err
+	}
+	phInfo := placeholderinfo.PlaceholderInfo{}
+	if err := placeholderinfo.ParseIntoPlaceholderInfo(job.AdminComment, &phInfo); err != nil {
+		return err
+	}
+	// The allocation was claimed by other pods since it was listed.
+	if !slices.Equal(phInfo.Pods, pods) {
+		return nil
+	}
+	phInfo = placeholderinfo.PlaceholderInfo{
+		Attached: true,
+		Pool:     phInfo.Pool,
+	}
+	req := v0043.V0043JobDescMsg{AdminComment: ptr.To(phInfo.ToString())}
+	if err := r.Update(ctx, job, req); err != nil {
+		if tolerateError(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// TerminateJob implements SlurmControlInterface.
+func (r *realSlurmControl) TerminateJob(ctx context.Context, jobId int32) error {
+	job := &types.V0043JobInfo{
+		V0043JobInfo: v0043.V0043JobInfo{
+			JobId: ptr.To(jobId),
+		},
+	}
+	if err := r.Delete(ctx, job); err != nil {
+		if tolerateError(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+var _ SlurmControlInterface = &realSlurmControl{}
+
+func NewControl(client client.Client, mcsLabel, partition string) SlurmControlInterface {
+	return &realSlurmControl{
+		Client:    client,
+		mcsLabel:  mcsLabel,
+		partition: partition,
+	}
+}
+
+// toOptString returns nil for an unset string.
+func toOptString(str string) *string {
+	if str == "" {
+		return nil
+	}
+	return ptr.To(str)
+}
+
+func tolerateError(err error) bool {
+	if err == nil {
+		return true
+	}
+	errText := err.Error()
+	if errText == http.StatusText(http.StatusNotFound) ||
+		errText == http.StatusText(http.StatusNoContent) {
+		return true
+	}
+	return false
+}
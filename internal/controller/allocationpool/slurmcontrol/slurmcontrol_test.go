@@ -0,0 +1,319 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmcontrol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	"github.com/SlinkyProject/slurm-client/pkg/client"
+	"github.com/SlinkyProject/slurm-client/pkg/client/fake"
+	"github.com/SlinkyProject/slurm-client/pkg/client/interceptor"
+	"github.com/SlinkyProject/slurm-client/pkg/object"
+	"github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/placeholderinfo"
+)
+
+func newPoolJob(jobId int32, state v0043.V0043JobInfoJobState, nodes, pool string, pods ...string) *types.V0043JobInfo {
+	phInfo := placeholderinfo.PlaceholderInfo{Pods: pods, Attached: true, Pool: pool}
+	return &types.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{
+		AdminComment: ptr.To(phInfo.ToString()),
+		JobId:        ptr.To(jobId),
+		JobState:     &[]v0043.V0043JobInfoJobState{state},
+		Nodes:        ptr.To(nodes),
+	}}
+}
+
+func Test_realSlurmControl_GetPoolJobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  client.Client
+		want    []PoolJob
+		wantErr bool
+	}{
+		{
+			name:   "Empty",
+			client: fake.NewFakeClient(),
+			want:   []PoolJob{},
+		},
+		{
+			name: "Jobs of pool",
+			client: fake.NewClientBuilder().WithObjects(
+				newPoolJob(1, v0043.V0043JobInfoJobStatePENDING, "", "slurm/pool"),
+				newPoolJob(2, v0043.V0043JobInfoJobStateRUNNING, "node1", "slurm/pool", "slurm/foo"),
+				newPoolJob(3, v0043.V0043JobInfoJobStateRUNNING, "node2", "slurm/other"),
+				newPoolJob(4, v0043.V0043JobInfoJobStateCOMPLETED, "node3", "slurm/pool"),
+				&types.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{
+					JobId:    ptr.To[int32](5),
+					JobState: &[]v0043.V0043JobInfoJobState{v0043.V0043JobInfoJobStateRUNNING},
+				}},
+			).Build(),
+			want: []PoolJob{
+				{JobId: 1, Pending: true},
+				{JobId: 2, Nodes: "node1", Pods: []string{"slurm/foo"}},
+			},
+		},
+		{
+			name: "Skip cache",
+			client: fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				List: func(ctx context.Context, list object.ObjectList, opts ...client.ListOption) error {
+					listOpts := &client.ListOptions{}
+					listOpts.ApplyOptions(opts)
+					if !listOpts.SkipCache {
+						return errors.New("expected the informer cache to be skipped")
+					}
+					return nil
+				},
+			}).Build(),
+			want: []PoolJob{},
+		},
+		{
+			name: "Failure",
+			client: fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				List: func(ctx context.Context, list object.ObjectList, opts ...client.ListOption) error {
+					return errors.New(http.StatusText(http.StatusInternalServerError))
+				},
+			}).Build(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &realSlurmControl{
+				Client: tt.client,
+			}
+			got, err := r.GetPoolJobs(context.Background(), "slurm/pool")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.GetPoolJobs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("realSlurmControl.GetPoolJobs() = %v, want %v", got, tt.want)
+			}
+			// The fake client lists jobs in no particular order.
+			for _, want := range tt.want {
+				found := false
+				for _, job := range got {
+					found = found || reflect.DeepEqual(job, want)
+				}
+				if !found {
+					t.Errorf("realSlurmControl.GetPoolJobs() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_SubmitPoolJob(t *testing.T) {
+	pool := &v1alpha1.AllocationPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "slurm", Name: "pool"},
+		Spec: v1alpha1.AllocationPoolSpec{
+			Size:       1,
+			Nodes:      2,
+			Account:    "physics",
+			Gres:       "gres/gpu=8",
+			MemPerNode: ptr.To(resource.MustParse("1Gi")),
+			TimeLimit:  ptr.To[int32](60),
+		},
+	}
+	tests := []struct {
+		name      string
+		partition string
+		pool      *v1alpha1.AllocationPool
+		want      v0043.V0043JobDescMsg
+		wantErr   bool
+	}{
+		{
+			name:      "Default partition",
+			partition: "slurm-bridge",
+			pool:      pool,
+			want: v0043.V0043JobDescMsg{
+				Account:      ptr.To("physics"),
+				AdminComment: ptr.To(`{"pods":null,"attached":true,"pool":"slurm/pool"}`),
+				MaximumNodes: ptr.To[int32](2),
+				MinimumNodes: ptr.To[int32](2),
+				Partition:    ptr.To("slurm-bridge"),
+				TresPerNode:  ptr.To("gres/gpu=8"),
+			},
+		},
+		{
+			name:      "Pool partition",
+			partition: "slurm-bridge",
+			pool: func() *v1alpha1.AllocationPool {
+				pool := pool.DeepCopy()
+				pool.Spec.Partition = "gpu"
+				return pool
+			}(),
+			want: v0043.V0043JobDescMsg{
+				Account:      ptr.To("physics"),
+				AdminComment: ptr.To(`{"pods":null,"attached":true,"pool":"slurm/pool"}`),
+				MaximumNodes: ptr.To[int32](2),
+				MinimumNodes: ptr.To[int32](2),
+				Partition:    ptr.To("gpu"),
+				TresPerNode:  ptr.To("gres/gpu=8"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *v0043.V0043JobDescMsg
+			c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, obj object.Object, req any, opts ...client.CreateOption) error {
+					got = req.(v0043.V0043JobSubmitReq).Job
+					obj.(*types.V0043JobInfo).JobId = ptr.To[int32](1)
+					return nil
+				},
+			}).Build()
+			r := NewControl(c, "kubernetes", tt.partition)
+			jobId, err := r.SubmitPoolJob(context.Background(), tt.pool)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.SubmitPoolJob() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if jobId != 1 {
+				t.Errorf("realSlurmControl.SubmitPoolJob() = %v, want %v", jobId, 1)
+			}
+			if ptr.Deref(got.Account, "") != ptr.Deref(tt.want.Account, "") ||
+				ptr.Deref(got.AdminComment, "") != ptr.Deref(tt.want.AdminComment, "") ||
+				ptr.Deref(got.MaximumNodes, 0) != ptr.Deref(tt.want.MaximumNodes, 0) ||
+				ptr.Deref(got.MinimumNodes, 0) != ptr.Deref(tt.want.MinimumNodes, 0) ||
+				ptr.Deref(got.Partition, "") != ptr.Deref(tt.want.Partition, "") ||
+				ptr.Deref(got.TresPerNode, "") != ptr.Deref(tt.want.TresPerNode, "") {
+				t.Errorf("realSlurmControl.SubmitPoolJob() job = %v, want %v", got, tt.want)
+			}
+			if ptr.Deref(got.MemoryPerNode.Number, 0) != 1024 {
+				t.Errorf("realSlurmControl.SubmitPoolJob() memory = %v, want %v", ptr.Deref(got.MemoryPerNode.Number, 0), 1024)
+			}
+			if ptr.Deref(got.TimeLimit.Number, 0) != 60 {
+				t.Errorf("realSlurmControl.SubmitPoolJob() time limit = %v, want %v", ptr.Deref(got.TimeLimit.Number, 0), 60)
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_ReleasePoolJob(t *testing.T) {
+	tests := []struct {
+		name        string
+		jobId       int32
+		pods        []string
+		wantComment *string
+		wantErr     bool
+	}{
+		{
+			name:        "Release allocation",
+			jobId:       1,
+			pods:        []string{"slurm/foo"},
+			wantComment: ptr.To(`{"pods":null,"attached":true,"pool":"slurm/pool"}`),
+		},
+		{
+			name:  "Allocation claimed by other pods",
+			jobId: 1,
+			pods:  []string{"slurm/bar"},
+		},
+		{
+			name:  "Job not found",
+			jobId: 2,
+			pods:  []string{"slurm/foo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotComment *string
+			c := fake.NewClientBuilder().
+				WithObjects(newPoolJob(1, v0043.V0043JobInfoJobStateRUNNING, "node1", "slurm/pool", "slurm/foo")).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Update: func(ctx context.Context, obj object.Object, req any, opts ...client.UpdateOption) error {
+						gotComment = req.(v0043.V0043JobDescMsg).AdminComment
+						return nil
+					},
+				}).Build()
+			r := &realSlurmControl{
+				Client: c,
+			}
+			if err := r.ReleasePoolJob(context.Background(), tt.jobId, tt.pods); (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.ReleasePoolJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(gotComment, tt.wantComment) {
+				t.Errorf("realSlurmControl.ReleasePoolJob() comment = %v, want %v",
+					ptr.Deref(gotComment, ""), ptr.Deref(tt.wantComment, ""))
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_TerminateJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  client.Client
+		jobId   int32
+		wantErr bool
+	}{
+		{
+			name:   "Job not found",
+			client: fake.NewFakeClient(),
+			jobId:  1,
+		},
+		{
+			name:   "Job deleted",
+			client: fake.NewClientBuilder().WithObjects(newPoolJob(1, v0043.V0043JobInfoJobStateRUNNING, "node1", "slurm/pool")).Build(),
+			jobId:  1,
+		},
+		{
+			name: "Failure",
+			client: fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				Delete: func(ctx context.Context, obj object.Object, opts ...client.DeleteOption) error {
+					return errors.New(http.StatusText(http.StatusInternalServerError))
+				},
+			}).Build(),
+			jobId:   1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &realSlurmControl{
+				Client: tt.client,
+			}
+			if err := r.TerminateJob(context.Background(), tt.jobId); (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.TerminateJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_toOptString(t *testing.T) {
+	tests := []struct {
+		name string
+		str  string
+		want *string
+	}{
+		{
+			name: "Unset",
+			str:  "",
+			want: nil,
+		},
+		{
+			name: "Set",
+			str:  "foo",
+			want: ptr.To("foo"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toOptString(tt.str); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toOptString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
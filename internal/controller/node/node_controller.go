@@ -29,6 +29,7 @@ import (
 	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
 	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
 
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
 	"github.com/SlinkyProject/slurm-bridge/internal/controller/node/slurmcontrol"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils/durationstore"
 )
@@ -60,12 +61,17 @@ type NodeReconciler struct {
 	SchedulerName string
 	SlurmClient   slurmclient.Client
 	EventCh       chan event.GenericEvent
+	// SlurmNodeEventCh, if set, receives an event for every change of a Slurm
+	// node. The node informer supports a single event handler, so it is shared
+	// with the SlurmNode controller this way.
+	SlurmNodeEventCh chan event.GenericEvent
 
 	slurmControl  slurmcontrol.SlurmControlInterface
-	eventRecorder record.EventRecorderLogger
+	eventRecorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;patch;update;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -102,6 +108,9 @@ func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res c
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *NodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.eventRecorder == nil {
+		r.eventRecorder = mgr.GetEventRecorderFor("node-controller")
+	}
 	r.setupInternal()
 	nodeEventHandler := &nodeEventHandler{
 		Reader: mgr.GetCache(),
@@ -141,6 +150,7 @@ func (r *NodeReconciler) setupEventHandler() {
 				logger.Error(fmt.Errorf("expected V0043Node"), "failed to cast object")
 				return
 			}
+			r.pushSlurmNodeEvent(*node.Name)
 			r.EventCh <- nodeEvent(*node.Name)
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
@@ -154,6 +164,7 @@ func (r *NodeReconciler) setupEventHandler() {
 				logger.Error(fmt.Errorf("expected V0043Node"), "failed to cast new object")
 				return
 			}
+			r.pushSlurmNodeEvent(*nodeNew.Name)
 			if !apiequality.Semantic.DeepEqual(nodeNew.Address, nodeOld.Address) ||
 				!apiequality.Semantic.DeepEqual(nodeNew.Hostname, nodeOld.Hostname) {
 				r.EventCh <- nodeEvent(*nodeNew.Name)
@@ -165,11 +176,36 @@ func (r *NodeReconciler) setupEventHandler() {
 				logger.Error(fmt.Errorf("expected V0043Node"), "failed to cast object")
 				return
 			}
+			r.pushSlurmNodeEvent(*node.Name)
 			r.EventCh <- nodeEvent(*node.Name)
 		},
 	})
 }
 
+// pushSlurmNodeEvent notifies the SlurmNode controller of a change of the
+// Slurm node, if any, which also deletes the SlurmNode of a removed Slurm node.
+// It never blocks: the Slurm informer is stalled while its handler runs, so the
+// event is sent in the background when the SlurmNode controller is behind.
+func (r *NodeReconciler) pushSlurmNodeEvent(name string) {
+	if r.SlurmNodeEventCh == nil {
+		return
+	}
+	e := event.GenericEvent{
+		Object: &v1alpha1.SlurmNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		},
+	}
+	select {
+	case r.SlurmNodeEventCh <- e:
+	default:
+		go func() {
+			r.SlurmNodeEventCh <- e
+		}()
+	}
+}
+
 func nodeEvent(name string) event.GenericEvent {
 	return event.GenericEvent{
 		Object: &corev1.Node{
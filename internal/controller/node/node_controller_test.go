@@ -5,6 +5,8 @@ package node
 
 import (
 	"context"
+	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -115,3 +117,44 @@ var _ = Describe("Node Controller", func() {
 		})
 	})
 })
+
+func TestNodeReconciler_pushSlurmNodeEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		eventCh chan event.GenericEvent
+	}{
+		{
+			name:    "Buffered",
+			eventCh: make(chan event.GenericEvent, 1),
+		},
+		{
+			name:    "Controller is not draining",
+			eventCh: make(chan event.GenericEvent),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &NodeReconciler{
+				SlurmNodeEventCh: tt.eventCh,
+			}
+			done := make(chan struct{})
+			go func() {
+				r.pushSlurmNodeEvent("node1")
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("NodeReconciler.pushSlurmNodeEvent() blocked")
+			}
+			select {
+			case e := <-tt.eventCh:
+				if got := e.Object.GetName(); got != "node1" {
+					t.Errorf("NodeReconciler.pushSlurmNodeEvent() = %v, want %v", got, "node1")
+				}
+			case <-time.After(time.Second):
+				t.Error("NodeReconciler.pushSlurmNodeEvent() dropped the event")
+			}
+		})
+	}
+}
@@ -6,11 +6,14 @@ package node
 import (
 	"context"
 	"fmt"
+	"maps"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/util/taints"
 	"k8s.io/utils/set"
@@ -19,7 +22,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	nodeutils "github.com/SlinkyProject/slurm-bridge/internal/controller/node/utils"
+	"github.com/SlinkyProject/slurm-bridge/internal/metrics"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 )
 
 func (r *NodeReconciler) Sync(ctx context.Context, req reconcile.Request) error {
@@ -33,6 +38,10 @@ func (r *NodeReconciler) Sync(ctx context.Context, req reconcile.Request) error
 		errs = append(errs, err)
 	}
 
+	if err := r.syncFeatures(ctx, req); err != nil {
+		errs = append(errs, err)
+	}
+
 	return utilerrors.NewAggregate(errs)
 }
 
@@ -110,11 +119,15 @@ func (r *NodeReconciler) taintNode(ctx context.Context, node *corev1.Node, nodeN
 		logger.V(2).Info("node patch is empty, skipping patch request", "node", klog.KObj(node))
 		return nil
 	}
-	logger.Info("Remove taint from node", "node", klog.KObj(node))
-	if err := r.Patch(ctx, toUpdate, patch); err != nil {
+	logger.Info("Add taint to node", "node", klog.KObj(node))
+	err = r.Patch(ctx, toUpdate, patch)
+	metrics.NodeOperations.WithLabelValues(metrics.OperationTaint, metrics.Result(err)).Inc()
+	if err != nil {
 		logger.Error(err, "failed to patch node", "node", klog.KObj(node))
 		return err
 	}
+	r.eventRecorder.Eventf(toUpdate, corev1.EventTypeNormal, wellknown.ReasonNodeTainted,
+		"Added taint %s, node corresponds to Slurm node %s", taint.ToString(), nodeutils.GetSlurmNodeName(node))
 	return nil
 }
 
@@ -147,11 +160,15 @@ func (r *NodeReconciler) untaintNode(ctx context.Context, node *corev1.Node, nod
 		logger.V(2).Info("node patch is empty, skipping patch request", "node", klog.KObj(node))
 		return nil
 	}
-	logger.Info("Add taint to node", "node", klog.KObj(node))
-	if err := r.Patch(ctx, toUpdate, patch); err != nil {
+	logger.Info("Remove taint from node", "node", klog.KObj(node))
+	err := r.Patch(ctx, toUpdate, patch)
+	metrics.NodeOperations.WithLabelValues(metrics.OperationUntaint, metrics.Result(err)).Inc()
+	if err != nil {
 		logger.Error(err, "failed to patch node", "node", klog.KObj(node))
 		return err
 	}
+	r.eventRecorder.Eventf(toUpdate, corev1.EventTypeNormal, wellknown.ReasonNodeUntainted,
+		"Removed taint %s, node does not correspond to Slurm node %s", taint.ToString(), nodeutils.GetSlurmNodeName(node))
 	return nil
 }
 
@@ -176,17 +193,82 @@ func (r *NodeReconciler) syncState(ctx context.Context, req reconcile.Request) e
 		slurmNode := nodeutils.GetSlurmNodeName(node)
 		logger.V(1).Info("draining Slurm node, Kubernetes node is unschedulable",
 			"node", klog.KObj(node), "slurmNode", slurmNode, "reason", reason)
-		if err := r.slurmControl.MakeNodeDrain(ctx, node, reason); err != nil {
+		drained, err := r.slurmControl.MakeNodeDrain(ctx, node, reason)
+		if drained || err != nil {
+			metrics.NodeOperations.WithLabelValues(metrics.OperationDrain, metrics.Result(err)).Inc()
+		}
+		if err != nil {
 			return err
 		}
+		if drained {
+			r.eventRecorder.Eventf(node, corev1.EventTypeNormal, wellknown.ReasonSlurmNodeDrained,
+				"Drained Slurm node %s, node is unschedulable", slurmNode)
+		}
 	} else {
 		reason := fmt.Sprintf("Corresponding Kubernetes node (%s) is schedulable", klog.KObj(node))
+		slurmNode := nodeutils.GetSlurmNodeName(node)
 		logger.V(1).Info("undraining Slurm node, Kubernetes node is schedulable",
-			"node", klog.KObj(node))
-		if err := r.slurmControl.MakeNodeUndrain(ctx, node, reason); err != nil {
+			"node", klog.KObj(node), "slurmNode", slurmNode)
+		undrained, err := r.slurmControl.MakeNodeUndrain(ctx, node, reason)
+		if undrained || err != nil {
+			metrics.NodeOperations.WithLabelValues(metrics.OperationUndrain, metrics.Result(err)).Inc()
+		}
+		if err != nil {
 			return err
 		}
+		if undrained {
+			r.eventRecorder.Eventf(node, corev1.EventTypeNormal, wellknown.ReasonSlurmNodeUndrained,
+				"Undrained Slurm node %s, node is schedulable", slurmNode)
+		}
 	}
 
 	return nil
 }
+
+// syncFeatures will label the Kubernetes node with the active features of its
+// Slurm node, so pods may select nodes by Slurm feature. Feature labels which
+// are no longer active are removed.
+func (r *NodeReconciler) syncFeatures(ctx context.Context, req reconcile.Request) error {
+	logger := log.FromContext(ctx)
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, req.NamespacedName, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	features, err := r.slurmControl.GetNodeFeatures(ctx, node)
+	if err != nil {
+		return err
+	}
+
+	toUpdate := node.DeepCopy()
+	if toUpdate.Labels == nil {
+		toUpdate.Labels = make(map[string]string)
+	}
+	for key := range toUpdate.Labels {
+		if strings.HasPrefix(key, wellknown.LabelPrefixSlurmFeature) {
+			delete(toUpdate.Labels, key)
+		}
+	}
+	for _, feature := range features {
+		key := wellknown.LabelPrefixSlurmFeature + feature
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			logger.V(2).Info("Slurm feature is not a valid label, skipping",
+				"node", klog.KObj(node), "feature", feature, "errs", errs)
+			continue
+		}
+		toUpdate.Labels[key] = "true"
+	}
+	if maps.Equal(node.Labels, toUpdate.Labels) {
+		return nil
+	}
+	logger.V(1).Info("update Slurm feature labels of node", "node", klog.KObj(node), "features", features)
+	if err := r.Patch(ctx, toUpdate, client.StrategicMergeFrom(node)); err != nil {
+		logger.Error(err, "failed to patch node", "node", klog.KObj(node))
+		return err
+	}
+	return nil
+}
@@ -364,3 +364,69 @@ var _ = Describe("syncState()", func() {
 		})
 	})
 })
+
+var _ = Describe("syncFeatures()", func() {
+	var controllerReconciler *NodeReconciler
+
+	BeforeEach(func() {
+		nodeList := &corev1.NodeList{
+			Items: []corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "kube-0", Labels: map[string]string{
+					wellknown.LabelPrefixSlurmFeature + "stale": "true",
+				}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "bridged-0", Labels: map[string]string{
+					wellknown.LabelPrefixSlurmFeature + "stale": "true",
+					"zone": "a",
+				}}},
+			},
+		}
+		k8sClient := fake.NewFakeClient(nodeList)
+		Expect(k8sClient).NotTo(BeNil())
+
+		slurmNodeList := &slurmtypes.V0043NodeList{
+			Items: []slurmtypes.V0043Node{
+				{V0043Node: v0043.V0043Node{
+					Name:           ptr.To("bridged-0"),
+					ActiveFeatures: ptr.To([]string{"a100", "not a label"}),
+				}},
+			},
+		}
+		slurmClient := slurmclientfake.NewClientBuilder().WithLists(slurmNodeList).Build()
+		Expect(slurmClient).NotTo(BeNil())
+
+		eventCh := make(chan event.GenericEvent)
+		controllerReconciler = New(k8sClient, k8sClient.Scheme(), schedulerName, eventCh, slurmClient)
+		Expect(controllerReconciler).NotTo(BeNil())
+	})
+
+	Context("Label Kubernetes nodes with Slurm features", func() {
+		It("Should remove feature labels from Kubernetes node", func() {
+			By("syncFeatures()")
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "kube-0"}}
+			err := controllerReconciler.syncFeatures(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Check node labels")
+			checkNode := &corev1.Node{}
+			err = controllerReconciler.Get(ctx, client.ObjectKey{Name: "kube-0"}, checkNode)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(checkNode.Labels).To(BeEmpty())
+		})
+
+		It("Should label bridged node with active features", func() {
+			By("syncFeatures()")
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "bridged-0"}}
+			err := controllerReconciler.syncFeatures(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Check node labels")
+			checkNode := &corev1.Node{}
+			err = controllerReconciler.Get(ctx, client.ObjectKey{Name: "bridged-0"}, checkNode)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(checkNode.Labels).To(Equal(map[string]string{
+				wellknown.LabelPrefixSlurmFeature + "a100": "true",
+				"zone": "a",
+			}))
+		})
+	})
+})
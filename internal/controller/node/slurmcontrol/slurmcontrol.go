@@ -24,12 +24,17 @@ import (
 type SlurmControlInterface interface {
 	// GetNodeNames returns the list Slurm nodes by name.
 	GetNodeNames(ctx context.Context) ([]string, error)
-	// MakeNodeDrain handles adding the DRAIN state to the Slurm node.
-	MakeNodeDrain(ctx context.Context, node *corev1.Node, reason string) error
-	// MakeNodeUndrain handles removing the DRAIN state from the Slurm node.
-	MakeNodeUndrain(ctx context.Context, node *corev1.Node, reason string) error
+	// MakeNodeDrain handles adding the DRAIN state to the Slurm node. It
+	// reports whether the Slurm node was drained by this request.
+	MakeNodeDrain(ctx context.Context, node *corev1.Node, reason string) (bool, error)
+	// MakeNodeUndrain handles removing the DRAIN state from the Slurm node. It
+	// reports whether the Slurm node was undrained by this request.
+	MakeNodeUndrain(ctx context.Context, node *corev1.Node, reason string) (bool, error)
 	// IsNodeDrain checks if the slurm node has the DRAIN state.
 	IsNodeDrain(ctx context.Context, node *corev1.Node) (bool, error)
+	// GetNodeFeatures returns the active features of the Slurm node, or nil if
+	// it does not exist.
+	GetNodeFeatures(ctx context.Context, node *corev1.Node) ([]string, error)
 }
 
 // RealPodControl is the default implementation of SlurmControlInterface.
@@ -53,22 +58,22 @@ func (r *realSlurmControl) GetNodeNames(ctx context.Context) ([]string, error) {
 const nodeReasonPrefix = "slurm-bridge:"
 
 // MakeNodeDrain implements SlurmControlInterface.
-func (r *realSlurmControl) MakeNodeDrain(ctx context.Context, node *corev1.Node, reason string) error {
+func (r *realSlurmControl) MakeNodeDrain(ctx context.Context, node *corev1.Node, reason string) (bool, error) {
 	logger := log.FromContext(ctx)
 
 	slurmNode := &slurmtypes.V0043Node{}
 	key := slurmobject.ObjectKey(nodeutils.GetSlurmNodeName(node))
 	if err := r.Get(ctx, key, slurmNode); err != nil {
 		if tolerateError(err) {
-			return nil
+			return false, nil
 		}
-		return err
+		return false, err
 	}
 
 	if slurmNode.GetStateAsSet().Has(v0043.V0043NodeStateDRAIN) {
 		logger.V(1).Info("node is already drained, skipping drain request",
 			"node", slurmNode.GetKey(), "nodeState", slurmNode.State)
-		return nil
+		return false, nil
 	}
 
 	logger.Info("Make Slurm node drain", "node", klog.KObj(node))
@@ -78,16 +83,16 @@ func (r *realSlurmControl) MakeNodeDrain(ctx context.Context, node *corev1.Node,
 	}
 	if err := r.Update(ctx, slurmNode, req); err != nil {
 		if tolerateError(err) {
-			return nil
+			return false, nil
 		}
-		return err
+		return false, err
 	}
 
-	return nil
+	return true, nil
 }
 
 // MakeNodeUndrain implements SlurmControlInterface.
-func (r *realSlurmControl) MakeNodeUndrain(ctx context.Context, node *corev1.Node, reason string) error {
+func (r *realSlurmControl) MakeNodeUndrain(ctx context.Context, node *corev1.Node, reason string) (bool, error) {
 	logger := log.FromContext(ctx)
 
 	slurmNode := &slurmtypes.V0043Node{}
@@ -95,9 +100,9 @@ func (r *realSlurmControl) MakeNodeUndrain(ctx context.Context, node *corev1.Nod
 	opts := &slurmclient.GetOptions{RefreshCache: true}
 	if err := r.Get(ctx, key, slurmNode, opts); err != nil {
 		if tolerateError(err) {
-			return nil
+			return false, nil
 		}
-		return err
+		return false, err
 	}
 
 	nodeReason := ptr.Deref(slurmNode.Reason, "")
@@ -105,11 +110,11 @@ func (r *realSlurmControl) MakeNodeUndrain(ctx context.Context, node *corev1.Nod
 		slurmNode.GetStateAsSet().Has(v0043.V0043NodeStateUNDRAIN) {
 		logger.V(1).Info("Node is already undrained, skipping undrain request",
 			"node", slurmNode.GetKey(), "nodeState", slurmNode.State)
-		return nil
+		return false, nil
 	} else if nodeReason != "" && !strings.Contains(nodeReason, nodeReasonPrefix) {
 		logger.Info("Node was drained but not by slurm-bridge, skipping undrain request",
 			"node", slurmNode.GetKey(), "nodeReason", nodeReason)
-		return nil
+		return false, nil
 	}
 
 	logger.Info("Make Slurm node undrain", "node", klog.KObj(node))
@@ -119,12 +124,12 @@ func (r *realSlurmControl) MakeNodeUndrain(ctx context.Context, node *corev1.Nod
 	}
 	if err := r.Update(ctx, slurmNode, req); err != nil {
 		if tolerateError(err) {
-			return nil
+			return false, nil
 		}
-		return err
+		return false, err
 	}
 
-	return nil
+	return true, nil
 }
 
 // IsNodeDrain implements SlurmControlInterface.
@@ -139,6 +144,19 @@ func (r *realSlurmControl) IsNodeDrain(ctx context.Context, node *corev1.Node) (
 	return isDrain, nil
 }
 
+// GetNodeFeatures implements SlurmControlInterface.
+func (r *realSlurmControl) GetNodeFeatures(ctx context.Context, node *corev1.Node) ([]string, error) {
+	key := slurmobject.ObjectKey(nodeutils.GetSlurmNodeName(node))
+	slurmNode := &slurmtypes.V0043Node{}
+	if err := r.Get(ctx, key, slurmNode); err != nil {
+		if tolerateError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ptr.Deref(slurmNode.ActiveFeatures, nil), nil
+}
+
 var _ SlurmControlInterface = &realSlurmControl{}
 
 func NewControl(client slurmclient.Client) SlurmControlInterface {
@@ -22,6 +22,8 @@ import (
 	"github.com/SlinkyProject/slurm-client/pkg/client/interceptor"
 	"github.com/SlinkyProject/slurm-client/pkg/object"
 	"github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 )
 
 func Test_realSlurmControl_GetNodeNames(t *testing.T) {
@@ -126,6 +128,7 @@ func Test_realSlurmControl_MakeNodeDrain(t *testing.T) {
 		name    string
 		fields  fields
 		args    args
+		want    bool
 		wantErr bool
 	}{
 		{
@@ -137,6 +140,7 @@ func Test_realSlurmControl_MakeNodeDrain(t *testing.T) {
 				ctx:  context.TODO(),
 				node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}},
 			},
+			want:    false,
 			wantErr: false,
 		},
 		{
@@ -151,6 +155,25 @@ func Test_realSlurmControl_MakeNodeDrain(t *testing.T) {
 				ctx:  context.TODO(),
 				node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}},
 			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "already drained",
+			fields: func() fields {
+				node := &types.V0043Node{V0043Node: v0043.V0043Node{
+					Name:  ptr.To("node-0"),
+					State: ptr.To([]v0043.V0043NodeState{v0043.V0043NodeStateIDLE, v0043.V0043NodeStateDRAIN}),
+				}}
+				return fields{
+					Client: fake.NewClientBuilder().WithObjects(node).Build(),
+				}
+			}(),
+			args: args{
+				ctx:  context.TODO(),
+				node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}},
+			},
+			want:    false,
 			wantErr: false,
 		},
 	}
@@ -159,8 +182,13 @@ func Test_realSlurmControl_MakeNodeDrain(t *testing.T) {
 			r := &realSlurmControl{
 				Client: tt.fields.Client,
 			}
-			if err := r.MakeNodeDrain(tt.args.ctx, tt.args.node, tt.args.reason); (err != nil) != tt.wantErr {
+			got, err := r.MakeNodeDrain(tt.args.ctx, tt.args.node, tt.args.reason)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("realSlurmControl.MakeNodeDrain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("realSlurmControl.MakeNodeDrain() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -179,6 +207,7 @@ func Test_realSlurmControl_MakeNodeUndrain(t *testing.T) {
 		name    string
 		fields  fields
 		args    args
+		want    bool
 		wantErr bool
 	}{
 		{
@@ -190,6 +219,7 @@ func Test_realSlurmControl_MakeNodeUndrain(t *testing.T) {
 				ctx:  context.TODO(),
 				node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}},
 			},
+			want:    false,
 			wantErr: false,
 		},
 		{
@@ -204,6 +234,45 @@ func Test_realSlurmControl_MakeNodeUndrain(t *testing.T) {
 				ctx:  context.TODO(),
 				node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}},
 			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "drained by slurm-bridge",
+			fields: func() fields {
+				node := &types.V0043Node{V0043Node: v0043.V0043Node{
+					Name:   ptr.To("node-0"),
+					State:  ptr.To([]v0043.V0043NodeState{v0043.V0043NodeStateIDLE, v0043.V0043NodeStateDRAIN}),
+					Reason: ptr.To(nodeReasonPrefix + " cordoned"),
+				}}
+				return fields{
+					Client: fake.NewClientBuilder().WithObjects(node).Build(),
+				}
+			}(),
+			args: args{
+				ctx:  context.TODO(),
+				node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}},
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "drained by another actor",
+			fields: func() fields {
+				node := &types.V0043Node{V0043Node: v0043.V0043Node{
+					Name:   ptr.To("node-0"),
+					State:  ptr.To([]v0043.V0043NodeState{v0043.V0043NodeStateIDLE, v0043.V0043NodeStateDRAIN}),
+					Reason: ptr.To("maintenance"),
+				}}
+				return fields{
+					Client: fake.NewClientBuilder().WithObjects(node).Build(),
+				}
+			}(),
+			args: args{
+				ctx:  context.TODO(),
+				node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}},
+			},
+			want:    false,
 			wantErr: false,
 		},
 	}
@@ -212,8 +281,13 @@ func Test_realSlurmControl_MakeNodeUndrain(t *testing.T) {
 			r := &realSlurmControl{
 				Client: tt.fields.Client,
 			}
-			if err := r.MakeNodeUndrain(tt.args.ctx, tt.args.node, tt.args.reason); (err != nil) != tt.wantErr {
+			got, err := r.MakeNodeUndrain(tt.args.ctx, tt.args.node, tt.args.reason)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("realSlurmControl.MakeNodeUndrain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("realSlurmControl.MakeNodeUndrain() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -306,6 +380,74 @@ func Test_realSlurmControl_IsNodeDrain(t *testing.T) {
 	}
 }
 
+func Test_realSlurmControl_GetNodeFeatures(t *testing.T) {
+	type fields struct {
+		Client client.Client
+	}
+	type args struct {
+		ctx  context.Context
+		node *corev1.Node
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "not found",
+			fields: fields{
+				Client: fake.NewFakeClient(),
+			},
+			args: args{
+				ctx:  context.TODO(),
+				node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}},
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name: "active features",
+			fields: func() fields {
+				node := &types.V0043Node{
+					V0043Node: v0043.V0043Node{
+						Name:           ptr.To("slurm-0"),
+						ActiveFeatures: ptr.To([]string{"a100", "ib"}),
+					},
+				}
+				return fields{
+					Client: fake.NewClientBuilder().WithObjects(node).Build(),
+				}
+			}(),
+			args: args{
+				ctx: context.TODO(),
+				node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+					Name:   "node-0",
+					Labels: map[string]string{wellknown.LabelSlurmNodeName: "slurm-0"},
+				}},
+			},
+			want:    []string{"a100", "ib"},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &realSlurmControl{
+				Client: tt.fields.Client,
+			}
+			got, err := r.GetNodeFeatures(tt.args.ctx, tt.args.node)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.GetNodeFeatures() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !apiequality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("realSlurmControl.GetNodeFeatures() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_tolerateError(t *testing.T) {
 	type args struct {
 		err error
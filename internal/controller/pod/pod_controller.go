@@ -7,6 +7,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"strconv"
 	"sync"
 	"time"
@@ -31,6 +32,7 @@ import (
 	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
 	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
 
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
 	"github.com/SlinkyProject/slurm-bridge/internal/controller/pod/slurmcontrol"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils/durationstore"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils/placeholderinfo"
@@ -61,15 +63,22 @@ type PodReconciler struct {
 	client.Client
 	Scheme        *runtime.Scheme
 	SchedulerName string
+	Teardown      config.Teardown
 
 	SlurmClient slurmclient.Client
-	EventCh     chan event.GenericEvent
+	// SlurmHTTPClient is used for Slurm REST API requests which the
+	// SlurmClient does not support.
+	SlurmHTTPClient *http.Client
+	EventCh         chan event.GenericEvent
 
 	slurmControl  slurmcontrol.SlurmControlInterface
-	eventRecorder record.EventRecorderLogger
+	eventRecorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups="",resources=pods,verbs=delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups="",resources=pods/status,verbs=patch;update
+// +kubebuilder:rbac:groups="",resources=pods/eviction,verbs=create
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -106,6 +115,9 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ct
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PodReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.eventRecorder == nil {
+		r.eventRecorder = mgr.GetEventRecorderFor("workload-controller")
+	}
 	r.setupInternal()
 	podEventHandler := &podEventHandler{
 		SchedulerName: r.SchedulerName,
@@ -126,7 +138,7 @@ func (r *PodReconciler) setupInternal() {
 		r.eventRecorder = record.NewBroadcaster().NewRecorder(r.Scheme, corev1.EventSource{Component: "workload-controller"})
 	}
 	if r.slurmControl == nil {
-		r.slurmControl = slurmcontrol.NewControl(r.SlurmClient)
+		r.slurmControl = slurmcontrol.NewControl(r.SlurmClient, r.SlurmHTTPClient)
 	}
 	if r.EventCh != nil {
 		r.setupEventHandler()
@@ -152,7 +164,8 @@ func (r *PodReconciler) setupEventHandler() {
 				return
 			}
 			jobId := ptr.Deref(job.JobId, 0)
-			r.generatePodEvents(jobId, true)
+			// Pods attached to a Slurm job do not own it
+			r.generatePodEvents(jobId, !phInfo.Attached)
 		},
 		UpdateFunc: func(oldObj, newObj any) {
 			jobOld, ok := oldObj.(*slurmtypes.V0043JobInfo)
@@ -187,7 +200,8 @@ func (r *PodReconciler) setupEventHandler() {
 				return
 			}
 			jobId := ptr.Deref(job.JobId, 0)
-			r.generatePodEvents(jobId, true)
+			// Pods attached to a Slurm job do not own it
+			r.generatePodEvents(jobId, !phInfo.Attached)
 		},
 	})
 }
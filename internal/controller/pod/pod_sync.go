@@ -5,11 +5,18 @@ package pod
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"time"
 
+	"github.com/SlinkyProject/slurm-bridge/internal/metrics"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/placeholderinfo"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjobir"
 	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
@@ -18,6 +25,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
 )
 
 func (r *PodReconciler) Sync(ctx context.Context, req reconcile.Request) error {
@@ -63,26 +73,94 @@ func (r *PodReconciler) syncKubernetes(ctx context.Context, req reconcile.Reques
 		return nil
 	}
 
+	ctx = metrics.WithWorkloadKind(ctx, metrics.WorkloadKind(pod))
 	jobId := slurmjobir.ParseSlurmJobId(pod.Labels[wellknown.LabelPlaceholderJobId])
-	exists, err := r.slurmControl.IsJobRunning(ctx, pod)
+	job, err := r.slurmControl.GetJob(ctx, pod)
 	if err != nil {
 		logger.Error(err, "failed to fetch Slurm job information", "jobId", jobId)
 		return err
 	}
 
-	if !exists {
-		logger.Info("Deleting Pod for corresponding Slurm Job",
-			"pod", podKey, "jobId", jobId)
-		if err := r.Delete(ctx, pod); err != nil {
+	if job == nil || !job.GetStateAsSet().Has(v0043.V0043JobInfoJobStateRUNNING) {
+		reason := disruptionReason(job)
+		logger.Info("Terminating Pod for corresponding Slurm Job",
+			"pod", podKey, "jobId", jobId, "reason", reason)
+		message := fmt.Sprintf("Slurm job %d is no longer running", jobId)
+		if err := r.terminatePod(ctx, pod, reason, message); err != nil {
 			logger.Error(err, "failed to terminate Pod without corresponding Slurm Job",
 				"pod", podKey, "jobId", jobId)
 			return err
 		}
+		r.eventRecorder.Eventf(pod, corev1.EventTypeNormal, reason, "Deleting pod, %s", message)
 	}
 
 	return nil
 }
 
+// disruptionReason returns the DisruptionTarget condition reason for a pod
+// whose Slurm job is no longer running.
+func disruptionReason(job *slurmtypes.V0043JobInfo) string {
+	if job == nil {
+		return wellknown.ReasonSlurmJobEnded
+	}
+	state := job.GetStateAsSet()
+	switch {
+	case state.Has(v0043.V0043JobInfoJobStateTIMEOUT) || state.Has(v0043.V0043JobInfoJobStateDEADLINE):
+		return wellknown.ReasonSlurmJobTimeout
+	case state.Has(v0043.V0043JobInfoJobStatePREEMPTED):
+		return wellknown.ReasonSlurmJobPreempted
+	case state.Has(v0043.V0043JobInfoJobStateNODEFAIL):
+		return wellknown.ReasonSlurmJobNodeFail
+	case state.Has(v0043.V0043JobInfoJobStateCANCELLED):
+		return wellknown.ReasonSlurmJobCancelled
+	default:
+		return wellknown.ReasonSlurmJobEnded
+	}
+}
+
+// terminatePod marks the pod with a DisruptionTarget condition, then evicts or
+// deletes it within its termination grace period.
+func (r *PodReconciler) terminatePod(ctx context.Context, pod *corev1.Pod, reason, message string) error {
+	logger := log.FromContext(ctx)
+
+	toUpdate := pod.DeepCopy()
+	podv1.UpdatePodCondition(&toUpdate.Status, &corev1.PodCondition{
+		Type:               corev1.DisruptionTarget,
+		Status:             corev1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	})
+	if err := r.Status().Patch(ctx, toUpdate, client.StrategicMergeFrom(pod)); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	deleteOptions := &metav1.DeleteOptions{
+		GracePeriodSeconds: pod.Spec.TerminationGracePeriodSeconds,
+	}
+	if r.Teardown.Evict {
+		eviction := &policyv1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+			},
+			DeleteOptions: deleteOptions,
+		}
+		err := r.SubResource("eviction").Create(ctx, pod, eviction)
+		if !apierrors.IsTooManyRequests(err) {
+			return client.IgnoreNotFound(err)
+		}
+		// The Slurm allocation of the pod is gone, so a PodDisruptionBudget
+		// must not keep the pod running.
+		logger.Info("Eviction blocked, deleting Pod instead", "pod", klog.KObj(pod), "err", err)
+	}
+	return client.IgnoreNotFound(r.Delete(ctx, pod, &client.DeleteOptions{Raw: deleteOptions}))
+}
+
+// jobEndGracePeriod is how long a Slurm job has to exit once it was signaled
+// with the outcome of its pods, before it is cancelled.
+const jobEndGracePeriod = 30 * time.Second
+
 // syncSlurm reconciles the Slurm Job with Kubernetes Pods.
 // It will terminate the job corresponding to a terminat[ed,ing] pod.
 func (r *PodReconciler) syncSlurm(ctx context.Context, req reconcile.Request) error {
@@ -127,9 +205,29 @@ func (r *PodReconciler) syncSlurm(ctx context.Context, req reconcile.Request) er
 		}
 	}
 	if activePods == 0 {
+		ctx = metrics.WithWorkloadKind(ctx, metrics.WorkloadKind(pod))
 		jobId := slurmjobir.ParseSlurmJobId(pod.Labels[wellknown.LabelPlaceholderJobId])
+		attached, err := r.isAttachedJob(ctx, pod)
+		if err != nil {
+			logger.Error(err, "failed to fetch Slurm job information", "jobId", jobId)
+			return err
+		}
+		if attached {
+			logger.Info("Pods were attached to Slurm Job, not terminating it", "pod", klog.KObj(pod), "jobId", jobId)
+			return nil
+		}
+		// The batch script of the job reads the exit code of failed pods from
+		// its comment.
+		phase, outcome := podsOutcome(pods.Items)
+		if phase != "" {
+			logger.Info("Record outcome of Pods on Slurm Job", "jobId", jobId, "outcome", outcome)
+			if err := r.slurmControl.SetJobComment(ctx, jobId, outcome); err != nil {
+				logger.Error(err, "failed to record outcome of Pods on Slurm Job", "jobId", jobId)
+				return err
+			}
+		}
 		logger.Info("Terminate Slurm Job for Pod", "pod", klog.KObj(pod), "jobId", jobId)
-		if err := r.slurmControl.TerminateJob(ctx, jobId); err != nil {
+		if err := r.terminateJob(ctx, pod, jobId, phase); err != nil {
 			logger.Error(err, "failed to terminate Slurm Job without corresponding Pod",
 				"jobId", jobId, "pod", podKey)
 			return err
@@ -139,6 +237,169 @@ func (r *PodReconciler) syncSlurm(ctx context.Context, req reconcile.Request) er
 	return nil
 }
 
+// isAttachedJob returns true if the pod was attached to an existing Slurm job,
+// which is owned by its user and outlives the pod.
+func (r *PodReconciler) isAttachedJob(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	job, err := r.slurmControl.GetJob(ctx, pod)
+	if err != nil || job == nil {
+		return false, err
+	}
+	phInfo := placeholderinfo.PlaceholderInfo{}
+	if err := placeholderinfo.ParseIntoPlaceholderInfo(job.AdminComment, &phInfo); err != nil {
+		return false, nil
+	}
+	return phInfo.Attached, nil
+}
+
+// isSignaledJob returns true if the Slurm job of the pod is still running after
+// it was sent the teardown signal.
+func (r *PodReconciler) isSignaledJob(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	job, err := r.slurmControl.GetJob(ctx, pod)
+	if err != nil || job == nil || !job.GetStateAsSet().Has(v0043.V0043JobInfoJobStateRUNNING) {
+		return false, err
+	}
+	phInfo := placeholderinfo.PlaceholderInfo{}
+	if err := placeholderinfo.ParseIntoPlaceholderInfo(job.AdminComment, &phInfo); err != nil {
+		return false, nil
+	}
+	return phInfo.SignaledAt != 0, nil
+}
+
+// podsOutcome summarizes the outcome of the ended pods of a Slurm job. The
+// pods failed if any pod failed, with the exit code of the first failed pod by
+// rank, and succeeded if all pods succeeded. Otherwise, the pods were deleted
+// before they ended and there is no outcome, nor phase.
+func podsOutcome(pods []corev1.Pod) (corev1.PodPhase, string) {
+	pods = slices.Clone(pods)
+	slurmjobir.SortPodsByRank(pods)
+	succeeded := len(pods) > 0
+	for _, p := range pods {
+		switch p.Status.Phase {
+		case corev1.PodFailed:
+			return corev1.PodFailed, fmt.Sprintf("%s: exit code %d (pod %s)",
+				corev1.PodFailed, podExitCode(&p), klog.KObj(&p))
+		case corev1.PodSucceeded:
+		default:
+			succeeded = false
+		}
+	}
+	if !succeeded {
+		return "", ""
+	}
+	return corev1.PodSucceeded, fmt.Sprintf("%s: exit code 0", corev1.PodSucceeded)
+}
+
+// podExitCode returns the first non-zero exit code of the containers of a
+// failed pod, or 1 if there is none (e.g. the pod was evicted).
+func podExitCode(pod *corev1.Pod) int32 {
+	statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses)
+	for _, status := range statuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return terminated.ExitCode
+		}
+	}
+	return 1
+}
+
+// terminateJob ends the Slurm job if it has not ended. Once its pods succeeded
+// or failed, the batch step of a running job is signaled to exit with their
+// outcome. Otherwise, when a teardown signal is configured, a running job is
+// signaled first. Either way, the job is cancelled if it is still running once
+// the grace period has passed. The time of the signal is recorded in the admin
+// comment of the job, and the pod is requeued in the meantime.
+func (r *PodReconciler) terminateJob(ctx context.Context, pod *corev1.Pod, jobId int32, phase corev1.PodPhase) error {
+	logger := log.FromContext(ctx)
+	podKey := client.ObjectKeyFromObject(pod).String()
+
+	job, err := r.slurmControl.GetJob(ctx, pod)
+	if err != nil {
+		return err
+	}
+	if isJobEnded(job) {
+		logger.V(2).Info("Slurm Job has already ended", "jobId", jobId)
+		return nil
+	}
+
+	signal := r.Teardown.Signal
+	signalJob := r.slurmControl.SignalJob
+	gracePeriod := time.Duration(r.Teardown.SignalGracePeriodSeconds) * time.Second
+	switch phase {
+	case corev1.PodSucceeded:
+		signal = wellknown.SignalPodsSucceeded
+		signalJob = r.slurmControl.SignalJobBatchStep
+		gracePeriod = jobEndGracePeriod
+	case corev1.PodFailed:
+		signal = wellknown.SignalPodsFailed
+		signalJob = r.slurmControl.SignalJobBatchStep
+		gracePeriod = jobEndGracePeriod
+	}
+
+	phInfo := placeholderinfo.PlaceholderInfo{}
+	if signal == "" || !job.GetStateAsSet().Has(v0043.V0043JobInfoJobStateRUNNING) ||
+		placeholderinfo.ParseIntoPlaceholderInfo(job.AdminComment, &phInfo) != nil {
+		return r.cancelJob(ctx, pod, jobId)
+	}
+
+	if phInfo.SignaledAt != 0 {
+		if remaining := gracePeriod - time.Since(time.Unix(phInfo.SignaledAt, 0)); remaining > 0 {
+			durationStore.Push(podKey, remaining)
+			return nil
+		}
+		return r.cancelJob(ctx, pod, jobId)
+	}
+
+	logger.Info("Signal Slurm Job", "jobId", jobId, "signal", signal)
+	if err := signalJob(ctx, jobId, signal); err != nil {
+		logger.Error(err, "failed to signal Slurm Job, cancelling it", "jobId", jobId)
+		return r.cancelJob(ctx, pod, jobId)
+	}
+	if gracePeriod <= 0 {
+		return r.cancelJob(ctx, pod, jobId)
+	}
+	// Without the time of the signal, the job would be signaled again.
+	phInfo.SignaledAt = time.Now().Unix()
+	if err := r.slurmControl.SetJobAdminComment(ctx, jobId, phInfo.ToString()); err != nil {
+		logger.Error(err, "failed to record signal on Slurm Job, cancelling it", "jobId", jobId)
+		return r.cancelJob(ctx, pod, jobId)
+	}
+	r.eventRecorder.Eventf(pod, corev1.EventTypeNormal, wellknown.ReasonSlurmJobSignaled,
+		"Signaled Slurm job %d with %s, cancelling it in %s if it is still running", jobId, signal, gracePeriod)
+	durationStore.Push(podKey, gracePeriod)
+	return nil
+}
+
+// isJobEnded returns true if the Slurm job does not exist or reached a
+// terminal state.
+func isJobEnded(job *slurmtypes.V0043JobInfo) bool {
+	if job == nil {
+		return true
+	}
+	state := job.GetStateAsSet()
+	return state.HasAny(
+		v0043.V0043JobInfoJobStateBOOTFAIL,
+		v0043.V0043JobInfoJobStateCANCELLED,
+		v0043.V0043JobInfoJobStateCOMPLETED,
+		v0043.V0043JobInfoJobStateDEADLINE,
+		v0043.V0043JobInfoJobStateFAILED,
+		v0043.V0043JobInfoJobStateNODEFAIL,
+		v0043.V0043JobInfoJobStateOUTOFMEMORY,
+		v0043.V0043JobInfoJobStatePREEMPTED,
+		v0043.V0043JobInfoJobStateTIMEOUT,
+	)
+}
+
+func (r *PodReconciler) cancelJob(ctx context.Context, pod *corev1.Pod, jobId int32) error {
+	start := time.Now()
+	err := r.slurmControl.TerminateJob(ctx, jobId)
+	metrics.ObservePlaceholderJobOperation(metrics.OperationDelete, metrics.WorkloadKind(pod), start, err)
+	if err != nil {
+		return err
+	}
+	r.eventRecorder.Eventf(pod, corev1.EventTypeNormal, wellknown.ReasonSlurmJobTerminated,
+		"Cancelled Slurm job %d, its pods have ended", jobId)
+	return nil
+}
+
 // deleteFinalizer will remove the finalizer from the pod if it is to be deleted.
 // This is done to ensure syncSlurm is able to get the pod labels to determine
 // if the pod has a placeholder JobId.
@@ -166,6 +427,17 @@ func (r *PodReconciler) deleteFinalizer(ctx context.Context, req reconcile.Reque
 		return nil
 	}
 
+	// Keep the pod until its signaled Slurm job is cancelled.
+	signaled, err := r.isSignaledJob(ctx, pod)
+	if err != nil {
+		logger.Error(err, "failed to fetch Slurm job information", "pod", podKey)
+		return err
+	}
+	if signaled {
+		logger.V(2).Info("Slurm Job is being terminated, skipping", "pod", podKey)
+		return nil
+	}
+
 	finalizers := []string{}
 	for _, f := range pod.Finalizers {
 		if f != wellknown.FinalizerScheduler {
@@ -6,6 +6,8 @@ package pod
 import (
 	"context"
 	"strconv"
+	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -15,13 +17,16 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	podv1 "k8s.io/kubernetes/pkg/api/v1/pod"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
 	slurmclientfake "github.com/SlinkyProject/slurm-client/pkg/client/fake"
+	"github.com/SlinkyProject/slurm-client/pkg/object"
 	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
 
 	"github.com/SlinkyProject/slurm-bridge/internal/controller/pod/slurmcontrol"
@@ -113,7 +118,7 @@ var _ = Describe("syncKubernetes()", func() {
 			Scheme:        scheme.Scheme,
 			SlurmClient:   c,
 			EventCh:       make(chan event.GenericEvent, 5),
-			slurmControl:  slurmcontrol.NewControl(c),
+			slurmControl:  slurmcontrol.NewControl(c, nil),
 			eventRecorder: record.NewFakeRecorder(10),
 		}
 	})
@@ -146,9 +151,99 @@ var _ = Describe("syncKubernetes()", func() {
 			err = controller.Get(ctx, key, pod)
 			Expect(apierrors.IsNotFound(err)).To(BeTrue())
 		})
+
+		It("Should only terminate the pods of the ended job on a shared node", func() {
+			By("Placing pods of two jobs on the same node")
+			key := types.NamespacedName{Namespace: corev1.NamespaceDefault, Name: podName}
+			pod := &corev1.Pod{}
+			Expect(controller.Get(ctx, key, pod)).To(Succeed())
+			pod.Spec.NodeName = "node1"
+			Expect(controller.Update(ctx, pod)).To(Succeed())
+			other := newPod("qux", 2)
+			other.Spec.NodeName = "node1"
+			Expect(controller.Create(ctx, other)).To(Succeed())
+
+			By("Terminating the Slurm job of the other pod")
+			err := controller.slurmControl.TerminateJob(ctx, 2)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Reconciling both pods")
+			Expect(controller.syncKubernetes(ctx, newRequest("qux"))).To(Succeed())
+			Expect(controller.syncKubernetes(ctx, req)).To(Succeed())
+
+			By("Check only the pod of the ended job was terminated")
+			err = controller.Get(ctx, types.NamespacedName{Namespace: corev1.NamespaceDefault, Name: "qux"}, &corev1.Pod{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(controller.Get(ctx, key, pod)).To(Succeed())
+		})
+
+		It("Should evict the pod with a disruption condition", func() {
+			controller.Teardown.Evict = true
+			key := types.NamespacedName{Namespace: corev1.NamespaceDefault, Name: podName}
+			pod := &corev1.Pod{}
+			Expect(controller.Get(ctx, key, pod)).To(Succeed())
+			pod.Finalizers = []string{wellknown.FinalizerScheduler}
+			Expect(controller.Update(ctx, pod)).To(Succeed())
+
+			By("Terminating the corresponding Slurm job")
+			err := controller.slurmControl.TerminateJob(ctx, jobId)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Reconciling")
+			err = controller.syncKubernetes(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Check pod is terminating with a disruption condition")
+			Expect(controller.Get(ctx, key, pod)).To(Succeed())
+			Expect(pod.DeletionTimestamp).ToNot(BeNil())
+			_, condition := podv1.GetPodCondition(&pod.Status, corev1.DisruptionTarget)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal(wellknown.ReasonSlurmJobEnded))
+
+			By("Check an event was recorded")
+			recorder := controller.eventRecorder.(*record.FakeRecorder)
+			Expect(recorder.Events).To(Receive(ContainSubstring(wellknown.ReasonSlurmJobEnded)))
+		})
 	})
 })
 
+type signalRecorder struct {
+	slurmcontrol.SlurmControlInterface
+	signals      []string
+	batchSignals []string
+}
+
+func (s *signalRecorder) SignalJob(ctx context.Context, jobId int32, signal string) error {
+	s.signals = append(s.signals, signal)
+	return nil
+}
+
+func (s *signalRecorder) SignalJobBatchStep(ctx context.Context, jobId int32, signal string) error {
+	s.batchSignals = append(s.batchSignals, signal)
+	return nil
+}
+
+// mergeJobUpdates makes the fake Slurm client apply the comments of a job
+// update onto the stored job, as slurmrestd does, instead of replacing it.
+func mergeJobUpdates(c *slurmclient.Client) func(context.Context, object.Object, any, ...slurmclient.UpdateOption) error {
+	return func(ctx context.Context, obj object.Object, req any, _ ...slurmclient.UpdateOption) error {
+		job := obj.(*slurmtypes.V0043JobInfo)
+		stored := &slurmtypes.V0043JobInfo{}
+		if err := (*c).Get(ctx, job.GetKey(), stored); err != nil {
+			return err
+		}
+		jobDesc := req.(v0043.V0043JobDescMsg)
+		if jobDesc.Comment != nil {
+			stored.Comment = jobDesc.Comment
+		}
+		if jobDesc.AdminComment != nil {
+			stored.AdminComment = jobDesc.AdminComment
+		}
+		*job = *stored
+		return nil
+	}
+}
+
 var _ = Describe("syncSlurm()", func() {
 	var controller *PodReconciler
 
@@ -173,9 +268,42 @@ var _ = Describe("syncSlurm()", func() {
 						AdminComment: ptr.To(newPlaceholderInfo("bar").ToString()),
 					},
 				},
+				{
+					V0043JobInfo: v0043.V0043JobInfo{
+						JobId:    ptr.To[int32](3),
+						JobState: &[]v0043.V0043JobInfoJobState{v0043.V0043JobInfoJobStateRUNNING},
+						AdminComment: func() *string {
+							phInfo := newPlaceholderInfo("baz")
+							phInfo.Attached = true
+							return ptr.To(phInfo.ToString())
+						}(),
+					},
+				},
+				{
+					V0043JobInfo: v0043.V0043JobInfo{
+						JobId:        ptr.To[int32](4),
+						JobState:     &[]v0043.V0043JobInfoJobState{v0043.V0043JobInfoJobStateCOMPLETED},
+						AdminComment: ptr.To(newPlaceholderInfo("qux").ToString()),
+					},
+				},
+				{
+					V0043JobInfo: v0043.V0043JobInfo{
+						JobId:        ptr.To[int32](5),
+						JobState:     &[]v0043.V0043JobInfoJobState{v0043.V0043JobInfoJobStatePENDING},
+						AdminComment: ptr.To(newPlaceholderInfo("quux").ToString()),
+					},
+				},
+				{
+					V0043JobInfo: v0043.V0043JobInfo{
+						JobId:        ptr.To[int32](6),
+						JobState:     &[]v0043.V0043JobInfoJobState{v0043.V0043JobInfoJobStateRUNNING},
+						AdminComment: ptr.To(newPlaceholderInfo("corge").ToString()),
+					},
+				},
 			},
 		}
-		c := slurmclientfake.NewClientBuilder().WithLists(jobList).Build()
+		var c slurmclient.Client
+		c = slurmclientfake.NewClientBuilder().WithLists(jobList).WithUpdateFn(mergeJobUpdates(&c)).Build()
 		podList := &corev1.PodList{
 			Items: []corev1.Pod{
 				*newPod("foo", jobId),
@@ -194,6 +322,68 @@ var _ = Describe("syncSlurm()", func() {
 						Phase: corev1.PodSucceeded,
 					},
 				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: metav1.NamespaceDefault,
+						Name:      "baz",
+						Labels: map[string]string{
+							wellknown.LabelPlaceholderJobId: "3",
+						},
+					},
+					Spec: corev1.PodSpec{
+						SchedulerName: schedulerName,
+					},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodSucceeded,
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: metav1.NamespaceDefault,
+						Name:      "qux",
+						Labels: map[string]string{
+							wellknown.LabelPlaceholderJobId: "4",
+						},
+					},
+					Spec: corev1.PodSpec{
+						SchedulerName: schedulerName,
+					},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodSucceeded,
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: metav1.NamespaceDefault,
+						Name:      "quux",
+						Labels: map[string]string{
+							wellknown.LabelPlaceholderJobId: "5",
+						},
+					},
+					Spec: corev1.PodSpec{
+						SchedulerName: schedulerName,
+					},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodFailed,
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: metav1.NamespaceDefault,
+						Name:      "corge",
+						Labels: map[string]string{
+							wellknown.LabelPlaceholderJobId: "6",
+						},
+						DeletionTimestamp: ptr.To(metav1.Now()),
+						Finalizers:        []string{wellknown.FinalizerScheduler},
+					},
+					Spec: corev1.PodSpec{
+						SchedulerName: schedulerName,
+					},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+					},
+				},
 			},
 		}
 		controller = &PodReconciler{
@@ -201,7 +391,7 @@ var _ = Describe("syncSlurm()", func() {
 			Scheme:        scheme.Scheme,
 			SlurmClient:   c,
 			EventCh:       make(chan event.GenericEvent, 5),
-			slurmControl:  slurmcontrol.NewControl(c),
+			slurmControl:  slurmcontrol.NewControl(c, nil),
 			eventRecorder: record.NewFakeRecorder(10),
 		}
 	})
@@ -224,22 +414,141 @@ var _ = Describe("syncSlurm()", func() {
 			Expect(exists).To(BeTrue())
 		})
 
-		It("Should terminate the job", func() {
-			By("Terminating the corresponding Slurm job")
+		It("Should end the job with the outcome of its pods", func() {
+			recorder := &signalRecorder{SlurmControlInterface: controller.slurmControl}
+			controller.slurmControl = recorder
 			key := types.NamespacedName{Namespace: corev1.NamespaceDefault, Name: "bar"}
 			pod := &corev1.Pod{}
-			err := controller.Get(ctx, key, pod)
-			Expect(apierrors.IsNotFound(err)).ToNot(BeTrue())
+			Expect(controller.Get(ctx, key, pod)).To(Succeed())
 
 			By("Reconciling")
+			err := controller.syncSlurm(ctx, newRequest("bar"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.batchSignals).To(Equal([]string{wellknown.SignalPodsSucceeded}))
+			Expect(recorder.signals).To(BeEmpty())
+
+			By("Check the outcome is recorded on the job")
+			job, err := controller.slurmControl.GetJob(ctx, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job).ToNot(BeNil())
+			Expect(ptr.Deref(job.Comment, "")).To(Equal("Succeeded: exit code 0"))
+
+			By("Reconciling after the grace period")
+			phInfo := placeholderinfo.PlaceholderInfo{}
+			Expect(placeholderinfo.ParseIntoPlaceholderInfo(job.AdminComment, &phInfo)).To(Succeed())
+			Expect(phInfo.SignaledAt).ToNot(BeZero())
+			phInfo.SignaledAt = time.Now().Add(-time.Hour).Unix()
+			Expect(controller.slurmControl.SetJobAdminComment(ctx, 2, phInfo.ToString())).To(Succeed())
 			err = controller.syncSlurm(ctx, newRequest("bar"))
 			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.batchSignals).To(HaveLen(1))
 
 			By("Check job is not running")
 			exists, err := controller.slurmControl.IsJobRunning(ctx, pod)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(exists).To(BeFalse())
 		})
+
+		It("Should not terminate an attached job", func() {
+			key := types.NamespacedName{Namespace: corev1.NamespaceDefault, Name: "baz"}
+			pod := &corev1.Pod{}
+			Expect(controller.Get(ctx, key, pod)).To(Succeed())
+
+			By("Reconciling")
+			err := controller.syncSlurm(ctx, newRequest("baz"))
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Check job is running")
+			exists, err := controller.slurmControl.IsJobRunning(ctx, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		})
+
+		It("Should signal the job before terminating it", func() {
+			recorder := &signalRecorder{SlurmControlInterface: controller.slurmControl}
+			controller.slurmControl = recorder
+			controller.Teardown.Signal = "SIGTERM"
+			controller.Teardown.SignalGracePeriodSeconds = 30
+			key := types.NamespacedName{Namespace: corev1.NamespaceDefault, Name: "corge"}
+			pod := &corev1.Pod{}
+			Expect(controller.Get(ctx, key, pod)).To(Succeed())
+
+			By("Reconciling")
+			err := controller.syncSlurm(ctx, newRequest("corge"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.signals).To(Equal([]string{"SIGTERM"}))
+			Expect(recorder.batchSignals).To(BeEmpty())
+			Expect(durationStore.Pop(newRequest("corge").String())).To(BeNumerically(">", 0))
+
+			By("Check job is not cancelled within the grace period")
+			job, err := controller.slurmControl.GetJob(ctx, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job).ToNot(BeNil())
+
+			By("Check the signal is recorded on the job")
+			phInfo := placeholderinfo.PlaceholderInfo{}
+			Expect(placeholderinfo.ParseIntoPlaceholderInfo(job.AdminComment, &phInfo)).To(Succeed())
+			Expect(phInfo.SignaledAt).ToNot(BeZero())
+
+			By("Check the pod is kept within the grace period")
+			signaled, err := controller.isSignaledJob(ctx, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signaled).To(BeTrue())
+
+			By("Reconciling after the grace period")
+			phInfo.SignaledAt = time.Now().Add(-time.Minute).Unix()
+			Expect(controller.slurmControl.SetJobAdminComment(ctx, 6, phInfo.ToString())).To(Succeed())
+			err = controller.syncSlurm(ctx, newRequest("corge"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.signals).To(HaveLen(1))
+
+			By("Check job is cancelled")
+			job, err = controller.slurmControl.GetJob(ctx, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job).To(BeNil())
+		})
+
+		It("Should not signal an ended job", func() {
+			recorder := &signalRecorder{SlurmControlInterface: controller.slurmControl}
+			controller.slurmControl = recorder
+			controller.Teardown.Signal = "SIGTERM"
+			controller.Teardown.SignalGracePeriodSeconds = 30
+			key := types.NamespacedName{Namespace: corev1.NamespaceDefault, Name: "qux"}
+			pod := &corev1.Pod{}
+			Expect(controller.Get(ctx, key, pod)).To(Succeed())
+
+			By("Reconciling")
+			err := controller.syncSlurm(ctx, newRequest("qux"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.signals).To(BeEmpty())
+			Expect(recorder.batchSignals).To(BeEmpty())
+
+			By("Check job is not cancelled")
+			job, err := controller.slurmControl.GetJob(ctx, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job).ToNot(BeNil())
+		})
+
+		It("Should cancel a pending job without signaling it", func() {
+			recorder := &signalRecorder{SlurmControlInterface: controller.slurmControl}
+			controller.slurmControl = recorder
+			controller.Teardown.Signal = "SIGTERM"
+			controller.Teardown.SignalGracePeriodSeconds = 30
+			key := types.NamespacedName{Namespace: corev1.NamespaceDefault, Name: "quux"}
+			pod := &corev1.Pod{}
+			Expect(controller.Get(ctx, key, pod)).To(Succeed())
+
+			By("Reconciling")
+			err := controller.syncSlurm(ctx, newRequest("quux"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.signals).To(BeEmpty())
+			Expect(recorder.batchSignals).To(BeEmpty())
+
+			By("Check job is cancelled")
+			job, err := controller.slurmControl.GetJob(ctx, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job).To(BeNil())
+		})
 	})
 })
 
@@ -283,7 +592,7 @@ var _ = Describe("Sync()", func() {
 			SchedulerName: schedulerName,
 			SlurmClient:   c,
 			EventCh:       make(chan event.GenericEvent, 5),
-			slurmControl:  slurmcontrol.NewControl(c),
+			slurmControl:  slurmcontrol.NewControl(c, nil),
 			eventRecorder: record.NewFakeRecorder(10),
 		}
 	})
@@ -366,7 +675,7 @@ var _ = Describe("deleteFinalizer()", func() {
 			Scheme:        scheme.Scheme,
 			SlurmClient:   c,
 			EventCh:       make(chan event.GenericEvent, 5),
-			slurmControl:  slurmcontrol.NewControl(c),
+			slurmControl:  slurmcontrol.NewControl(c, nil),
 			eventRecorder: record.NewFakeRecorder(10),
 		}
 	})
@@ -388,3 +697,167 @@ var _ = Describe("deleteFinalizer()", func() {
 		})
 	})
 })
+
+func Test_disruptionReason(t *testing.T) {
+	newJob := func(states ...v0043.V0043JobInfoJobState) *slurmtypes.V0043JobInfo {
+		return &slurmtypes.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{JobState: &states}}
+	}
+	tests := []struct {
+		name string
+		job  *slurmtypes.V0043JobInfo
+		want string
+	}{
+		{
+			name: "Job not found",
+			job:  nil,
+			want: wellknown.ReasonSlurmJobEnded,
+		},
+		{
+			name: "Timeout",
+			job:  newJob(v0043.V0043JobInfoJobStateTIMEOUT),
+			want: wellknown.ReasonSlurmJobTimeout,
+		},
+		{
+			name: "Preempted",
+			job:  newJob(v0043.V0043JobInfoJobStatePREEMPTED),
+			want: wellknown.ReasonSlurmJobPreempted,
+		},
+		{
+			name: "Node failure",
+			job:  newJob(v0043.V0043JobInfoJobStateNODEFAIL),
+			want: wellknown.ReasonSlurmJobNodeFail,
+		},
+		{
+			name: "Cancelled",
+			job:  newJob(v0043.V0043JobInfoJobStateCANCELLED),
+			want: wellknown.ReasonSlurmJobCancelled,
+		},
+		{
+			name: "Completed",
+			job:  newJob(v0043.V0043JobInfoJobStateCOMPLETED),
+			want: wellknown.ReasonSlurmJobEnded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := disruptionReason(tt.job); got != tt.want {
+				t.Errorf("disruptionReason() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isJobEnded(t *testing.T) {
+	newJob := func(states ...v0043.V0043JobInfoJobState) *slurmtypes.V0043JobInfo {
+		return &slurmtypes.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{JobState: &states}}
+	}
+	tests := []struct {
+		name string
+		job  *slurmtypes.V0043JobInfo
+		want bool
+	}{
+		{
+			name: "Job not found",
+			job:  nil,
+			want: true,
+		},
+		{
+			name: "Pending",
+			job:  newJob(v0043.V0043JobInfoJobStatePENDING),
+			want: false,
+		},
+		{
+			name: "Running",
+			job:  newJob(v0043.V0043JobInfoJobStateRUNNING),
+			want: false,
+		},
+		{
+			name: "Completing",
+			job:  newJob(v0043.V0043JobInfoJobStateCOMPLETED, v0043.V0043JobInfoJobStateCOMPLETING),
+			want: true,
+		},
+		{
+			name: "Timeout",
+			job:  newJob(v0043.V0043JobInfoJobStateTIMEOUT),
+			want: true,
+		},
+		{
+			name: "Cancelled",
+			job:  newJob(v0043.V0043JobInfoJobStateCANCELLED),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isJobEnded(tt.job); got != tt.want {
+				t.Errorf("isJobEnded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_podsOutcome(t *testing.T) {
+	newEndedPod := func(name string, phase corev1.PodPhase, exitCode int32) corev1.Pod {
+		pod := newPod(name, 1)
+		pod.Status = corev1.PodStatus{
+			Phase: phase,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode}}},
+			},
+		}
+		return *pod
+	}
+	tests := []struct {
+		name      string
+		pods      []corev1.Pod
+		wantPhase corev1.PodPhase
+		want      string
+	}{
+		{
+			name: "No pods",
+			pods: []corev1.Pod{},
+		},
+		{
+			name: "All succeeded",
+			pods: []corev1.Pod{
+				newEndedPod("foo-0", corev1.PodSucceeded, 0),
+				newEndedPod("foo-1", corev1.PodSucceeded, 0),
+			},
+			wantPhase: corev1.PodSucceeded,
+			want:      "Succeeded: exit code 0",
+		},
+		{
+			name: "Failed",
+			pods: []corev1.Pod{
+				newEndedPod("foo-0", corev1.PodSucceeded, 0),
+				newEndedPod("foo-2", corev1.PodFailed, 2),
+				newEndedPod("foo-1", corev1.PodFailed, 137),
+			},
+			wantPhase: corev1.PodFailed,
+			want:      "Failed: exit code 137 (pod default/foo-1)",
+		},
+		{
+			name: "Failed without exit code",
+			pods: []corev1.Pod{
+				newEndedPod("foo-0", corev1.PodFailed, 0),
+			},
+			wantPhase: corev1.PodFailed,
+			want:      "Failed: exit code 1 (pod default/foo-0)",
+		},
+		{
+			name: "Deleted before ending",
+			pods: []corev1.Pod{
+				newEndedPod("foo-0", corev1.PodSucceeded, 0),
+				*newPod("foo-1", 1),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPhase, got := podsOutcome(tt.pods)
+			if gotPhase != tt.wantPhase || got != tt.want {
+				t.Errorf("podsOutcome() = %v, %v, want %v, %v", gotPhase, got, tt.wantPhase, tt.want)
+			}
+		})
+	}
+}
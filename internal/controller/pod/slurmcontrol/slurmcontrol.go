@@ -5,13 +5,16 @@ package slurmcontrol
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
 
 	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
 	"github.com/SlinkyProject/slurm-client/pkg/client"
+	slurmapi "github.com/SlinkyProject/slurm-client/pkg/client/api/v0043"
 	"github.com/SlinkyProject/slurm-client/pkg/object"
 	"github.com/SlinkyProject/slurm-client/pkg/types"
 
@@ -19,8 +22,18 @@ import (
 )
 
 type SlurmControlInterface interface {
-	// GetJob returns a Slurm Job from a pod annotation
+	// GetJob returns the Slurm Job from a pod label, or nil if it does not exist
+	GetJob(ctx context.Context, pod *corev1.Pod) (*types.V0043JobInfo, error)
+	// IsJobRunning returns true if the Slurm Job from a pod label is running
 	IsJobRunning(ctx context.Context, pod *corev1.Pod) (bool, error)
+	// SetJobComment sets the comment of the Slurm job by JobId
+	SetJobComment(ctx context.Context, jobId int32, comment string) error
+	// SetJobAdminComment sets the admin comment of the Slurm job by JobId
+	SetJobAdminComment(ctx context.Context, jobId int32, adminComment string) error
+	// SignalJob sends a signal to the Slurm job by JobId
+	SignalJob(ctx context.Context, jobId int32, signal string) error
+	// SignalJobBatchStep sends a signal to the batch step of the Slurm job by JobId
+	SignalJobBatchStep(ctx context.Context, jobId int32, signal string) error
 	// TerminateJob cancels the Slurm job by JobId
 	TerminateJob(ctx context.Context, jobId int32) error
 }
@@ -28,20 +41,30 @@ type SlurmControlInterface interface {
 // RealPodControl is the default implementation of SlurmControlInterface.
 type realSlurmControl struct {
 	client.Client
+	httpClient *http.Client
 }
 
 // GetJob implements SlurmControlInterface.
-func (r *realSlurmControl) IsJobRunning(ctx context.Context, pod *corev1.Pod) (bool, error) {
+func (r *realSlurmControl) GetJob(ctx context.Context, pod *corev1.Pod) (*types.V0043JobInfo, error) {
 	job := &types.V0043JobInfo{}
 	jobId := object.ObjectKey(pod.Labels[wellknown.LabelPlaceholderJobId])
 	if jobId == "" {
-		return false, nil
+		return nil, nil
 	}
 	err := r.Get(ctx, jobId, job, &client.GetOptions{RefreshCache: true})
 	if err != nil {
 		if tolerateError(err) {
-			return false, nil
+			return nil, nil
 		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// IsJobRunning implements SlurmControlInterface.
+func (r *realSlurmControl) IsJobRunning(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	job, err := r.GetJob(ctx, pod)
+	if err != nil || job == nil {
 		return false, err
 	}
 	if job.GetStateAsSet().Has(v0043.V0043JobInfoJobStateRUNNING) {
@@ -50,6 +73,81 @@ func (r *realSlurmControl) IsJobRunning(ctx context.Context, pod *corev1.Pod) (b
 	return false, nil
 }
 
+// SetJobComment implements SlurmControlInterface.
+func (r *realSlurmControl) SetJobComment(ctx context.Context, jobId int32, comment string) error {
+	job := &types.V0043JobInfo{
+		V0043JobInfo: v0043.V0043JobInfo{
+			JobId: ptr.To(jobId),
+		},
+	}
+	req := v0043.V0043JobDescMsg{
+		Comment: ptr.To(comment),
+	}
+	if err := r.Update(ctx, job, req); err != nil {
+		if tolerateError(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// SetJobAdminComment implements SlurmControlInterface.
+func (r *realSlurmControl) SetJobAdminComment(ctx context.Context, jobId int32, adminComment string) error {
+	job := &types.V0043JobInfo{
+		V0043JobInfo: v0043.V0043JobInfo{
+			JobId: ptr.To(jobId),
+		},
+	}
+	req := v0043.V0043JobDescMsg{
+		AdminComment: ptr.To(adminComment),
+	}
+	if err := r.Update(ctx, job, req); err != nil {
+		if tolerateError(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// SignalJob implements SlurmControlInterface.
+func (r *realSlurmControl) SignalJob(ctx context.Context, jobId int32, signal string) error {
+	params := &v0043.SlurmV0043DeleteJobParams{
+		Signal: ptr.To(signal),
+	}
+	return r.signalJob(ctx, jobId, params)
+}
+
+// SignalJobBatchStep implements SlurmControlInterface.
+func (r *realSlurmControl) SignalJobBatchStep(ctx context.Context, jobId int32, signal string) error {
+	params := &v0043.SlurmV0043DeleteJobParams{
+		Signal: ptr.To(signal),
+		Flags:  ptr.To(v0043.BATCHJOB),
+	}
+	return r.signalJob(ctx, jobId, params)
+}
+
+func (r *realSlurmControl) signalJob(ctx context.Context, jobId int32, params *v0043.SlurmV0043DeleteJobParams) error {
+	// The generic client cannot signal jobs, so call slurmrestd directly.
+	sc, err := slurmapi.NewSlurmClient(r.GetServer(), r.GetToken(), r.httpClient)
+	if err != nil {
+		return err
+	}
+	res, err := sc.SlurmV0043DeleteJobWithResponse(ctx, strconv.Itoa(int(jobId)), params)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode() != http.StatusOK {
+		err := errors.New(http.StatusText(res.StatusCode()))
+		if tolerateError(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
 // TerminateJob implements SlurmControlInterface.
 func (r *realSlurmControl) TerminateJob(ctx context.Context, jobId int32) error {
 	job := &types.V0043JobInfo{
@@ -68,9 +166,10 @@ func (r *realSlurmControl) TerminateJob(ctx context.Context, jobId int32) error
 
 var _ SlurmControlInterface = &realSlurmControl{}
 
-func NewControl(client client.Client) SlurmControlInterface {
+func NewControl(client client.Client, httpClient *http.Client) SlurmControlInterface {
 	return &realSlurmControl{
-		Client: client,
+		Client:     client,
+		httpClient: httpClient,
 	}
 }
 
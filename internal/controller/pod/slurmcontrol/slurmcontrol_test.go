@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -17,6 +18,7 @@ import (
 	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
 	"github.com/SlinkyProject/slurm-client/pkg/client"
 	"github.com/SlinkyProject/slurm-client/pkg/client/fake"
+	"github.com/SlinkyProject/slurm-client/pkg/object"
 	"github.com/SlinkyProject/slurm-client/pkg/types"
 )
 
@@ -211,6 +213,196 @@ func Test_realSlurmControl_TerminateJob(t *testing.T) {
 	}
 }
 
+func Test_realSlurmControl_SetJobComment(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		client  client.Client
+		jobId   int32
+		wantErr bool
+	}{
+		{
+			name:    "Job not found",
+			client:  fake.NewFakeClient(),
+			jobId:   1,
+			wantErr: false,
+		},
+		{
+			name: "Job updated",
+			client: func() client.Client {
+				obj := &types.V0043JobInfo{
+					V0043JobInfo: v0043.V0043JobInfo{
+						JobId: ptr.To[int32](1),
+					},
+				}
+				return fake.NewClientBuilder().WithObjects(obj).WithUpdateFn(func(_ context.Context, _ object.Object, req any, _ ...client.UpdateOption) error {
+					if ptr.Deref(req.(v0043.V0043JobDescMsg).Comment, "") != "Succeeded: exit code 0" {
+						return errors.New(http.StatusText(http.StatusBadRequest))
+					}
+					return nil
+				}).Build()
+			}(),
+			jobId:   1,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &realSlurmControl{
+				Client: tt.client,
+			}
+			if err := r.SetJobComment(ctx, tt.jobId, "Succeeded: exit code 0"); (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.SetJobComment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_SetJobAdminComment(t *testing.T) {
+	ctx := context.Background()
+	adminComment := `{"pods":["default/foo"],"signaledAt":1700000000}`
+	tests := []struct {
+		name    string
+		client  client.Client
+		jobId   int32
+		wantErr bool
+	}{
+		{
+			name:    "Job not found",
+			client:  fake.NewFakeClient(),
+			jobId:   1,
+			wantErr: false,
+		},
+		{
+			name: "Job updated",
+			client: func() client.Client {
+				obj := &types.V0043JobInfo{
+					V0043JobInfo: v0043.V0043JobInfo{
+						JobId: ptr.To[int32](1),
+					},
+				}
+				return fake.NewClientBuilder().WithObjects(obj).WithUpdateFn(func(_ context.Context, _ object.Object, req any, _ ...client.UpdateOption) error {
+					if ptr.Deref(req.(v0043.V0043JobDescMsg).AdminComment, "") != adminComment {
+						return errors.New(http.StatusText(http.StatusBadRequest))
+					}
+					return nil
+				}).Build()
+			}(),
+			jobId:   1,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &realSlurmControl{
+				Client: tt.client,
+			}
+			if err := r.SetJobAdminComment(ctx, tt.jobId, adminComment); (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.SetJobAdminComment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_SignalJob(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{
+			name:       "Job signaled",
+			statusCode: http.StatusOK,
+			wantErr:    false,
+		},
+		{
+			name:       "Job not found",
+			statusCode: http.StatusNotFound,
+			wantErr:    false,
+		},
+		{
+			name:       "Forbidden",
+			statusCode: http.StatusForbidden,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.Method != http.MethodDelete || req.URL.Path != "/slurm/v0.0.43/job/1" {
+					t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+				}
+				if got := req.URL.Query().Get("signal"); got != "SIGTERM" {
+					t.Errorf("signal = %v, want %v", got, "SIGTERM")
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+			c := fake.NewFakeClient()
+			c.SetServer(server.URL)
+			r := &realSlurmControl{
+				Client: c,
+			}
+			if err := r.SignalJob(context.Background(), 1, "SIGTERM"); (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.SignalJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_SignalJobBatchStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{
+			name:       "Batch step signaled",
+			statusCode: http.StatusOK,
+			wantErr:    false,
+		},
+		{
+			name:       "Job not found",
+			statusCode: http.StatusNotFound,
+			wantErr:    false,
+		},
+		{
+			name:       "Forbidden",
+			statusCode: http.StatusForbidden,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.Method != http.MethodDelete || req.URL.Path != "/slurm/v0.0.43/job/1" {
+					t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+				}
+				if got := req.URL.Query().Get("signal"); got != "SIGUSR1" {
+					t.Errorf("signal = %v, want %v", got, "SIGUSR1")
+				}
+				if got := req.URL.Query().Get("flags"); got != "BATCH_JOB" {
+					t.Errorf("flags = %v, want %v", got, "BATCH_JOB")
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+			c := fake.NewFakeClient()
+			c.SetServer(server.URL)
+			r := &realSlurmControl{
+				Client: c,
+			}
+			if err := r.SignalJobBatchStep(context.Background(), 1, "SIGUSR1"); (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.SignalJobBatchStep() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func Test_tolerateError(t *testing.T) {
 	type args struct {
 		err error
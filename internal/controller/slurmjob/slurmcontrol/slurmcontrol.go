@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmcontrol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/ptr"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	"github.com/SlinkyProject/slurm-client/pkg/client"
+	"github.com/SlinkyProject/slurm-client/pkg/object"
+	"github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjobir"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjwt"
+)
+
+var (
+	ErrorJobUserRequired = errors.New("slurm jobs are only submitted with a user")
+	ErrorJobUserRoot     = errors.New("slurm jobs are not submitted as root")
+)
+
+type SlurmControlInterface interface {
+	// SubmitJob submits the batch script of the SlurmJob. The job description
+	// of the SlurmJob takes precedence over jobInfo, which is translated from
+	// its annotations. The job is never submitted without a user, or as root.
+	SubmitJob(ctx context.Context, slurmJob *v1alpha1.SlurmJob, jobInfo *slurmjobir.SlurmJobIRJobInfo) (int32, error)
+	// GetJob returns the Slurm job by JobId, or nil if it does not exist
+	GetJob(ctx context.Context, jobId int32) (*types.V0043JobInfo, error)
+	// TerminateJob cancels the Slurm job by JobId
+	TerminateJob(ctx context.Context, jobId int32) error
+}
+
+// realSlurmControl is the default implementation of SlurmControlInterface.
+type realSlurmControl struct {
+	client.Client
+	mcsLabel  string
+	partition string
+	// impersonate submits jobs as their user instead of the user of the
+	// token.
+	impersonate bool
+}
+
+// SubmitJob implements SlurmControlInterface.
+func (r *realSlurmControl) SubmitJob(ctx context.Context, slurmJob *v1alpha1.SlurmJob, jobInfo *slurmjobir.SlurmJobIRJobInfo) (int32, error) {
+	// Without a user, the script would run as the user of the token.
+	user := ptr.Deref(jobInfo.UserId, "")
+	if user == "" {
+		return 0, ErrorJobUserRequired
+	}
+	if user == "0" || user == "root" {
+		return 0, ErrorJobUserRoot
+	}
+	job := &types.V0043JobInfo{}
+	jobSubmit := v0043.V0043JobSubmitReq{
+		Job: r.jobDesc(slurmJob, jobInfo),
+	}
+	if r.impersonate {
+		ctx = slurmjwt.WithUser(ctx, user)
+	}
+	if err := r.Create(ctx, job, jobSubmit); err != nil {
+		return 0, err
+	}
+	return ptr.Deref(job.JobId, 0), nil
+}
+
+// jobDesc returns the description of the batch job of the SlurmJob.
+func (r *realSlurmControl) jobDesc(slurmJob *v1alpha1.SlurmJob, jobInfo *slurmjobir.SlurmJobIRJobInfo) *v0043.V0043JobDescMsg {
+	spec := slurmJob.Spec
+	environment := v0043.V0043StringArray{
+		"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+	}
+	environment = append(environment, spec.Environment...)
+	memPerNode := jobInfo.MemPerNode
+	if spec.MemPerNode != nil {
+		memPerNode = ptr.To(slurmjobir.GetMemoryFromQuantity(spec.MemPerNode))
+	}
+	return &v0043.V0043JobDescMsg{
+		Account:                 jobInfo.Account,
+		CpusPerTask:             orDefault(spec.CpusPerTask, jobInfo.CpuPerTask),
+		Constraints:             jobInfo.Constraints,
+		CurrentWorkingDirectory: ptr.To(orString(spec.WorkingDirectory, "/tmp")),
+		Environment:             &environment,
+		ExcludedNodes:           toCsvString(jobInfo.ExcludeNodes),
+		GroupId:                 jobInfo.GroupId,
+		Licenses:                jobInfo.Licenses,
+		MaximumNodes:            orDefault(spec.Nodes, jobInfo.MaxNodes),
+		McsLabel:                ptr.To(r.mcsLabel),
+		MemoryPerNode: func() *v0043.V0043Uint64NoValStruct {
+			if memPerNode != nil {
+				return &v0043.V0043Uint64NoValStruct{
+					Infinite: ptr.To(false),
+					Number:   memPerNode,
+					Set:      ptr.To(true),
+				}
+			} else {
+				return &v0043.V0043Uint64NoValStruct{Set: ptr.To(false)}
+			}
+		}(),
+		MinimumNodes:   orDefault(spec.Nodes, jobInfo.MinNodes),
+		Name:           ptr.To(orString(spec.JobName, ptr.Deref(jobInfo.JobName, slurmJob.Name))),
+		Partition:      ptr.To(ptr.Deref(jobInfo.Partition, r.partition)),
+		Qos:            jobInfo.QOS,
+		RequiredNodes:  toCsvString(jobInfo.NodeList),
+		Reservation:    jobInfo.Reservation,
+		Script:         ptr.To(spec.Script),
+		StandardError:  toOptString(spec.StandardError),
+		StandardOutput: toOptString(spec.StandardOutput),
+		Tasks:          orDefault(spec.Tasks, jobInfo.Tasks),
+		TasksPerNode:   orDefault(spec.TasksPerNode, jobInfo.TasksPerNode),
+		TimeLimit: func() *v0043.V0043Uint32NoValStruct {
+			if timeLimit := orDefault(spec.TimeLimit, jobInfo.TimeLimit); timeLimit != nil {
+				return &v0043.V0043Uint32NoValStruct{
+					Infinite: ptr.To(false),
+					Number:   timeLimit,
+					Set:      ptr.To(true),
+				}
+			} else {
+				return &v0043.V0043Uint32NoValStruct{Set: ptr.To(false)}
+			}
+		}(),
+		TresPerNode: orDefault(toOptString(spec.Gres), jobInfo.Gres),
+		UserId:      jobInfo.UserId,
+		Wckey:       jobInfo.Wckey,
+	}
+}
+
+// GetJob implements SlurmControlInterface.
+func (r *realSlurmControl) GetJob(ctx context.Context, jobId int32) (*types.V0043JobInfo, error) {
+	job := &types.V0043JobInfo{}
+	key := object.ObjectKey(strconv.Itoa(int(jobId)))
+	if err := r.Get(ctx, key, job, &client.GetOptions{RefreshCache: true}); err != nil {
+		if tolerateError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// TerminateJob implements SlurmControlInterface.
+func (r *realSlurmControl) TerminateJob(ctx context.Context, jobId int32) error {
+	job := &types.V0043JobInfo{
+		V0043JobInfo: v0043.V0043JobInfo{
+			JobId: ptr.To(jobId),
+		},
+	}
+	if err := r.Delete(ctx, job); err != nil {
+		if tolerateError(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+var _ SlurmControlInterface = &realSlurmControl{}
+
+func NewControl(client client.Client, mcsLabel, partition string, impersonate bool) SlurmControlInterface {
+	return &realSlurmControl{
+		Client:      client,
+		mcsLabel:    mcsLabel,
+		partition:   partition,
+		impersonate: impersonate,
+	}
+}
+
+// orDefault returns value, or def if value is unset.
+func orDefault[T any](value, def *T) *T {
+	if value != nil {
+		return value
+	}
+	return def
+}
+
+// orString returns str, or def if str is unset.
+func orString(str, def string) string {
+	if str != "" {
+		return str
+	}
+	return def
+}
+
+// toCsvString splits a comma separated list, as used by the Slurm CLI.
+func toCsvString(list *string) *v0043.V0043CsvString {
+	if list == nil || *list == "" {
+		return nil
+	}
+	return ptr.To(strings.Split(*list, ","))
+}
+
+// toOptString returns nil for an unset string.
+func toOptString(str string) *string {
+	if str == "" {
+		return nil
+	}
+	return ptr.To(str)
+}
+
+func tolerateError(err error) bool {
+	if err == nil {
+		return true
+	}
+	errText := err.Error()
+	if errText == http.StatusText(http.StatusNotFound) ||
+		errText == http.StatusText(http.StatusNoContent) {
+		return true
+	}
+	return false
+}
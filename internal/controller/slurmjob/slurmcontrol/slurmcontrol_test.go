@@ -0,0 +1,369 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmcontrol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	"github.com/SlinkyProject/slurm-client/pkg/client"
+	"github.com/SlinkyProject/slurm-client/pkg/client/fake"
+	"github.com/SlinkyProject/slurm-client/pkg/client/interceptor"
+	"github.com/SlinkyProject/slurm-client/pkg/object"
+	"github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjobir"
+)
+
+func newJob(jobId int32, state v0043.V0043JobInfoJobState) *types.V0043JobInfo {
+	return &types.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{
+		JobId:    ptr.To(jobId),
+		JobState: &[]v0043.V0043JobInfoJobState{state},
+	}}
+}
+
+func Test_realSlurmControl_SubmitJob(t *testing.T) {
+	slurmJob := &v1alpha1.SlurmJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "slurm", Name: "hello"},
+		Spec: v1alpha1.SlurmJobSpec{
+			Script:      "#!/bin/sh\nhostname\n",
+			Environment: []string{"FOO=bar"},
+		},
+	}
+	tests := []struct {
+		name      string
+		partition string
+		slurmJob  *v1alpha1.SlurmJob
+		jobInfo   *slurmjobir.SlurmJobIRJobInfo
+		want      v0043.V0043JobDescMsg
+		wantErr   bool
+	}{
+		{
+			name:      "Defaults",
+			partition: "slurm-bridge",
+			slurmJob:  slurmJob,
+			jobInfo: &slurmjobir.SlurmJobIRJobInfo{
+				UserId: ptr.To("1000"),
+			},
+			want: v0043.V0043JobDescMsg{
+				CurrentWorkingDirectory: ptr.To("/tmp"),
+				Environment: &v0043.V0043StringArray{
+					"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+					"FOO=bar",
+				},
+				McsLabel:  ptr.To("kubernetes"),
+				Name:      ptr.To("hello"),
+				Partition: ptr.To("slurm-bridge"),
+				Script:    ptr.To("#!/bin/sh\nhostname\n"),
+				UserId:    ptr.To("1000"),
+			},
+		},
+		{
+			name:      "Annotations",
+			partition: "slurm-bridge",
+			slurmJob:  slurmJob,
+			jobInfo: &slurmjobir.SlurmJobIRJobInfo{
+				Account:   ptr.To("physics"),
+				JobName:   ptr.To("annotated"),
+				MinNodes:  ptr.To[int32](2),
+				MaxNodes:  ptr.To[int32](2),
+				NodeList:  ptr.To("node1,node2"),
+				Partition: ptr.To("gpu"),
+				UserId:    ptr.To("1000"),
+			},
+			want: v0043.V0043JobDescMsg{
+				Account:                 ptr.To("physics"),
+				CurrentWorkingDirectory: ptr.To("/tmp"),
+				Environment: &v0043.V0043StringArray{
+					"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+					"FOO=bar",
+				},
+				MaximumNodes:  ptr.To[int32](2),
+				McsLabel:      ptr.To("kubernetes"),
+				MinimumNodes:  ptr.To[int32](2),
+				Name:          ptr.To("annotated"),
+				Partition:     ptr.To("gpu"),
+				RequiredNodes: &v0043.V0043CsvString{"node1", "node2"},
+				Script:        ptr.To("#!/bin/sh\nhostname\n"),
+				UserId:        ptr.To("1000"),
+			},
+		},
+		{
+			name:      "Spec takes precedence over annotations",
+			partition: "slurm-bridge",
+			slurmJob: func() *v1alpha1.SlurmJob {
+				slurmJob := slurmJob.DeepCopy()
+				slurmJob.Spec.JobName = "spec"
+				slurmJob.Spec.Nodes = ptr.To[int32](4)
+				slurmJob.Spec.Gres = "gres/gpu=8"
+				slurmJob.Spec.WorkingDirectory = "/home/user"
+				return slurmJob
+			}(),
+			jobInfo: &slurmjobir.SlurmJobIRJobInfo{
+				JobName:  ptr.To("annotated"),
+				MinNodes: ptr.To[int32](2),
+				MaxNodes: ptr.To[int32](2),
+				Gres:     ptr.To("gres/gpu=1"),
+				UserId:   ptr.To("1000"),
+			},
+			want: v0043.V0043JobDescMsg{
+				CurrentWorkingDirectory: ptr.To("/home/user"),
+				Environment: &v0043.V0043StringArray{
+					"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+					"FOO=bar",
+				},
+				MaximumNodes: ptr.To[int32](4),
+				McsLabel:     ptr.To("kubernetes"),
+				MinimumNodes: ptr.To[int32](4),
+				Name:         ptr.To("spec"),
+				Partition:    ptr.To("slurm-bridge"),
+				Script:       ptr.To("#!/bin/sh\nhostname\n"),
+				TresPerNode:  ptr.To("gres/gpu=8"),
+				UserId:       ptr.To("1000"),
+			},
+		},
+		{
+			name:      "No user",
+			partition: "slurm-bridge",
+			slurmJob:  slurmJob,
+			jobInfo:   &slurmjobir.SlurmJobIRJobInfo{},
+			wantErr:   true,
+		},
+		{
+			name:      "Root",
+			partition: "slurm-bridge",
+			slurmJob:  slurmJob,
+			jobInfo: &slurmjobir.SlurmJobIRJobInfo{
+				UserId: ptr.To("0"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *v0043.V0043JobDescMsg
+			c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, obj object.Object, req any, opts ...client.CreateOption) error {
+					got = req.(v0043.V0043JobSubmitReq).Job
+					obj.(*types.V0043JobInfo).JobId = ptr.To[int32](1)
+					return nil
+				},
+			}).Build()
+			r := NewControl(c, "kubernetes", tt.partition, false)
+			jobId, err := r.SubmitJob(context.Background(), tt.slurmJob, tt.jobInfo)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.SubmitJob() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if got != nil {
+					t.Errorf("realSlurmControl.SubmitJob() job = %+v, want not submitted", *got)
+				}
+				return
+			}
+			if jobId != 1 {
+				t.Errorf("realSlurmControl.SubmitJob() = %v, want %v", jobId, 1)
+			}
+			// Unset numbers are sent as unset NoVal structs.
+			got.MemoryPerNode = nil
+			got.TimeLimit = nil
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("realSlurmControl.SubmitJob() job = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_SubmitJob_Limits(t *testing.T) {
+	slurmJob := &v1alpha1.SlurmJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "slurm", Name: "hello"},
+		Spec: v1alpha1.SlurmJobSpec{
+			Script:     "#!/bin/sh\nhostname\n",
+			MemPerNode: ptr.To(resource.MustParse("1Gi")),
+		},
+	}
+	jobInfo := &slurmjobir.SlurmJobIRJobInfo{
+		MemPerNode: ptr.To[int64](512),
+		TimeLimit:  ptr.To[int32](60),
+		UserId:     ptr.To("1000"),
+	}
+	var got *v0043.V0043JobDescMsg
+	c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, obj object.Object, req any, opts ...client.CreateOption) error {
+			got = req.(v0043.V0043JobSubmitReq).Job
+			return nil
+		},
+	}).Build()
+	r := NewControl(c, "", "slurm-bridge", false)
+	if _, err := r.SubmitJob(context.Background(), slurmJob, jobInfo); err != nil {
+		t.Fatalf("realSlurmControl.SubmitJob() error = %v", err)
+	}
+	if ptr.Deref(got.MemoryPerNode.Number, 0) != 1024 {
+		t.Errorf("realSlurmControl.SubmitJob() memory = %v, want %v", ptr.Deref(got.MemoryPerNode.Number, 0), 1024)
+	}
+	if ptr.Deref(got.TimeLimit.Number, 0) != 60 {
+		t.Errorf("realSlurmControl.SubmitJob() time limit = %v, want %v", ptr.Deref(got.TimeLimit.Number, 0), 60)
+	}
+}
+
+func Test_realSlurmControl_GetJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  client.Client
+		jobId   int32
+		want    *int32
+		wantErr bool
+	}{
+		{
+			name:   "Job not found",
+			client: fake.NewFakeClient(),
+			jobId:  1,
+			want:   nil,
+		},
+		{
+			name:   "Job found",
+			client: fake.NewClientBuilder().WithObjects(newJob(1, v0043.V0043JobInfoJobStateRUNNING)).Build(),
+			jobId:  1,
+			want:   ptr.To[int32](1),
+		},
+		{
+			name: "Failure",
+			client: fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, key object.ObjectKey, obj object.Object, opts ...client.GetOption) error {
+					return errors.New(http.StatusText(http.StatusInternalServerError))
+				},
+			}).Build(),
+			jobId:   1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &realSlurmControl{
+				Client: tt.client,
+			}
+			got, err := r.GetJob(context.Background(), tt.jobId)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.GetJob() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var gotJobId *int32
+			if got != nil {
+				gotJobId = got.JobId
+			}
+			if !reflect.DeepEqual(gotJobId, tt.want) {
+				t.Errorf("realSlurmControl.GetJob() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_TerminateJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  client.Client
+		jobId   int32
+		wantErr bool
+	}{
+		{
+			name:   "Job not found",
+			client: fake.NewFakeClient(),
+			jobId:  1,
+		},
+		{
+			name:   "Job deleted",
+			client: fake.NewClientBuilder().WithObjects(newJob(1, v0043.V0043JobInfoJobStateRUNNING)).Build(),
+			jobId:  1,
+		},
+		{
+			name: "Failure",
+			client: fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				Delete: func(ctx context.Context, obj object.Object, opts ...client.DeleteOption) error {
+					return errors.New(http.StatusText(http.StatusInternalServerError))
+				},
+			}).Build(),
+			jobId:   1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &realSlurmControl{
+				Client: tt.client,
+			}
+			if err := r.TerminateJob(context.Background(), tt.jobId); (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.TerminateJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_orDefault(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *int32
+		def   *int32
+		want  *int32
+	}{
+		{
+			name:  "Unset",
+			value: nil,
+			def:   ptr.To[int32](1),
+			want:  ptr.To[int32](1),
+		},
+		{
+			name:  "Set",
+			value: ptr.To[int32](2),
+			def:   ptr.To[int32](1),
+			want:  ptr.To[int32](2),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orDefault(tt.value, tt.def); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orDefault() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_toCsvString(t *testing.T) {
+	tests := []struct {
+		name string
+		list *string
+		want *v0043.V0043CsvString
+	}{
+		{
+			name: "Unset",
+			list: nil,
+			want: nil,
+		},
+		{
+			name: "Empty",
+			list: ptr.To(""),
+			want: nil,
+		},
+		{
+			name: "List",
+			list: ptr.To("node1,node2"),
+			want: &v0043.V0043CsvString{"node1", "node2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toCsvString(tt.list); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toCsvString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
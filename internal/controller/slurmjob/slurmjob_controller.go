@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjob
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/slurmjob/slurmcontrol"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/durationstore"
+)
+
+const (
+	// SyncInterval is how often the status of a SlurmJob is synced with its
+	// Slurm job until the job ends.
+	SyncInterval = 30 * time.Second
+)
+
+func init() {
+	flag.IntVar(&maxConcurrentReconciles, "slurmjob-workers", maxConcurrentReconciles, "Max concurrent workers for SlurmJob controller.")
+}
+
+var (
+	maxConcurrentReconciles = 1
+
+	// this is a short cut for any sub-functions to notify the reconcile how long to wait to requeue
+	durationStore = durationstore.NewDurationStore(durationstore.Less)
+)
+
+// SlurmJobReconciler reconciles a SlurmJob object
+type SlurmJobReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	MCSLabel          string
+	Partition         string
+	NamespaceDefaults []config.NamespaceDefaults
+	// Policies restrict the identity and resources of SlurmJobs by the policy
+	// of the default ServiceAccount of their namespace.
+	Policies []config.Policy
+	// Impersonate submits jobs as their user instead of the user of the
+	// token.
+	Impersonate bool
+	SlurmClient slurmclient.Client
+
+	slurmControl  slurmcontrol.SlurmControlInterface
+	eventRecorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=bridge.slinky.slurm.net,resources=slurmjobs,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=bridge.slinky.slurm.net,resources=slurmjobs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=bridge.slinky.slurm.net,resources=slurmjobs/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *SlurmJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, retErr error) {
+	logger := log.FromContext(ctx)
+
+	logger.Info("Started syncing SlurmJob", "request", req)
+
+	startTime := time.Now()
+	defer func() {
+		if retErr == nil {
+			if res.RequeueAfter > 0 {
+				logger.Info("Finished syncing SlurmJob", "duration", time.Since(startTime), "result", res)
+			} else {
+				logger.Info("Finished syncing SlurmJob", "duration", time.Since(startTime))
+			}
+		} else {
+			logger.Info("Finished syncing SlurmJob", "duration", time.Since(startTime), "error", retErr)
+		}
+		// clean the duration store
+		_ = durationStore.Pop(req.String())
+	}()
+
+	retErr = r.Sync(ctx, req)
+	res = reconcile.Result{
+		RequeueAfter: durationStore.Pop(req.String()),
+	}
+	return res, retErr
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SlurmJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.eventRecorder == nil {
+		r.eventRecorder = mgr.GetEventRecorderFor("slurmjob-controller")
+	}
+	r.setupInternal()
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("slurmjob-controller").
+		For(&v1alpha1.SlurmJob{}).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: maxConcurrentReconciles,
+		}).
+		Complete(r)
+}
+
+func (r *SlurmJobReconciler) setupInternal() {
+	if r.eventRecorder == nil {
+		r.eventRecorder = record.NewBroadcaster().NewRecorder(r.Scheme, corev1.EventSource{Component: "slurmjob-controller"})
+	}
+	if r.slurmControl == nil {
+		r.slurmControl = slurmcontrol.NewControl(r.SlurmClient, r.MCSLabel, r.Partition, r.Impersonate)
+	}
+}
+
+func New(client client.Client, scheme *runtime.Scheme, cfg *config.Config, slurmClient slurmclient.Client) *SlurmJobReconciler {
+	r := &SlurmJobReconciler{
+		Client:            client,
+		Scheme:            scheme,
+		MCSLabel:          cfg.MCSLabel,
+		Partition:         cfg.Partition,
+		NamespaceDefaults: cfg.NamespaceDefaults,
+		Policies:          cfg.Policies,
+		Impersonate:       cfg.SlurmJwt.KeySecretRef != nil,
+		SlurmClient:       slurmClient,
+	}
+	r.setupInternal()
+	return r
+}
@@ -0,0 +1,216 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjob
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjobir"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+// StateUnknown is the state of a SlurmJob whose Slurm job is no longer known
+// to Slurm.
+const StateUnknown = "Unknown"
+
+// endedStates are the states of a Slurm job which ended.
+var endedStates = []v0043.V0043JobInfoJobState{
+	v0043.V0043JobInfoJobStateBOOTFAIL,
+	v0043.V0043JobInfoJobStateCANCELLED,
+	v0043.V0043JobInfoJobStateCOMPLETED,
+	v0043.V0043JobInfoJobStateDEADLINE,
+	v0043.V0043JobInfoJobStateFAILED,
+	v0043.V0043JobInfoJobStateNODEFAIL,
+	v0043.V0043JobInfoJobStateOUTOFMEMORY,
+	v0043.V0043JobInfoJobStatePREEMPTED,
+	v0043.V0043JobInfoJobStateTIMEOUT,
+}
+
+func (r *SlurmJobReconciler) Sync(ctx context.Context, req reconcile.Request) error {
+	slurmJob := &v1alpha1.SlurmJob{}
+	if err := r.Get(ctx, req.NamespacedName, slurmJob); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if !slurmJob.DeletionTimestamp.IsZero() {
+		return r.syncDelete(ctx, slurmJob)
+	}
+	if controllerutil.AddFinalizer(slurmJob, wellknown.FinalizerSlurmJob) {
+		if err := r.Update(ctx, slurmJob); err != nil {
+			return err
+		}
+	}
+
+	if slurmJob.Status.JobId == 0 {
+		return r.syncSubmit(ctx, slurmJob)
+	}
+	if isEnded(slurmJob.Status.State) {
+		return nil
+	}
+	if err := r.syncStatus(ctx, slurmJob); err != nil {
+		return err
+	}
+	if !isEnded(slurmJob.Status.State) {
+		durationStore.Push(req.String(), SyncInterval)
+	}
+	return nil
+}
+
+// syncDelete cancels the Slurm job of the SlurmJob, then removes its
+// finalizer.
+func (r *SlurmJobReconciler) syncDelete(ctx context.Context, slurmJob *v1alpha1.SlurmJob) error {
+	logger := log.FromContext(ctx)
+
+	if jobId := slurmJob.Status.JobId; jobId != 0 && !isEnded(slurmJob.Status.State) {
+		logger.Info("Cancelling Slurm job of deleted SlurmJob", "jobId", jobId)
+		if err := r.slurmControl.TerminateJob(ctx, jobId); err != nil {
+			return err
+		}
+	}
+	if controllerutil.RemoveFinalizer(slurmJob, wellknown.FinalizerSlurmJob) {
+		if err := r.Update(ctx, slurmJob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncSubmit submits the batch job of the SlurmJob and records its JobId. The
+// job is cancelled if its JobId cannot be recorded, so it is not submitted
+// twice.
+func (r *SlurmJobReconciler) syncSubmit(ctx context.Context, slurmJob *v1alpha1.SlurmJob) error {
+	logger := log.FromContext(ctx)
+
+	jobInfo, err := slurmjobir.TranslateAnnotationsToJobInfo(r.Client, ctx, slurmJob, r.NamespaceDefaults)
+	if err != nil {
+		// The SlurmJob is synced again once its annotations are fixed.
+		r.eventRecorder.Eventf(slurmJob, corev1.EventTypeWarning, wellknown.ReasonSlurmJobSubmitFailed,
+			"Invalid annotations: %v", err)
+		return nil
+	}
+	// A SlurmJob has no ServiceAccount, it runs as allowed by the policy of the
+	// default ServiceAccount of its namespace.
+	policy := config.PolicyFor(r.Policies, slurmJob.Namespace, "default")
+	if err := slurmjobir.ApplyUserPolicy(jobInfo, policy); err != nil {
+		r.eventRecorder.Eventf(slurmJob, corev1.EventTypeWarning, wellknown.ReasonPolicyViolation,
+			"Not submitting Slurm job: %v", err)
+		return nil
+	}
+	jobId, err := r.slurmControl.SubmitJob(ctx, slurmJob, jobInfo)
+	if err != nil {
+		r.eventRecorder.Eventf(slurmJob, corev1.EventTypeWarning, wellknown.ReasonSlurmJobSubmitFailed,
+			"Failed to submit Slurm job: %v", err)
+		return err
+	}
+	logger.Info("Submitted Slurm job", "jobId", jobId)
+
+	toUpdate := slurmJob.DeepCopy()
+	toUpdate.Status = v1alpha1.SlurmJobStatus{
+		JobId: jobId,
+		State: string(v0043.V0043JobInfoJobStatePENDING),
+	}
+	if err := r.Status().Update(ctx, toUpdate); err != nil {
+		logger.Error(err, "failed to record Slurm job, cancelling it", "jobId", jobId)
+		if err := r.slurmControl.TerminateJob(ctx, jobId); err != nil {
+			logger.Error(err, "failed to cancel Slurm job", "jobId", jobId)
+		}
+		return err
+	}
+	r.eventRecorder.Eventf(slurmJob, corev1.EventTypeNormal, wellknown.ReasonSlurmJobSubmitted,
+		"Submitted Slurm job %d", jobId)
+	durationStore.Push(client.ObjectKeyFromObject(slurmJob).String(), SyncInterval)
+	return nil
+}
+
+// syncStatus mirrors the state of the Slurm job into the status of the
+// SlurmJob.
+func (r *SlurmJobReconciler) syncStatus(ctx context.Context, slurmJob *v1alpha1.SlurmJob) error {
+	job, err := r.slurmControl.GetJob(ctx, slurmJob.Status.JobId)
+	if err != nil {
+		return err
+	}
+	status := *slurmJob.Status.DeepCopy()
+	if job == nil {
+		status.State = StateUnknown
+	} else {
+		status = jobStatus(job)
+	}
+	if apiequality.Semantic.DeepEqual(slurmJob.Status, status) {
+		return nil
+	}
+	slurmJob.Status = status
+	return r.Status().Update(ctx, slurmJob)
+}
+
+// jobStatus returns the status of a SlurmJob from its Slurm job.
+func jobStatus(job *slurmtypes.V0043JobInfo) v1alpha1.SlurmJobStatus {
+	status := v1alpha1.SlurmJobStatus{
+		JobId:     ptr.Deref(job.JobId, 0),
+		Reason:    ptr.Deref(job.StateReason, ""),
+		Nodes:     ptr.Deref(job.Nodes, ""),
+		StartTime: toTime(job.StartTime),
+		EndTime:   toTime(job.EndTime),
+	}
+	if status.Reason == "None" {
+		status.Reason = ""
+	}
+	if job.JobState != nil && len(*job.JobState) > 0 {
+		status.State = string((*job.JobState)[0])
+	}
+	if isEnded(status.State) && !job.GetStateAsSet().Has(v0043.V0043JobInfoJobStateCOMPLETING) {
+		if job.ExitCode != nil && job.ExitCode.ReturnCode != nil && ptr.Deref(job.ExitCode.ReturnCode.Set, false) {
+			status.ExitCode = ptr.To(int32(ptr.Deref(job.ExitCode.ReturnCode.Number, 0))) //nolint:gosec // disable G115
+		}
+	} else {
+		// The job only ended once it completed.
+		status.EndTime = nil
+		if isEnded(status.State) {
+			status.State = string(v0043.V0043JobInfoJobStateCOMPLETING)
+		}
+	}
+	return status
+}
+
+// isEnded returns true if the state of a SlurmJob is final.
+func isEnded(state string) bool {
+	if state == StateUnknown {
+		return true
+	}
+	for _, s := range endedStates {
+		if state == string(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// toTime converts a Slurm timestamp, or returns nil if it is unset.
+func toTime(timestamp *v0043.V0043Uint64NoValStruct) *metav1.Time {
+	if timestamp == nil || !ptr.Deref(timestamp.Set, false) || ptr.Deref(timestamp.Infinite, false) {
+		return nil
+	}
+	if seconds := ptr.Deref(timestamp.Number, 0); seconds > 0 {
+		return ptr.To(metav1.NewTime(time.Unix(seconds, 0)))
+	}
+	return nil
+}
@@ -0,0 +1,417 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjob
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+	slurmclientfake "github.com/SlinkyProject/slurm-client/pkg/client/fake"
+	"github.com/SlinkyProject/slurm-client/pkg/client/interceptor"
+	"github.com/SlinkyProject/slurm-client/pkg/object"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/slurmjob/slurmcontrol"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newJob(jobId int32, state v0043.V0043JobInfoJobState) *slurmtypes.V0043JobInfo {
+	return &slurmtypes.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{
+		JobId:       ptr.To(jobId),
+		JobState:    &[]v0043.V0043JobInfoJobState{state},
+		StateReason: ptr.To("None"),
+	}}
+}
+
+func newSlurmJob(status v1alpha1.SlurmJobStatus) *v1alpha1.SlurmJob {
+	return &v1alpha1.SlurmJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "slurm",
+			Name:       "hello",
+			Finalizers: []string{wellknown.FinalizerSlurmJob},
+		},
+		Spec: v1alpha1.SlurmJobSpec{
+			Script: "#!/bin/sh\nhostname\n",
+		},
+		Status: status,
+	}
+}
+
+func TestSlurmJobReconciler_Sync(t *testing.T) {
+	policies := []config.Policy{
+		{
+			Namespaces: []string{"slurm"},
+			UserId:     config.PolicyRule{Allowed: []string{"1000"}, Default: "1000"},
+			Account:    config.PolicyRule{Allowed: []string{"team-a"}},
+		},
+	}
+	tests := []struct {
+		name           string
+		slurmJob       *v1alpha1.SlurmJob
+		policies       []config.Policy
+		jobs           []object.Object
+		wantStatus     v1alpha1.SlurmJobStatus
+		wantSubmitted  int
+		wantTerminated []int32
+		wantDeleted    bool
+	}{
+		{
+			name:     "Submit job",
+			slurmJob: newSlurmJob(v1alpha1.SlurmJobStatus{}),
+			policies: policies,
+			wantStatus: v1alpha1.SlurmJobStatus{
+				JobId: 101,
+				State: string(v0043.V0043JobInfoJobStatePENDING),
+			},
+			wantSubmitted: 1,
+		},
+		{
+			name: "Invalid annotations",
+			slurmJob: func() *v1alpha1.SlurmJob {
+				slurmJob := newSlurmJob(v1alpha1.SlurmJobStatus{})
+				slurmJob.Annotations = map[string]string{wellknown.AnnotationTimeLimit: "foo"}
+				return slurmJob
+			}(),
+			policies:   policies,
+			wantStatus: v1alpha1.SlurmJobStatus{},
+		},
+		{
+			name: "User not restricted by a policy",
+			slurmJob: func() *v1alpha1.SlurmJob {
+				slurmJob := newSlurmJob(v1alpha1.SlurmJobStatus{})
+				slurmJob.Annotations = map[string]string{wellknown.AnnotationUserId: "1000"}
+				return slurmJob
+			}(),
+			wantStatus: v1alpha1.SlurmJobStatus{},
+		},
+		{
+			name: "User not allowed by the policy",
+			slurmJob: func() *v1alpha1.SlurmJob {
+				slurmJob := newSlurmJob(v1alpha1.SlurmJobStatus{})
+				slurmJob.Annotations = map[string]string{wellknown.AnnotationUserId: "0"}
+				return slurmJob
+			}(),
+			policies:   policies,
+			wantStatus: v1alpha1.SlurmJobStatus{},
+		},
+		{
+			name: "Account not allowed by the policy",
+			slurmJob: func() *v1alpha1.SlurmJob {
+				slurmJob := newSlurmJob(v1alpha1.SlurmJobStatus{})
+				slurmJob.Annotations = map[string]string{wellknown.AnnotationAccount: "team-b"}
+				return slurmJob
+			}(),
+			policies:   policies,
+			wantStatus: v1alpha1.SlurmJobStatus{},
+		},
+		{
+			name: "Running job",
+			slurmJob: newSlurmJob(v1alpha1.SlurmJobStatus{
+				JobId: 1,
+				State: string(v0043.V0043JobInfoJobStatePENDING),
+			}),
+			jobs: []object.Object{func() *slurmtypes.V0043JobInfo {
+				job := newJob(1, v0043.V0043JobInfoJobStateRUNNING)
+				job.Nodes = ptr.To("node[1-2]")
+				return job
+			}()},
+			wantStatus: v1alpha1.SlurmJobStatus{
+				JobId: 1,
+				State: string(v0043.V0043JobInfoJobStateRUNNING),
+				Nodes: "node[1-2]",
+			},
+		},
+		{
+			name: "Completed job",
+			slurmJob: newSlurmJob(v1alpha1.SlurmJobStatus{
+				JobId: 1,
+				State: string(v0043.V0043JobInfoJobStateRUNNING),
+			}),
+			jobs: []object.Object{func() *slurmtypes.V0043JobInfo {
+				job := newJob(1, v0043.V0043JobInfoJobStateFAILED)
+				job.StateReason = ptr.To("NonZeroExitCode")
+				job.ExitCode = &v0043.V0043ProcessExitCodeVerbose{
+					ReturnCode: &v0043.V0043Uint32NoValStruct{Number: ptr.To[int32](3), Set: ptr.To(true)},
+				}
+				return job
+			}()},
+			wantStatus: v1alpha1.SlurmJobStatus{
+				JobId:    1,
+				State:    string(v0043.V0043JobInfoJobStateFAILED),
+				Reason:   "NonZeroExitCode",
+				ExitCode: ptr.To[int32](3),
+			},
+		},
+		{
+			name: "Job no longer known",
+			slurmJob: newSlurmJob(v1alpha1.SlurmJobStatus{
+				JobId: 1,
+				State: string(v0043.V0043JobInfoJobStateRUNNING),
+			}),
+			wantStatus: v1alpha1.SlurmJobStatus{
+				JobId: 1,
+				State: StateUnknown,
+			},
+		},
+		{
+			name: "Ended job",
+			slurmJob: newSlurmJob(v1alpha1.SlurmJobStatus{
+				JobId:    1,
+				State:    string(v0043.V0043JobInfoJobStateCOMPLETED),
+				ExitCode: ptr.To[int32](0),
+			}),
+			wantStatus: v1alpha1.SlurmJobStatus{
+				JobId:    1,
+				State:    string(v0043.V0043JobInfoJobStateCOMPLETED),
+				ExitCode: ptr.To[int32](0),
+			},
+		},
+		{
+			name: "Cancel job of deleted SlurmJob",
+			slurmJob: func() *v1alpha1.SlurmJob {
+				slurmJob := newSlurmJob(v1alpha1.SlurmJobStatus{
+					JobId: 1,
+					State: string(v0043.V0043JobInfoJobStateRUNNING),
+				})
+				slurmJob.DeletionTimestamp = ptr.To(metav1.Now())
+				return slurmJob
+			}(),
+			jobs:           []object.Object{newJob(1, v0043.V0043JobInfoJobStateRUNNING)},
+			wantTerminated: []int32{1},
+			wantDeleted:    true,
+		},
+		{
+			name: "Delete SlurmJob of ended job",
+			slurmJob: func() *v1alpha1.SlurmJob {
+				slurmJob := newSlurmJob(v1alpha1.SlurmJobStatus{
+					JobId: 1,
+					State: string(v0043.V0043JobInfoJobStateCOMPLETED),
+				})
+				slurmJob.DeletionTimestamp = ptr.To(metav1.Now())
+				return slurmJob
+			}(),
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := fake.NewClientBuilder().
+				WithScheme(newScheme(t)).
+				WithObjects(tt.slurmJob).
+				WithStatusSubresource(&v1alpha1.SlurmJob{}).
+				Build()
+			var submitted int
+			var terminated []int32
+			slurmClient := slurmclientfake.NewClientBuilder().
+				WithObjects(tt.jobs...).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Create: func(ctx context.Context, obj object.Object, req any, opts ...slurmclient.CreateOption) error {
+						submitted++
+						obj.(*slurmtypes.V0043JobInfo).JobId = ptr.To(int32(100 + submitted)) //nolint:gosec // disable G115
+						return nil
+					},
+					Delete: func(ctx context.Context, obj object.Object, opts ...slurmclient.DeleteOption) error {
+						terminated = append(terminated, ptr.Deref(obj.(*slurmtypes.V0043JobInfo).JobId, 0))
+						return nil
+					},
+				}).Build()
+			r := &SlurmJobReconciler{
+				Client:        c,
+				Scheme:        c.Scheme(),
+				Policies:      tt.policies,
+				slurmControl:  slurmcontrol.NewControl(slurmClient, "", "slurm-bridge", false),
+				eventRecorder: record.NewFakeRecorder(10),
+			}
+			req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(tt.slurmJob)}
+			if err := r.Sync(ctx, req); err != nil {
+				t.Fatalf("SlurmJobReconciler.Sync() error = %v", err)
+			}
+			_ = durationStore.Pop(req.String())
+
+			if submitted != tt.wantSubmitted {
+				t.Errorf("SlurmJobReconciler.Sync() submitted = %v, want %v", submitted, tt.wantSubmitted)
+			}
+			if !slices.Equal(terminated, tt.wantTerminated) {
+				t.Errorf("SlurmJobReconciler.Sync() terminated = %v, want %v", terminated, tt.wantTerminated)
+			}
+
+			slurmJob := &v1alpha1.SlurmJob{}
+			err := c.Get(ctx, req.NamespacedName, slurmJob)
+			if tt.wantDeleted {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("SlurmJobReconciler.Sync() slurmJob = %v, want deleted", slurmJob)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(slurmJob.Status, tt.wantStatus) {
+				t.Errorf("SlurmJobReconciler.Sync() status = %+v, want %+v", slurmJob.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSlurmJobReconciler_Sync_Finalizer(t *testing.T) {
+	ctx := context.Background()
+	slurmJob := newSlurmJob(v1alpha1.SlurmJobStatus{})
+	slurmJob.Finalizers = nil
+	c := fake.NewClientBuilder().
+		WithScheme(newScheme(t)).
+		WithObjects(slurmJob).
+		WithStatusSubresource(&v1alpha1.SlurmJob{}).
+		Build()
+	r := &SlurmJobReconciler{
+		Client:        c,
+		Scheme:        c.Scheme(),
+		slurmControl:  slurmcontrol.NewControl(slurmclientfake.NewFakeClient(), "", "slurm-bridge", false),
+		eventRecorder: record.NewFakeRecorder(10),
+	}
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(slurmJob)}
+	if err := r.Sync(ctx, req); err != nil {
+		t.Fatalf("SlurmJobReconciler.Sync() error = %v", err)
+	}
+	_ = durationStore.Pop(req.String())
+	if err := c.Get(ctx, req.NamespacedName, slurmJob); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(slurmJob.Finalizers, wellknown.FinalizerSlurmJob) {
+		t.Errorf("SlurmJobReconciler.Sync() finalizers = %v, want %v", slurmJob.Finalizers, wellknown.FinalizerSlurmJob)
+	}
+}
+
+func Test_jobStatus(t *testing.T) {
+	exitCode := &v0043.V0043ProcessExitCodeVerbose{
+		ReturnCode: &v0043.V0043Uint32NoValStruct{Number: ptr.To[int32](0), Set: ptr.To(true)},
+	}
+	startTime := time.Unix(1700000000, 0)
+	endTime := time.Unix(1700000060, 0)
+	tests := []struct {
+		name string
+		job  *slurmtypes.V0043JobInfo
+		want v1alpha1.SlurmJobStatus
+	}{
+		{
+			name: "Pending job",
+			job: func() *slurmtypes.V0043JobInfo {
+				job := newJob(1, v0043.V0043JobInfoJobStatePENDING)
+				job.StateReason = ptr.To("Resources")
+				return job
+			}(),
+			want: v1alpha1.SlurmJobStatus{
+				JobId:  1,
+				State:  string(v0043.V0043JobInfoJobStatePENDING),
+				Reason: "Resources",
+			},
+		},
+		{
+			name: "Completing job",
+			job: func() *slurmtypes.V0043JobInfo {
+				job := newJob(1, v0043.V0043JobInfoJobStateCOMPLETED)
+				job.JobState = &[]v0043.V0043JobInfoJobState{
+					v0043.V0043JobInfoJobStateCOMPLETED,
+					v0043.V0043JobInfoJobStateCOMPLETING,
+				}
+				job.ExitCode = exitCode
+				job.StartTime = &v0043.V0043Uint64NoValStruct{Number: ptr.To(startTime.Unix()), Set: ptr.To(true)}
+				job.EndTime = &v0043.V0043Uint64NoValStruct{Number: ptr.To(endTime.Unix()), Set: ptr.To(true)}
+				return job
+			}(),
+			want: v1alpha1.SlurmJobStatus{
+				JobId:     1,
+				State:     string(v0043.V0043JobInfoJobStateCOMPLETING),
+				StartTime: ptr.To(metav1.NewTime(startTime)),
+			},
+		},
+		{
+			name: "Completed job",
+			job: func() *slurmtypes.V0043JobInfo {
+				job := newJob(1, v0043.V0043JobInfoJobStateCOMPLETED)
+				job.ExitCode = exitCode
+				job.StartTime = &v0043.V0043Uint64NoValStruct{Number: ptr.To(startTime.Unix()), Set: ptr.To(true)}
+				job.EndTime = &v0043.V0043Uint64NoValStruct{Number: ptr.To(endTime.Unix()), Set: ptr.To(true)}
+				return job
+			}(),
+			want: v1alpha1.SlurmJobStatus{
+				JobId:     1,
+				State:     string(v0043.V0043JobInfoJobStateCOMPLETED),
+				ExitCode:  ptr.To[int32](0),
+				StartTime: ptr.To(metav1.NewTime(startTime)),
+				EndTime:   ptr.To(metav1.NewTime(endTime)),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jobStatus(tt.job); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("jobStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_toTime(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp *v0043.V0043Uint64NoValStruct
+		want      *metav1.Time
+	}{
+		{
+			name:      "Nil",
+			timestamp: nil,
+			want:      nil,
+		},
+		{
+			name:      "Unset",
+			timestamp: &v0043.V0043Uint64NoValStruct{Set: ptr.To(false)},
+			want:      nil,
+		},
+		{
+			name:      "Zero",
+			timestamp: &v0043.V0043Uint64NoValStruct{Number: ptr.To[int64](0), Set: ptr.To(true)},
+			want:      nil,
+		},
+		{
+			name:      "Set",
+			timestamp: &v0043.V0043Uint64NoValStruct{Number: ptr.To[int64](1700000000), Set: ptr.To(true)},
+			want:      ptr.To(metav1.NewTime(time.Unix(1700000000, 0))),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toTime(tt.timestamp); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
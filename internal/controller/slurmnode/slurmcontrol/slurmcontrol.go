@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmcontrol
+
+import (
+	"context"
+	"net/http"
+
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+	slurmobject "github.com/SlinkyProject/slurm-client/pkg/object"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+)
+
+type SlurmControlInterface interface {
+	// GetNode returns the Slurm node by name, or nil if it does not exist.
+	GetNode(ctx context.Context, name string) (*slurmtypes.V0043Node, error)
+}
+
+// realSlurmControl is the default implementation of SlurmControlInterface.
+type realSlurmControl struct {
+	slurmclient.Client
+}
+
+// GetNode implements SlurmControlInterface.
+func (r *realSlurmControl) GetNode(ctx context.Context, name string) (*slurmtypes.V0043Node, error) {
+	node := &slurmtypes.V0043Node{}
+	if err := r.Get(ctx, slurmobject.ObjectKey(name), node); err != nil {
+		if tolerateError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return node, nil
+}
+
+var _ SlurmControlInterface = &realSlurmControl{}
+
+func NewControl(client slurmclient.Client) SlurmControlInterface {
+	return &realSlurmControl{
+		Client: client,
+	}
+}
+
+func tolerateError(err error) bool {
+	if err == nil {
+		return true
+	}
+	errText := err.Error()
+	if errText == http.StatusText(http.StatusNotFound) ||
+		errText == http.StatusText(http.StatusNoContent) {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmcontrol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"k8s.io/utils/ptr"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+	"github.com/SlinkyProject/slurm-client/pkg/client/fake"
+	"github.com/SlinkyProject/slurm-client/pkg/client/interceptor"
+	slurmobject "github.com/SlinkyProject/slurm-client/pkg/object"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+)
+
+func Test_realSlurmControl_GetNode(t *testing.T) {
+	node := &slurmtypes.V0043Node{V0043Node: v0043.V0043Node{
+		Name: ptr.To("node1"),
+	}}
+	tests := []struct {
+		name     string
+		client   slurmclient.Client
+		nodeName string
+		want     *string
+		wantErr  bool
+	}{
+		{
+			name:     "Node not found",
+			client:   fake.NewFakeClient(),
+			nodeName: "node1",
+			want:     nil,
+		},
+		{
+			name:     "Node found",
+			client:   fake.NewClientBuilder().WithObjects(node).Build(),
+			nodeName: "node1",
+			want:     ptr.To("node1"),
+		},
+		{
+			name: "Failure",
+			client: fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, key slurmobject.ObjectKey, obj slurmobject.Object, opts ...slurmclient.GetOption) error {
+					return errors.New(http.StatusText(http.StatusInternalServerError))
+				},
+			}).Build(),
+			nodeName: "node1",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewControl(tt.client)
+			got, err := r.GetNode(context.Background(), tt.nodeName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.GetNode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var gotName *string
+			if got != nil {
+				gotName = got.Name
+			}
+			if ptr.Deref(gotName, "") != ptr.Deref(tt.want, "") {
+				t.Errorf("realSlurmControl.GetNode() = %v, want %v", ptr.Deref(gotName, ""), ptr.Deref(tt.want, ""))
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmnode
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	nodeutils "github.com/SlinkyProject/slurm-bridge/internal/controller/node/utils"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/slurmnode/slurmcontrol"
+)
+
+func init() {
+	flag.IntVar(&maxConcurrentReconciles, "slurmnode-workers", maxConcurrentReconciles, "Max concurrent workers for SlurmNode controller.")
+}
+
+var (
+	maxConcurrentReconciles = 1
+)
+
+// SlurmNodeReconciler mirrors Slurm nodes into SlurmNode objects
+type SlurmNodeReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	SlurmClient slurmclient.Client
+	// EventCh receives an event for every change of a Slurm node, from the
+	// node informer shared with the Node controller.
+	EventCh chan event.GenericEvent
+
+	slurmControl slurmcontrol.SlurmControlInterface
+}
+
+// +kubebuilder:rbac:groups=bridge.slinky.slurm.net,resources=slurmnodes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=bridge.slinky.slurm.net,resources=slurmnodes/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *SlurmNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, retErr error) {
+	logger := log.FromContext(ctx)
+
+	logger.V(1).Info("Started syncing SlurmNode", "request", req)
+
+	startTime := time.Now()
+	defer func() {
+		if retErr == nil {
+			logger.V(1).Info("Finished syncing SlurmNode", "duration", time.Since(startTime))
+		} else {
+			logger.Info("Finished syncing SlurmNode", "duration", time.Since(startTime), "error", retErr)
+		}
+	}()
+
+	retErr = r.Sync(ctx, req)
+	return res, retErr
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SlurmNodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.setupInternal()
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named("slurmnode-controller").
+		For(&v1alpha1.SlurmNode{}).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(nodeToSlurmNode))
+	if r.EventCh != nil {
+		b = b.WatchesRawSource(source.Channel(r.EventCh, &handler.EnqueueRequestForObject{}))
+	}
+	return b.WithOptions(controller.Options{
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+	}).
+		Complete(r)
+}
+
+func (r *SlurmNodeReconciler) setupInternal() {
+	if r.slurmControl == nil {
+		r.slurmControl = slurmcontrol.NewControl(r.SlurmClient)
+	}
+}
+
+// nodeToSlurmNode maps a Kubernetes node to the SlurmNode it may be bridged to.
+func nodeToSlurmNode(ctx context.Context, obj client.Object) []reconcile.Request {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: nodeutils.GetSlurmNodeName(node)}},
+	}
+}
+
+func New(client client.Client, scheme *runtime.Scheme, eventCh chan event.GenericEvent, slurmClient slurmclient.Client) *SlurmNodeReconciler {
+	r := &SlurmNodeReconciler{
+		Client:      client,
+		Scheme:      scheme,
+		EventCh:     eventCh,
+		SlurmClient: slurmClient,
+	}
+	r.setupInternal()
+	return r
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmnode
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	nodeutils "github.com/SlinkyProject/slurm-bridge/internal/controller/node/utils"
+)
+
+// Sync creates, updates or deletes the SlurmNode mirroring the Slurm node of the
+// same name.
+func (r *SlurmNodeReconciler) Sync(ctx context.Context, req reconcile.Request) error {
+	logger := log.FromContext(ctx)
+
+	slurmNode := &v1alpha1.SlurmNode{}
+	if err := r.Get(ctx, req.NamespacedName, slurmNode); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		slurmNode = nil
+	}
+
+	node, err := r.slurmControl.GetNode(ctx, req.Name)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		if slurmNode == nil {
+			return nil
+		}
+		logger.Info("Deleting SlurmNode of removed Slurm node", "slurmNode", req.Name)
+		return client.IgnoreNotFound(r.Delete(ctx, slurmNode))
+	}
+
+	if slurmNode == nil {
+		if errs := validation.IsDNS1123Subdomain(req.Name); len(errs) > 0 {
+			logger.V(1).Info("Slurm node name is not a valid object name, skipping",
+				"slurmNode", req.Name, "errors", errs)
+			return nil
+		}
+		slurmNode = &v1alpha1.SlurmNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: req.Name,
+			},
+		}
+		if err := r.Create(ctx, slurmNode); err != nil {
+			return err
+		}
+	}
+
+	kubernetesNode, err := r.kubernetesNodeName(ctx, req.Name)
+	if err != nil {
+		return err
+	}
+	status := nodeStatus(node, kubernetesNode)
+	if apiequality.Semantic.DeepEqual(slurmNode.Status, status) {
+		return nil
+	}
+	slurmNode.Status = status
+	return r.Status().Update(ctx, slurmNode)
+}
+
+// kubernetesNodeName returns the name of the Kubernetes node bridged to the
+// Slurm node, or an empty string.
+func (r *SlurmNodeReconciler) kubernetesNodeName(ctx context.Context, slurmNodeName string) (string, error) {
+	nodeList := &corev1.NodeList{}
+	if err := r.List(ctx, nodeList); err != nil {
+		return "", err
+	}
+	return nodeutils.MakeNodeNameMap(ctx, nodeList)[slurmNodeName], nil
+}
+
+// nodeStatus returns the status of a SlurmNode from its Slurm node.
+func nodeStatus(node *slurmtypes.V0043Node, kubernetesNode string) v1alpha1.SlurmNodeStatus {
+	status := v1alpha1.SlurmNodeStatus{
+		Reason:          ptr.Deref(node.Reason, ""),
+		CPUs:            ptr.Deref(node.Cpus, 0),
+		AllocatedCPUs:   ptr.Deref(node.AllocCpus, 0),
+		Memory:          toQuantity(node.RealMemory),
+		AllocatedMemory: toQuantity(node.AllocMemory),
+		Gres:            ptr.Deref(node.Gres, ""),
+		AllocatedGres:   ptr.Deref(node.GresUsed, ""),
+		KubernetesNode:  kubernetesNode,
+	}
+	if node.State != nil {
+		states := make([]string, len(*node.State))
+		for i, state := range *node.State {
+			states[i] = string(state)
+		}
+		status.State = strings.Join(states, "+")
+	}
+	if node.ActiveFeatures != nil && len(*node.ActiveFeatures) > 0 {
+		status.Features = *node.ActiveFeatures
+	}
+	if node.Partitions != nil && len(*node.Partitions) > 0 {
+		status.Partitions = *node.Partitions
+	}
+	return status
+}
+
+// toQuantity converts memory in megabytes, or returns nil if it is unset.
+func toQuantity(megabytes *int64) *resource.Quantity {
+	if megabytes == nil {
+		return nil
+	}
+	return resource.NewQuantity(*megabytes*1024*1024, resource.BinarySI)
+}
@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmnode
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	slurmclientfake "github.com/SlinkyProject/slurm-client/pkg/client/fake"
+	"github.com/SlinkyProject/slurm-client/pkg/object"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/slurmnode/slurmcontrol"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newNode(name string) *slurmtypes.V0043Node {
+	return &slurmtypes.V0043Node{V0043Node: v0043.V0043Node{
+		Name:           ptr.To(name),
+		State:          &[]v0043.V0043NodeState{v0043.V0043NodeStateMIXED},
+		Cpus:           ptr.To[int32](64),
+		AllocCpus:      ptr.To[int32](16),
+		RealMemory:     ptr.To[int64](262144),
+		AllocMemory:    ptr.To[int64](65536),
+		Gres:           ptr.To("gpu:h100:8"),
+		GresUsed:       ptr.To("gpu:h100:2(IDX:0-1)"),
+		ActiveFeatures: &v0043.V0043CsvString{"h100"},
+		Partitions:     &v0043.V0043CsvString{"gpu"},
+	}}
+}
+
+func newSlurmNode(name string, status v1alpha1.SlurmNodeStatus) *v1alpha1.SlurmNode {
+	return &v1alpha1.SlurmNode{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     status,
+	}
+}
+
+func TestSlurmNodeReconciler_Sync(t *testing.T) {
+	wantStatus := v1alpha1.SlurmNodeStatus{
+		State:           "MIXED",
+		CPUs:            64,
+		AllocatedCPUs:   16,
+		Memory:          ptr.To(resource.MustParse("256Gi")),
+		AllocatedMemory: ptr.To(resource.MustParse("64Gi")),
+		Gres:            "gpu:h100:8",
+		AllocatedGres:   "gpu:h100:2(IDX:0-1)",
+		Features:        []string{"h100"},
+		Partitions:      []string{"gpu"},
+	}
+	tests := []struct {
+		name        string
+		nodeName    string
+		objs        []client.Object
+		nodes       []object.Object
+		wantStatus  v1alpha1.SlurmNodeStatus
+		wantDeleted bool
+	}{
+		{
+			name:       "Create SlurmNode",
+			nodeName:   "node1",
+			nodes:      []object.Object{newNode("node1")},
+			wantStatus: wantStatus,
+		},
+		{
+			name:     "Update SlurmNode",
+			nodeName: "node1",
+			objs: []client.Object{
+				newSlurmNode("node1", v1alpha1.SlurmNodeStatus{State: "IDLE"}),
+			},
+			nodes:      []object.Object{newNode("node1")},
+			wantStatus: wantStatus,
+		},
+		{
+			name:     "Bridged Kubernetes node",
+			nodeName: "node1",
+			objs: []client.Object{
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{
+					Name:   "kube-node1",
+					Labels: map[string]string{wellknown.LabelSlurmNodeName: "node1"},
+				}},
+			},
+			nodes: []object.Object{newNode("node1")},
+			wantStatus: func() v1alpha1.SlurmNodeStatus {
+				status := *wantStatus.DeepCopy()
+				status.KubernetesNode = "kube-node1"
+				return status
+			}(),
+		},
+		{
+			name:     "Delete SlurmNode of removed Slurm node",
+			nodeName: "node1",
+			objs: []client.Object{
+				newSlurmNode("node1", wantStatus),
+			},
+			wantDeleted: true,
+		},
+		{
+			name:        "Skip invalid name",
+			nodeName:    "Node_1",
+			nodes:       []object.Object{newNode("Node_1")},
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := fake.NewClientBuilder().
+				WithScheme(newScheme(t)).
+				WithObjects(tt.objs...).
+				WithStatusSubresource(&v1alpha1.SlurmNode{}).
+				Build()
+			slurmClient := slurmclientfake.NewClientBuilder().
+				WithObjects(tt.nodes...).
+				Build()
+			r := &SlurmNodeReconciler{
+				Client:       c,
+				Scheme:       c.Scheme(),
+				slurmControl: slurmcontrol.NewControl(slurmClient),
+			}
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: tt.nodeName}}
+			if err := r.Sync(ctx, req); err != nil {
+				t.Fatalf("SlurmNodeReconciler.Sync() error = %v", err)
+			}
+
+			slurmNode := &v1alpha1.SlurmNode{}
+			err := c.Get(ctx, req.NamespacedName, slurmNode)
+			if tt.wantDeleted {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("SlurmNodeReconciler.Sync() slurmNode = %v, want deleted", slurmNode)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !apiequality.Semantic.DeepEqual(slurmNode.Status, tt.wantStatus) {
+				t.Errorf("SlurmNodeReconciler.Sync() status = %+v, want %+v", slurmNode.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func Test_nodeStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		node           *slurmtypes.V0043Node
+		kubernetesNode string
+		want           v1alpha1.SlurmNodeStatus
+	}{
+		{
+			name: "Empty",
+			node: &slurmtypes.V0043Node{V0043Node: v0043.V0043Node{Name: ptr.To("node1")}},
+			want: v1alpha1.SlurmNodeStatus{},
+		},
+		{
+			name: "Drained node",
+			node: &slurmtypes.V0043Node{V0043Node: v0043.V0043Node{
+				Name:   ptr.To("node1"),
+				State:  &[]v0043.V0043NodeState{v0043.V0043NodeStateIDLE, v0043.V0043NodeStateDRAIN},
+				Reason: ptr.To("maintenance"),
+			}},
+			kubernetesNode: "node1",
+			want: v1alpha1.SlurmNodeStatus{
+				State:          "IDLE+DRAIN",
+				Reason:         "maintenance",
+				KubernetesNode: "node1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nodeStatus(tt.node, tt.kubernetesNode); !apiequality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("nodeStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_nodeToSlurmNode(t *testing.T) {
+	tests := []struct {
+		name string
+		node *corev1.Node
+		want string
+	}{
+		{
+			name: "Same name",
+			node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+			want: "node1",
+		},
+		{
+			name: "Slurm node name label",
+			node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name:   "kube-node1",
+				Labels: map[string]string{wellknown.LabelSlurmNodeName: "node1"},
+			}},
+			want: "node1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nodeToSlurmNode(context.Background(), tt.node)
+			if len(got) != 1 || got[0].Name != tt.want {
+				t.Errorf("nodeToSlurmNode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmcontrol
+
+import (
+	"context"
+	"net/http"
+
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+	slurmobject "github.com/SlinkyProject/slurm-client/pkg/object"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+)
+
+type SlurmControlInterface interface {
+	// GetPartition returns the Slurm partition by name, or nil if it does not
+	// exist.
+	GetPartition(ctx context.Context, name string) (*slurmtypes.V0043PartitionInfo, error)
+	// GetPartitionNodes returns the Slurm nodes of the partition.
+	GetPartitionNodes(ctx context.Context, name string) ([]slurmtypes.V0043Node, error)
+}
+
+// realSlurmControl is the default implementation of SlurmControlInterface.
+type realSlurmControl struct {
+	slurmclient.Client
+}
+
+// GetPartition implements SlurmControlInterface.
+func (r *realSlurmControl) GetPartition(ctx context.Context, name string) (*slurmtypes.V0043PartitionInfo, error) {
+	partition := &slurmtypes.V0043PartitionInfo{}
+	if err := r.Get(ctx, slurmobject.ObjectKey(name), partition); err != nil {
+		if tolerateError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return partition, nil
+}
+
+// GetPartitionNodes implements SlurmControlInterface.
+func (r *realSlurmControl) GetPartitionNodes(ctx context.Context, name string) ([]slurmtypes.V0043Node, error) {
+	list := &slurmtypes.V0043NodeList{}
+	if err := r.List(ctx, list); err != nil {
+		return nil, err
+	}
+	nodes := []slurmtypes.V0043Node{}
+	for _, node := range list.Items {
+		if node.Partitions == nil {
+			continue
+		}
+		for _, partition := range *node.Partitions {
+			if partition == name {
+				nodes = append(nodes, node)
+				break
+			}
+		}
+	}
+	return nodes, nil
+}
+
+var _ SlurmControlInterface = &realSlurmControl{}
+
+func NewControl(client slurmclient.Client) SlurmControlInterface {
+	return &realSlurmControl{
+		Client: client,
+	}
+}
+
+func tolerateError(err error) bool {
+	if err == nil {
+		return true
+	}
+	errText := err.Error()
+	if errText == http.StatusText(http.StatusNotFound) ||
+		errText == http.StatusText(http.StatusNoContent) {
+		return true
+	}
+	return false
+}
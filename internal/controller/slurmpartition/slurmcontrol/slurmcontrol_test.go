@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmcontrol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"k8s.io/utils/ptr"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+	"github.com/SlinkyProject/slurm-client/pkg/client/fake"
+	"github.com/SlinkyProject/slurm-client/pkg/client/interceptor"
+	slurmobject "github.com/SlinkyProject/slurm-client/pkg/object"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+)
+
+func newNode(name string, partitions ...string) *slurmtypes.V0043Node {
+	return &slurmtypes.V0043Node{V0043Node: v0043.V0043Node{
+		Name:       ptr.To(name),
+		Partitions: ptr.To(v0043.V0043CsvString(partitions)),
+	}}
+}
+
+func Test_realSlurmControl_GetPartition(t *testing.T) {
+	partition := &slurmtypes.V0043PartitionInfo{V0043PartitionInfo: v0043.V0043PartitionInfo{
+		Name: ptr.To("debug"),
+	}}
+	tests := []struct {
+		name          string
+		client        slurmclient.Client
+		partitionName string
+		want          *string
+		wantErr       bool
+	}{
+		{
+			name:          "Partition not found",
+			client:        fake.NewFakeClient(),
+			partitionName: "debug",
+			want:          nil,
+		},
+		{
+			name:          "Partition found",
+			client:        fake.NewClientBuilder().WithObjects(partition).Build(),
+			partitionName: "debug",
+			want:          ptr.To("debug"),
+		},
+		{
+			name: "Failure",
+			client: fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, key slurmobject.ObjectKey, obj slurmobject.Object, opts ...slurmclient.GetOption) error {
+					return errors.New(http.StatusText(http.StatusInternalServerError))
+				},
+			}).Build(),
+			partitionName: "debug",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewControl(tt.client)
+			got, err := r.GetPartition(context.Background(), tt.partitionName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.GetPartition() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var gotName *string
+			if got != nil {
+				gotName = got.Name
+			}
+			if ptr.Deref(gotName, "") != ptr.Deref(tt.want, "") {
+				t.Errorf("realSlurmControl.GetPartition() = %v, want %v", ptr.Deref(gotName, ""), ptr.Deref(tt.want, ""))
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_GetPartitionNodes(t *testing.T) {
+	tests := []struct {
+		name          string
+		client        slurmclient.Client
+		partitionName string
+		want          []string
+		wantErr       bool
+	}{
+		{
+			name:          "No nodes",
+			client:        fake.NewFakeClient(),
+			partitionName: "debug",
+			want:          []string{},
+		},
+		{
+			name: "Nodes of partition",
+			client: fake.NewClientBuilder().WithObjects(
+				newNode("node1", "debug"),
+				newNode("node2", "gpu", "debug"),
+				newNode("node3", "gpu"),
+				&slurmtypes.V0043Node{V0043Node: v0043.V0043Node{Name: ptr.To("node4")}},
+			).Build(),
+			partitionName: "debug",
+			want:          []string{"node1", "node2"},
+		},
+		{
+			name: "Failure",
+			client: fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				List: func(ctx context.Context, list slurmobject.ObjectList, opts ...slurmclient.ListOption) error {
+					return errors.New(http.StatusText(http.StatusInternalServerError))
+				},
+			}).Build(),
+			partitionName: "debug",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewControl(tt.client)
+			got, err := r.GetPartitionNodes(context.Background(), tt.partitionName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.GetPartitionNodes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			names := []string{}
+			for _, node := range got {
+				names = append(names, ptr.Deref(node.Name, ""))
+			}
+			// The fake client lists nodes in no particular order.
+			slices.Sort(names)
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("realSlurmControl.GetPartitionNodes() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
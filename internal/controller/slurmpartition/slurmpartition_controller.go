@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmpartition
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/slurmpartition/slurmcontrol"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/durationstore"
+)
+
+const (
+	// SyncInterval is how often a SlurmPartition is synced, as the allocation
+	// of its nodes changes without the partition itself changing.
+	SyncInterval = 30 * time.Second
+)
+
+func init() {
+	flag.IntVar(&maxConcurrentReconciles, "slurmpartition-workers", maxConcurrentReconciles, "Max concurrent workers for SlurmPartition controller.")
+}
+
+var (
+	maxConcurrentReconciles = 1
+
+	// this is a short cut for any sub-functions to notify the reconcile how long to wait to requeue
+	durationStore = durationstore.NewDurationStore(durationstore.Less)
+)
+
+// SlurmPartitionReconciler mirrors Slurm partitions into SlurmPartition objects
+type SlurmPartitionReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	SlurmClient slurmclient.Client
+	EventCh     chan event.GenericEvent
+
+	slurmControl slurmcontrol.SlurmControlInterface
+}
+
+// +kubebuilder:rbac:groups=bridge.slinky.slurm.net,resources=slurmpartitions,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=bridge.slinky.slurm.net,resources=slurmpartitions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *SlurmPartitionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, retErr error) {
+	logger := log.FromContext(ctx)
+
+	logger.V(1).Info("Started syncing SlurmPartition", "request", req)
+
+	startTime := time.Now()
+	defer func() {
+		if retErr == nil {
+			if res.RequeueAfter > 0 {
+				logger.V(1).Info("Finished syncing SlurmPartition", "duration", time.Since(startTime), "result", res)
+			} else {
+				logger.V(1).Info("Finished syncing SlurmPartition", "duration", time.Since(startTime))
+			}
+		} else {
+			logger.Info("Finished syncing SlurmPartition", "duration", time.Since(startTime), "error", retErr)
+		}
+		// clean the duration store
+		_ = durationStore.Pop(req.String())
+	}()
+
+	retErr = r.Sync(ctx, req)
+	res = reconcile.Result{
+		RequeueAfter: durationStore.Pop(req.String()),
+	}
+	return res, retErr
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SlurmPartitionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.setupInternal()
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named("slurmpartition-controller").
+		For(&v1alpha1.SlurmPartition{})
+	if r.EventCh != nil {
+		b = b.WatchesRawSource(source.Channel(r.EventCh, &handler.EnqueueRequestForObject{}))
+	}
+	return b.WithOptions(controller.Options{
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+	}).
+		Complete(r)
+}
+
+func (r *SlurmPartitionReconciler) setupInternal() {
+	if r.slurmControl == nil {
+		r.slurmControl = slurmcontrol.NewControl(r.SlurmClient)
+	}
+	if r.EventCh != nil {
+		r.setupEventHandler()
+	}
+}
+
+func (r *SlurmPartitionReconciler) setupEventHandler() {
+	logger := log.FromContext(context.Background())
+	informer := r.SlurmClient.GetInformer(slurmtypes.ObjectTypeV0043PartitionInfo)
+	if informer == nil {
+		return
+	}
+	informer.SetEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			partition, ok := obj.(*slurmtypes.V0043PartitionInfo)
+			if !ok {
+				logger.Error(fmt.Errorf("expected V0043PartitionInfo"), "failed to cast object")
+				return
+			}
+			r.EventCh <- partitionEvent(*partition.Name)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			partition, ok := newObj.(*slurmtypes.V0043PartitionInfo)
+			if !ok {
+				logger.Error(fmt.Errorf("expected V0043PartitionInfo"), "failed to cast new object")
+				return
+			}
+			r.EventCh <- partitionEvent(*partition.Name)
+		},
+		DeleteFunc: func(obj interface{}) {
+			partition, ok := obj.(*slurmtypes.V0043PartitionInfo)
+			if !ok {
+				logger.Error(fmt.Errorf("expected V0043PartitionInfo"), "failed to cast object")
+				return
+			}
+			r.EventCh <- partitionEvent(*partition.Name)
+		},
+	})
+}
+
+func partitionEvent(name string) event.GenericEvent {
+	return event.GenericEvent{
+		Object: &v1alpha1.SlurmPartition{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		},
+	}
+}
+
+func New(client client.Client, scheme *runtime.Scheme, eventCh chan event.GenericEvent, slurmClient slurmclient.Client) *SlurmPartitionReconciler {
+	r := &SlurmPartitionReconciler{
+		Client:      client,
+		Scheme:      scheme,
+		EventCh:     eventCh,
+		SlurmClient: slurmClient,
+	}
+	r.setupInternal()
+	return r
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmpartition
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	nodeutils "github.com/SlinkyProject/slurm-bridge/internal/controller/node/utils"
+)
+
+// gresTresPrefix is the prefix of the generic resources of a TRES string.
+const gresTresPrefix = "gres/"
+
+// Sync creates, updates or deletes the SlurmPartition mirroring the Slurm
+// partition of the same name.
+func (r *SlurmPartitionReconciler) Sync(ctx context.Context, req reconcile.Request) error {
+	logger := log.FromContext(ctx)
+
+	slurmPartition := &v1alpha1.SlurmPartition{}
+	if err := r.Get(ctx, req.NamespacedName, slurmPartition); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		slurmPartition = nil
+	}
+
+	partition, err := r.slurmControl.GetPartition(ctx, req.Name)
+	if err != nil {
+		return err
+	}
+	if partition == nil {
+		if slurmPartition == nil {
+			return nil
+		}
+		logger.Info("Deleting SlurmPartition of removed Slurm partition", "slurmPartition", req.Name)
+		return client.IgnoreNotFound(r.Delete(ctx, slurmPartition))
+	}
+
+	if slurmPartition == nil {
+		if errs := validation.IsDNS1123Subdomain(req.Name); len(errs) > 0 {
+			logger.V(1).Info("Slurm partition name is not a valid object name, skipping",
+				"slurmPartition", req.Name, "errors", errs)
+			return nil
+		}
+		slurmPartition = &v1alpha1.SlurmPartition{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: req.Name,
+			},
+		}
+		if err := r.Create(ctx, slurmPartition); err != nil {
+			return err
+		}
+	}
+	durationStore.Push(req.String(), SyncInterval)
+
+	nodes, err := r.slurmControl.GetPartitionNodes(ctx, req.Name)
+	if err != nil {
+		return err
+	}
+	nodeList := &corev1.NodeList{}
+	if err := r.List(ctx, nodeList); err != nil {
+		return err
+	}
+	nodeNameMap := nodeutils.MakeNodeNameMap(ctx, nodeList)
+	status := partitionStatus(partition, nodes, nodeNameMap)
+	if apiequality.Semantic.DeepEqual(slurmPartition.Status, status) {
+		return nil
+	}
+	slurmPartition.Status = status
+	return r.Status().Update(ctx, slurmPartition)
+}
+
+// partitionStatus returns the status of a SlurmPartition from its Slurm
+// partition and the Slurm nodes of the partition. The nodeNameMap maps Slurm
+// node names to Kubernetes node names.
+func partitionStatus(partition *slurmtypes.V0043PartitionInfo, nodes []slurmtypes.V0043Node, nodeNameMap map[string]string) v1alpha1.SlurmPartitionStatus {
+	status := v1alpha1.SlurmPartitionStatus{
+		NodeCount: int32(len(nodes)), //nolint:gosec // disable G115
+	}
+	if partition.Partition != nil && partition.Partition.State != nil {
+		states := make([]string, len(*partition.Partition.State))
+		for i, state := range *partition.Partition.State {
+			states[i] = string(state)
+		}
+		status.State = strings.Join(states, "+")
+	}
+	if partition.Nodes != nil {
+		status.Nodes = ptr.Deref(partition.Nodes.Configured, "")
+	}
+
+	var memory, allocatedMemory int64
+	gres := map[string]int64{}
+	allocatedGres := map[string]int64{}
+	for _, node := range nodes {
+		status.CPUs += ptr.Deref(node.Cpus, 0)
+		status.AllocatedCPUs += ptr.Deref(node.AllocCpus, 0)
+		memory += ptr.Deref(node.RealMemory, 0)
+		allocatedMemory += ptr.Deref(node.AllocMemory, 0)
+		addGresTres(gres, ptr.Deref(node.Tres, ""))
+		addGresTres(allocatedGres, ptr.Deref(node.TresUsed, ""))
+		if name, ok := nodeNameMap[ptr.Deref(node.Name, "")]; ok {
+			status.KubernetesNodes = append(status.KubernetesNodes, name)
+		}
+	}
+	if len(nodes) > 0 {
+		status.Memory = resource.NewQuantity(memory*1024*1024, resource.BinarySI)
+		status.AllocatedMemory = resource.NewQuantity(allocatedMemory*1024*1024, resource.BinarySI)
+	}
+	status.Gres = formatGresTres(gres)
+	status.AllocatedGres = formatGresTres(allocatedGres)
+	slices.Sort(status.KubernetesNodes)
+	return status
+}
+
+// addGresTres adds the generic resources of a TRES string (e.g.
+// "cpu=8,mem=16G,gres/gpu=2") to the totals.
+func addGresTres(totals map[string]int64, tres string) {
+	for _, item := range strings.Split(tres, ",") {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok || !strings.HasPrefix(name, gresTresPrefix) {
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		totals[name] += count
+	}
+}
+
+// formatGresTres returns the totals of generic resources as a TRES string,
+// sorted by name.
+func formatGresTres(totals map[string]int64) string {
+	items := make([]string, 0, len(totals))
+	for name, count := range totals {
+		items = append(items, fmt.Sprintf("%s=%d", name, count))
+	}
+	slices.Sort(items)
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmpartition
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	slurmclientfake "github.com/SlinkyProject/slurm-client/pkg/client/fake"
+	"github.com/SlinkyProject/slurm-client/pkg/object"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/api/v1alpha1"
+	"github.com/SlinkyProject/slurm-bridge/internal/controller/slurmpartition/slurmcontrol"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newPartition(name, nodes string) *slurmtypes.V0043PartitionInfo {
+	partition := &slurmtypes.V0043PartitionInfo{V0043PartitionInfo: v0043.V0043PartitionInfo{
+		Name: ptr.To(name),
+	}}
+	partition.Partition = &struct {
+		State *[]v0043.V0043PartitionInfoPartitionState `json:"state,omitempty"`
+	}{
+		State: &[]v0043.V0043PartitionInfoPartitionState{v0043.V0043PartitionInfoPartitionStateUP},
+	}
+	partition.Nodes = &struct {
+		AllowedAllocation *string `json:"allowed_allocation,omitempty"`
+		Configured        *string `json:"configured,omitempty"`
+		Total             *int32  `json:"total,omitempty"`
+	}{
+		Configured: ptr.To(nodes),
+	}
+	return partition
+}
+
+func newNode(name, tres, tresUsed string, partitions ...string) *slurmtypes.V0043Node {
+	return &slurmtypes.V0043Node{V0043Node: v0043.V0043Node{
+		Name:        ptr.To(name),
+		Cpus:        ptr.To[int32](64),
+		AllocCpus:   ptr.To[int32](16),
+		RealMemory:  ptr.To[int64](262144),
+		AllocMemory: ptr.To[int64](65536),
+		Tres:        ptr.To(tres),
+		TresUsed:    ptr.To(tresUsed),
+		Partitions:  ptr.To(v0043.V0043CsvString(partitions)),
+	}}
+}
+
+func TestSlurmPartitionReconciler_Sync(t *testing.T) {
+	nodes := []object.Object{
+		newNode("node1", "cpu=64,mem=256G,billing=64,gres/gpu=8", "cpu=16,mem=64G,gres/gpu=2", "gpu"),
+		newNode("node2", "cpu=64,mem=256G,billing=64,gres/gpu=8", "cpu=16,mem=64G", "gpu"),
+		newNode("node3", "cpu=64,mem=256G,billing=64", "", "debug"),
+	}
+	wantStatus := v1alpha1.SlurmPartitionStatus{
+		State:           "UP",
+		Nodes:           "node[1-2]",
+		NodeCount:       2,
+		CPUs:            128,
+		AllocatedCPUs:   32,
+		Memory:          ptr.To(resource.MustParse("512Gi")),
+		AllocatedMemory: ptr.To(resource.MustParse("128Gi")),
+		Gres:            "gres/gpu=16",
+		AllocatedGres:   "gres/gpu=2",
+		KubernetesNodes: []string{"node1"},
+	}
+	tests := []struct {
+		name          string
+		partitionName string
+		objs          []client.Object
+		partitions    []object.Object
+		wantStatus    v1alpha1.SlurmPartitionStatus
+		wantDeleted   bool
+	}{
+		{
+			name:          "Create SlurmPartition",
+			partitionName: "gpu",
+			objs: []client.Object{
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+			},
+			partitions: []object.Object{newPartition("gpu", "node[1-2]")},
+			wantStatus: wantStatus,
+		},
+		{
+			name:          "Update SlurmPartition",
+			partitionName: "gpu",
+			objs: []client.Object{
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+				&v1alpha1.SlurmPartition{
+					ObjectMeta: metav1.ObjectMeta{Name: "gpu"},
+					Status:     v1alpha1.SlurmPartitionStatus{State: "DOWN"},
+				},
+			},
+			partitions: []object.Object{newPartition("gpu", "node[1-2]")},
+			wantStatus: wantStatus,
+		},
+		{
+			name:          "Delete SlurmPartition of removed Slurm partition",
+			partitionName: "gpu",
+			objs: []client.Object{
+				&v1alpha1.SlurmPartition{ObjectMeta: metav1.ObjectMeta{Name: "gpu"}},
+			},
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := fake.NewClientBuilder().
+				WithScheme(newScheme(t)).
+				WithObjects(tt.objs...).
+				WithStatusSubresource(&v1alpha1.SlurmPartition{}).
+				Build()
+			slurmClient := slurmclientfake.NewClientBuilder().
+				WithObjects(append(tt.partitions, nodes...)...).
+				Build()
+			r := &SlurmPartitionReconciler{
+				Client:       c,
+				Scheme:       c.Scheme(),
+				slurmControl: slurmcontrol.NewControl(slurmClient),
+			}
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: tt.partitionName}}
+			if err := r.Sync(ctx, req); err != nil {
+				t.Fatalf("SlurmPartitionReconciler.Sync() error = %v", err)
+			}
+			requeueAfter := durationStore.Pop(req.String())
+
+			slurmPartition := &v1alpha1.SlurmPartition{}
+			err := c.Get(ctx, req.NamespacedName, slurmPartition)
+			if tt.wantDeleted {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("SlurmPartitionReconciler.Sync() slurmPartition = %v, want deleted", slurmPartition)
+				}
+				if requeueAfter != 0 {
+					t.Errorf("SlurmPartitionReconciler.Sync() requeueAfter = %v, want %v", requeueAfter, 0)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if requeueAfter != SyncInterval {
+				t.Errorf("SlurmPartitionReconciler.Sync() requeueAfter = %v, want %v", requeueAfter, SyncInterval)
+			}
+			if !apiequality.Semantic.DeepEqual(slurmPartition.Status, tt.wantStatus) {
+				t.Errorf("SlurmPartitionReconciler.Sync() status = %+v, want %+v", slurmPartition.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func Test_addGresTres(t *testing.T) {
+	tests := []struct {
+		name string
+		tres []string
+		want string
+	}{
+		{
+			name: "Empty",
+			tres: []string{""},
+			want: "",
+		},
+		{
+			name: "No generic resources",
+			tres: []string{"cpu=64,mem=256G,billing=64"},
+			want: "",
+		},
+		{
+			name: "Sum generic resources",
+			tres: []string{
+				"cpu=64,mem=256G,gres/gpu=8,gres/gpu:h100=8",
+				"cpu=64,mem=256G,gres/gpu=4,gres/shard=16",
+			},
+			want: "gres/gpu:h100=8,gres/gpu=12,gres/shard=16",
+		},
+		{
+			name: "Invalid count",
+			tres: []string{"gres/gpu=foo,gres/shard=2"},
+			want: "gres/shard=2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals := map[string]int64{}
+			for _, tres := range tt.tres {
+				addGresTres(totals, tres)
+			}
+			if got := formatGresTres(totals); got != tt.want {
+				t.Errorf("formatGresTres() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	jobset "sigs.k8s.io/jobset/api/jobset/v1alpha2"
+	lws "sigs.k8s.io/lws/api/leaderworkerset/v1"
+	sched "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+const namespace = "slurm_bridge"
+
+// Workload kinds used as the value of the kind label.
+const (
+	KindJob      = "Job"
+	KindJobSet   = "JobSet"
+	KindPodGroup = "PodGroup"
+	KindLWS      = "LWS"
+	KindPod      = "Pod"
+	KindUnknown  = "Unknown"
+)
+
+// Placeholder job operations used as the value of the operation label.
+const (
+	OperationSubmit = "submit"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// Node operations used as the value of the operation label.
+const (
+	OperationTaint   = "taint"
+	OperationUntaint = "untaint"
+	OperationDrain   = "drain"
+	OperationUndrain = "undrain"
+)
+
+// Admission decisions used as the value of the decision label.
+const (
+	DecisionAllowed = "allowed"
+	DecisionDenied  = "denied"
+)
+
+var (
+	PlaceholderJobOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "placeholder_job_operations_total",
+			Help:      "Number of placeholder job operations by operation, workload kind and result.",
+		},
+		[]string{"operation", "kind", "result"},
+	)
+	PlaceholderJobOperationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "placeholder_job_operation_duration_seconds",
+			Help:      "Latency of placeholder job operations by operation and workload kind.",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{"operation", "kind"},
+	)
+	PodAllocationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "pod_allocation_duration_seconds",
+			Help:      "Time from pod creation until Slurm allocates nodes to its placeholder job, by workload kind.",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 16),
+		},
+		[]string{"kind"},
+	)
+	PodBindDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "pod_bind_duration_seconds",
+			Help:      "Time from pod creation until the pod is bound to a node, by workload kind.",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 16),
+		},
+		[]string{"kind"},
+	)
+	SlurmAPIRequestErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "slurm_api_request_errors_total",
+			Help:      "Number of failed Slurm REST API requests by HTTP status code and workload kind.",
+		},
+		[]string{"code", "kind"},
+	)
+	PodToJobCacheSize = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "pod_to_job_cache_size",
+			Help:      "Number of pods in the placeholder job cache by workload kind.",
+		},
+		[]string{"kind"},
+	)
+	NodeOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "node_operations_total",
+			Help:      "Number of node taint and Slurm node drain operations by operation and result.",
+		},
+		[]string{"operation", "result"},
+	)
+	AdmissionDecisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "admission_decisions_total",
+			Help:      "Number of pod admission decisions by operation, decision and workload kind.",
+		},
+		[]string{"operation", "decision", "kind"},
+	)
+
+	collectors = []prometheus.Collector{
+		PlaceholderJobOperations,
+		PlaceholderJobOperationDuration,
+		PodAllocationDuration,
+		PodBindDuration,
+		SlurmAPIRequestErrors,
+		PodToJobCacheSize,
+		NodeOperations,
+		AdmissionDecisions,
+	}
+	registerOnce sync.Once
+)
+
+// Register registers the slurm-bridge metrics with the registerer. Only the
+// first call has an effect.
+func Register(registerer prometheus.Registerer) {
+	registerOnce.Do(func() {
+		registerer.MustRegister(collectors...)
+	})
+}
+
+// WorkloadKind returns the kind of workload which the pod belongs to, based on
+// the labels set by the workload controllers.
+func WorkloadKind(pod *corev1.Pod) string {
+	if pod == nil {
+		return KindUnknown
+	}
+	switch {
+	case pod.Labels[sched.PodGroupLabel] != "":
+		return KindPodGroup
+	case pod.Labels[jobset.JobSetNameKey] != "":
+		return KindJobSet
+	case pod.Labels[lws.SetNameLabelKey] != "":
+		return KindLWS
+	case pod.Labels[batchv1.JobNameLabel] != "":
+		return KindJob
+	default:
+		return KindPod
+	}
+}
+
+// Result returns the value of the result label for an error.
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// ObservePlaceholderJobOperation records the result and latency of a
+// placeholder job operation which started at start.
+func ObservePlaceholderJobOperation(operation, kind string, start time.Time, err error) {
+	PlaceholderJobOperations.WithLabelValues(operation, kind, Result(err)).Inc()
+	PlaceholderJobOperationDuration.WithLabelValues(operation, kind).Observe(time.Since(start).Seconds())
+}
+
+type kindKey struct{}
+
+// WithWorkloadKind returns a context which attributes Slurm REST API requests
+// made with it to the workload kind.
+func WithWorkloadKind(ctx context.Context, kind string) context.Context {
+	return context.WithValue(ctx, kindKey{}, kind)
+}
+
+func workloadKindFrom(ctx context.Context) string {
+	if kind, ok := ctx.Value(kindKey{}).(string); ok {
+		return kind
+	}
+	return KindUnknown
+}
+
+// NewTransport returns a RoundTripper for the Slurm REST API which counts
+// failed requests before passing them to next.
+func NewTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &roundTripper{next: next}
+}
+
+type roundTripper struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	kind := workloadKindFrom(req.Context())
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		// The request did not get a response (e.g. connection refused).
+		SlurmAPIRequestErrors.WithLabelValues("0", kind).Inc()
+		return resp, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		SlurmAPIRequestErrors.WithLabelValues(strconv.Itoa(resp.StatusCode), kind).Inc()
+	}
+	return resp, nil
+}
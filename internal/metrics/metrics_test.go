@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	jobset "sigs.k8s.io/jobset/api/jobset/v1alpha2"
+	lws "sigs.k8s.io/lws/api/leaderworkerset/v1"
+	sched "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+func TestWorkloadKind(t *testing.T) {
+	newPod := func(labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: labels}}
+	}
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want string
+	}{
+		{
+			name: "Nil",
+			pod:  nil,
+			want: KindUnknown,
+		},
+		{
+			name: "Pod",
+			pod:  newPod(nil),
+			want: KindPod,
+		},
+		{
+			name: "Job",
+			pod:  newPod(map[string]string{batchv1.JobNameLabel: "foo"}),
+			want: KindJob,
+		},
+		{
+			name: "JobSet",
+			pod: newPod(map[string]string{
+				batchv1.JobNameLabel: "foo-workers-0",
+				jobset.JobSetNameKey: "foo",
+			}),
+			want: KindJobSet,
+		},
+		{
+			name: "PodGroup",
+			pod: newPod(map[string]string{
+				batchv1.JobNameLabel: "foo",
+				sched.PodGroupLabel:  "foo",
+			}),
+			want: KindPodGroup,
+		},
+		{
+			name: "LWS",
+			pod:  newPod(map[string]string{lws.SetNameLabelKey: "foo"}),
+			want: KindLWS,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WorkloadKind(tt.pod); got != tt.want {
+				t.Errorf("WorkloadKind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResult(t *testing.T) {
+	if got := Result(nil); got != "success" {
+		t.Errorf("Result() = %v, want %v", got, "success")
+	}
+	if got := Result(errors.New("error")); got != "error" {
+		t.Errorf("Result() = %v, want %v", got, "error")
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	tests := []struct {
+		name string
+		path string
+		code string
+		want float64
+	}{
+		{
+			name: "Success",
+			path: "/",
+			code: "200",
+			want: 0,
+		},
+		{
+			name: "Error",
+			path: "/error",
+			code: "500",
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithWorkloadKind(context.Background(), KindJob)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := testutil.ToFloat64(SlurmAPIRequestErrors.WithLabelValues(tt.code, KindJob))
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			got := testutil.ToFloat64(SlurmAPIRequestErrors.WithLabelValues(tt.code, KindJob)) - before
+			if got != tt.want {
+				t.Errorf("SlurmAPIRequestErrors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
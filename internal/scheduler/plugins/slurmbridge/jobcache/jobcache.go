@@ -0,0 +1,249 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package jobcache
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/metrics"
+	"github.com/SlinkyProject/slurm-bridge/internal/scheduler/plugins/slurmbridge/slurmcontrol"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/placeholderinfo"
+)
+
+// JobCache indexes placeholder jobs by pod key (namespace/name) and by job ID.
+// It is kept up to date by the Slurm job informer so lookups do not require
+// a round trip to slurmrestd.
+type JobCache struct {
+	mu       sync.RWMutex
+	informer slurmclient.Informer
+	podToJob map[string]int32
+	jobs     map[int32]*entry
+	// hetNodes are the nodes of the components of heterogeneous jobs.
+	hetNodes map[int32]string
+
+	// nodesAllocated is called when nodes are allocated to a placeholder job.
+	nodesAllocated func(job slurmcontrol.PlaceholderJob)
+}
+
+type entry struct {
+	job  slurmcontrol.PlaceholderJob
+	kind string
+	pods []string
+	// components are the job IDs of the components of a heterogeneous job.
+	components []int32
+}
+
+// NewJobCache returns a JobCache fed by the given Slurm job informer. A nil
+// informer results in a cache which never reports having synced. If not nil,
+// nodesAllocated is called whenever the node allocation of a placeholder job
+// changes to a non-empty value.
+func NewJobCache(informer slurmclient.Informer, nodesAllocated func(job slurmcontrol.PlaceholderJob)) *JobCache {
+	c := &JobCache{
+		informer:       informer,
+		podToJob:       make(map[string]int32),
+		jobs:           make(map[int32]*entry),
+		hetNodes:       make(map[int32]string),
+		nodesAllocated: nodesAllocated,
+	}
+	if informer != nil {
+		informer.SetEventHandler(c.EventHandler())
+	}
+	return c
+}
+
+// HasSynced returns true once the informer has listed all Slurm jobs.
+func (c *JobCache) HasSynced() bool {
+	if c == nil || c.informer == nil {
+		return false
+	}
+	synced, err := c.informer.HasSynced()
+	return synced && err == nil
+}
+
+// GetJobForPod returns the placeholder job which includes the pod key.
+func (c *JobCache) GetJobForPod(podKey string) (slurmcontrol.PlaceholderJob, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	jobId, ok := c.podToJob[podKey]
+	if !ok {
+		return slurmcontrol.PlaceholderJob{}, false
+	}
+	return c.jobs[jobId].job, true
+}
+
+// GetJob returns the placeholder job with the given job ID.
+func (c *JobCache) GetJob(jobId int32) (slurmcontrol.PlaceholderJob, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.jobs[jobId]
+	if !ok {
+		return slurmcontrol.PlaceholderJob{}, false
+	}
+	return e.job, true
+}
+
+// GetPods returns the keys of the pods of the placeholder job with the given
+// job ID.
+func (c *JobCache) GetPods(jobId int32) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.jobs[jobId]
+	if !ok {
+		return nil
+	}
+	return slices.Clone(e.pods)
+}
+
+// Update adds or replaces a Slurm job in the cache. Jobs that are not
+// placeholder jobs are ignored.
+func (c *JobCache) Update(job *slurmtypes.V0043JobInfo) {
+	jobId := ptr.Deref(job.JobId, 0)
+	phInfo := placeholderinfo.PlaceholderInfo{}
+	err := placeholderinfo.ParseIntoPlaceholderInfo(job.AdminComment, &phInfo)
+
+	components := slurmcontrol.HetJobComponents(job)
+
+	c.mu.Lock()
+	if components != nil {
+		c.hetNodes[jobId] = ptr.Deref(job.Nodes, "")
+		if leaderId := components[0]; leaderId != jobId {
+			// Only the leader of a heterogeneous job is a placeholder job,
+			// which is updated with the nodes of this component.
+			allocated := c.updateComponentsLocked(leaderId)
+			c.mu.Unlock()
+			if c.nodesAllocated != nil && allocated != nil {
+				c.nodesAllocated(*allocated)
+			}
+			return
+		}
+	}
+	var oldNodes string
+	var oldPods []string
+	if old, ok := c.jobs[jobId]; ok {
+		oldNodes = old.job.Nodes
+		oldPods = old.pods
+	}
+	c.deleteLocked(jobId)
+	if err != nil {
+		c.mu.Unlock()
+		return
+	}
+	placeholderJob := slurmcontrol.PlaceholderJob{
+		JobId: jobId,
+		Nodes: ptr.Deref(job.Nodes, ""),
+	}
+	if components != nil {
+		slurmcontrol.SetComponentNodes(&placeholderJob, components, c.hetNodes)
+	}
+	kind := phInfo.Kind
+	if kind == "" {
+		kind = metrics.KindUnknown
+	}
+	c.jobs[jobId] = &entry{
+		job:        placeholderJob,
+		kind:       kind,
+		pods:       phInfo.Pods,
+		components: components,
+	}
+	for _, pod := range phInfo.Pods {
+		if oldJobId, ok := c.podToJob[pod]; ok {
+			metrics.PodToJobCacheSize.WithLabelValues(c.jobs[oldJobId].kind).Dec()
+		}
+		c.podToJob[pod] = jobId
+		metrics.PodToJobCacheSize.WithLabelValues(kind).Inc()
+	}
+	c.mu.Unlock()
+
+	// Pods may be added to a running job when they are attached to it
+	podsAdded := slices.ContainsFunc(phInfo.Pods, func(pod string) bool {
+		return !slices.Contains(oldPods, pod)
+	})
+	if c.nodesAllocated != nil && placeholderJob.Nodes != "" && (placeholderJob.Nodes != oldNodes || podsAdded) {
+		c.nodesAllocated(placeholderJob)
+	}
+}
+
+// updateComponentsLocked updates the nodes of the heterogeneous placeholder
+// job from the nodes of its components. It returns the placeholder job if its
+// nodes were allocated by the update.
+func (c *JobCache) updateComponentsLocked(leaderId int32) *slurmcontrol.PlaceholderJob {
+	e, ok := c.jobs[leaderId]
+	if !ok || e.components == nil {
+		return nil
+	}
+	oldNodes := e.job.Nodes
+	slurmcontrol.SetComponentNodes(&e.job, e.components, c.hetNodes)
+	if e.job.Nodes == "" || e.job.Nodes == oldNodes {
+		return nil
+	}
+	job := e.job
+	return &job
+}
+
+// Delete removes a Slurm job from the cache.
+func (c *JobCache) Delete(job *slurmtypes.V0043JobInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	jobId := ptr.Deref(job.JobId, 0)
+	delete(c.hetNodes, jobId)
+	c.deleteLocked(jobId)
+}
+
+func (c *JobCache) deleteLocked(jobId int32) {
+	e, ok := c.jobs[jobId]
+	if !ok {
+		return
+	}
+	for _, pod := range e.pods {
+		// Another job may have since claimed the pod
+		if c.podToJob[pod] == jobId {
+			delete(c.podToJob, pod)
+			metrics.PodToJobCacheSize.WithLabelValues(e.kind).Dec()
+		}
+	}
+	delete(c.jobs, jobId)
+}
+
+// EventHandler returns the informer event handler which keeps the cache
+// up to date.
+func (c *JobCache) EventHandler() cache.ResourceEventHandler {
+	logger := klog.Background()
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			job, ok := obj.(*slurmtypes.V0043JobInfo)
+			if !ok {
+				logger.Error(fmt.Errorf("expected V0043JobInfo"), "failed to cast object")
+				return
+			}
+			c.Update(job)
+		},
+		// The slurm-client informer calls OnUpdate with the new object first,
+		// unlike client-go informers.
+		UpdateFunc: func(newObj, oldObj any) {
+			job, ok := newObj.(*slurmtypes.V0043JobInfo)
+			if !ok {
+				logger.Error(fmt.Errorf("expected V0043JobInfo"), "failed to cast new object")
+				return
+			}
+			c.Update(job)
+		},
+		DeleteFunc: func(obj any) {
+			job, ok := obj.(*slurmtypes.V0043JobInfo)
+			if !ok {
+				logger.Error(fmt.Errorf("expected V0043JobInfo"), "failed to cast object")
+				return
+			}
+			c.Delete(job)
+		},
+	}
+}
@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package jobcache
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+
+	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
+	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/scheduler/plugins/slurmbridge/slurmcontrol"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/placeholderinfo"
+)
+
+type fakeInformer struct {
+	slurmclient.Informer
+	handler cache.ResourceEventHandler
+	synced  bool
+}
+
+func (f *fakeInformer) SetEventHandler(handler cache.ResourceEventHandler) {
+	f.handler = handler
+}
+
+func (f *fakeInformer) HasSynced() (bool, error) {
+	return f.synced, nil
+}
+
+func makeJob(jobId int32, nodes string, pods ...string) *slurmtypes.V0043JobInfo {
+	phInfo := placeholderinfo.PlaceholderInfo{Pods: pods}
+	return &slurmtypes.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{
+		JobId:        ptr.To(jobId),
+		Nodes:        ptr.To(nodes),
+		AdminComment: ptr.To(phInfo.ToString()),
+	}}
+}
+
+func TestJobCache_HasSynced(t *testing.T) {
+	tests := []struct {
+		name     string
+		informer slurmclient.Informer
+		want     bool
+	}{
+		{
+			name:     "No informer",
+			informer: nil,
+			want:     false,
+		},
+		{
+			name:     "Not synced",
+			informer: &fakeInformer{synced: false},
+			want:     false,
+		},
+		{
+			name:     "Synced",
+			informer: &fakeInformer{synced: true},
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewJobCache(tt.informer, nil)
+			if got := c.HasSynced(); got != tt.want {
+				t.Errorf("JobCache.HasSynced() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobCache_EventHandler(t *testing.T) {
+	informer := &fakeInformer{synced: true}
+	c := NewJobCache(informer, nil)
+	if informer.handler == nil {
+		t.Fatal("NewJobCache() did not set the informer event handler")
+	}
+
+	informer.handler.OnAdd(makeJob(1, "", "default/pod1", "default/pod2"), false)
+	informer.handler.OnAdd(makeJob(2, "node1", "default/pod3"), false)
+	informer.handler.OnAdd(&slurmtypes.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{
+		JobId:        ptr.To(int32(3)),
+		AdminComment: ptr.To("not a placeholder"),
+	}}, false)
+
+	if got, ok := c.GetJobForPod("default/pod2"); !ok || !reflect.DeepEqual(got, slurmcontrol.PlaceholderJob{JobId: 1}) {
+		t.Errorf("JobCache.GetJobForPod() = %v, %v, want job 1", got, ok)
+	}
+	if _, ok := c.GetJob(3); ok {
+		t.Errorf("JobCache.GetJob() found job which is not a placeholder job")
+	}
+
+	// Nodes are allocated and pod2 is removed from the job. The slurm-client
+	// informer passes the new object first.
+	informer.handler.OnUpdate(makeJob(1, "node[1-2]", "default/pod1"), makeJob(1, "", "default/pod1", "default/pod2"))
+	if got, ok := c.GetJobForPod("default/pod1"); !ok || !reflect.DeepEqual(got, slurmcontrol.PlaceholderJob{JobId: 1, Nodes: "node[1-2]"}) {
+		t.Errorf("JobCache.GetJobForPod() = %v, %v, want job 1 with nodes", got, ok)
+	}
+	if _, ok := c.GetJobForPod("default/pod2"); ok {
+		t.Errorf("JobCache.GetJobForPod() found pod removed from job")
+	}
+	if got := c.GetPods(1); !reflect.DeepEqual(got, []string{"default/pod1"}) {
+		t.Errorf("JobCache.GetPods() = %v, want %v", got, []string{"default/pod1"})
+	}
+
+	informer.handler.OnDelete(makeJob(2, "node1", "default/pod3"))
+	if _, ok := c.GetJobForPod("default/pod3"); ok {
+		t.Errorf("JobCache.GetJobForPod() found pod of deleted job")
+	}
+	if _, ok := c.GetJob(2); ok {
+		t.Errorf("JobCache.GetJob() found deleted job")
+	}
+}
+
+func TestJobCache_Delete(t *testing.T) {
+	c := NewJobCache(nil, nil)
+	c.Update(makeJob(1, "", "default/pod1"))
+	// A newer job claims the same pod
+	c.Update(makeJob(2, "", "default/pod1"))
+	c.Delete(makeJob(1, ""))
+	if got, ok := c.GetJobForPod("default/pod1"); !ok || got.JobId != 2 {
+		t.Errorf("JobCache.GetJobForPod() = %v, %v, want job 2", got, ok)
+	}
+}
+
+func TestJobCache_nodesAllocated(t *testing.T) {
+	allocated := []slurmcontrol.PlaceholderJob{}
+	c := NewJobCache(nil, func(job slurmcontrol.PlaceholderJob) {
+		allocated = append(allocated, job)
+	})
+	c.Update(makeJob(1, "", "default/pod1"))
+	c.Update(makeJob(1, "node1", "default/pod1"))
+	// Unchanged nodes do not trigger the callback again
+	c.Update(makeJob(1, "node1", "default/pod1"))
+	c.Update(makeJob(2, "node2", "default/pod2"))
+	// Pods attached to a running job trigger the callback again
+	c.Update(makeJob(2, "node2", "default/pod2", "default/pod3"))
+	want := []slurmcontrol.PlaceholderJob{
+		{JobId: 1, Nodes: "node1"},
+		{JobId: 2, Nodes: "node2"},
+		{JobId: 2, Nodes: "node2"},
+	}
+	if !reflect.DeepEqual(allocated, want) {
+		t.Errorf("JobCache nodesAllocated = %v, want %v", allocated, want)
+	}
+}
+
+func TestJobCache_heterogeneousJob(t *testing.T) {
+	makeComponent := func(jobId int32, nodes string) *slurmtypes.V0043JobInfo {
+		job := &slurmtypes.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{
+			JobId:       ptr.To(jobId),
+			Nodes:       ptr.To(nodes),
+			HetJobIdSet: ptr.To("1-2"),
+		}}
+		if jobId == 1 {
+			phInfo := placeholderinfo.PlaceholderInfo{Pods: []string{"default/leader", "default/worker"}}
+			job.AdminComment = ptr.To(phInfo.ToString())
+		}
+		return job
+	}
+	allocated := []slurmcontrol.PlaceholderJob{}
+	c := NewJobCache(nil, func(job slurmcontrol.PlaceholderJob) {
+		allocated = append(allocated, job)
+	})
+	c.Update(makeComponent(2, ""))
+	c.Update(makeComponent(1, "node1"))
+	if got, ok := c.GetJobForPod("default/worker"); !ok || !reflect.DeepEqual(got, slurmcontrol.PlaceholderJob{JobId: 1}) {
+		t.Errorf("JobCache.GetJobForPod() = %v, %v, want job 1 without nodes", got, ok)
+	}
+	if _, ok := c.GetJob(2); ok {
+		t.Errorf("JobCache.GetJob() found component which is not a placeholder job")
+	}
+	c.Update(makeComponent(2, "node[2-3]"))
+	want := slurmcontrol.PlaceholderJob{
+		JobId:          1,
+		Nodes:          "node1,node[2-3]",
+		ComponentNodes: []string{"node1", "node[2-3]"},
+	}
+	if got, ok := c.GetJobForPod("default/worker"); !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("JobCache.GetJobForPod() = %v, %v, want %v", got, ok, want)
+	}
+	if !reflect.DeepEqual(allocated, []slurmcontrol.PlaceholderJob{want}) {
+		t.Errorf("JobCache nodesAllocated = %v, want %v", allocated, want)
+	}
+}
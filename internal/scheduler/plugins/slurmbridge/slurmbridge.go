@@ -6,23 +6,34 @@ package slurmbridge
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"os"
 	"reflect"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 	fwk "k8s.io/kube-scheduler/framework"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/noderesources"
+	schedutil "k8s.io/kubernetes/pkg/scheduler/util"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	jobset "sigs.k8s.io/jobset/api/jobset/v1alpha2"
 	lws "sigs.k8s.io/lws/api/leaderworkerset/v1"
@@ -30,11 +41,15 @@ import (
 
 	"github.com/SlinkyProject/slurm-bridge/internal/config"
 	nodecontrollerutils "github.com/SlinkyProject/slurm-bridge/internal/controller/node/utils"
+	"github.com/SlinkyProject/slurm-bridge/internal/metrics"
+	"github.com/SlinkyProject/slurm-bridge/internal/scheduler/plugins/slurmbridge/jobcache"
 	"github.com/SlinkyProject/slurm-bridge/internal/scheduler/plugins/slurmbridge/slurmcontrol"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjobir"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjwt"
 	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
+	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
 
 	"github.com/puttsk/hostlist"
 )
@@ -45,6 +60,9 @@ var (
 	ErrorNoKubeNode        = errors.New("no more placeholder nodes to annotate pods")
 	ErrorPodUpdateFailed   = errors.New("failed to update pod")
 	ErrorNodeConfigInvalid = errors.New("requested node configuration is not available")
+
+	ErrorAttachNodesNotBridged = errors.New("nodes of the Slurm job to attach to are not bridged")
+	ErrorAttachJobNoCapacity   = errors.New("nodes of the Slurm job to attach to have no room for the pods")
 )
 
 func init() {
@@ -59,15 +77,34 @@ func init() {
 type SlurmBridge struct {
 	client.Client
 	schedulerName string
-	slurmControl  slurmcontrol.SlurmControlInterface
-	handle        framework.Handle
+	gresMappings  []config.GresMapping
+	// namespaceDefaults are the default annotations of workloads by namespace.
+	namespaceDefaults []config.NamespaceDefaults
+	// policies restrict the Slurm identity and resources of workloads.
+	policies     []config.Policy
+	slurmControl slurmcontrol.SlurmControlInterface
+	jobCache     *jobcache.JobCache
+	handle       framework.Handle
+	// permitTimeout is how long pods of a placeholder job wait at Permit.
+	permitTimeout time.Duration
+	// revertingJobs are the placeholder jobs being reverted by Unreserve.
+	revertingJobs sync.Map
 }
 
 var _ framework.PreFilterPlugin = &SlurmBridge{}
 var _ framework.FilterPlugin = &SlurmBridge{}
+var _ framework.EnqueueExtensions = &SlurmBridge{}
+var _ framework.ReservePlugin = &SlurmBridge{}
+var _ framework.PermitPlugin = &SlurmBridge{}
+var _ framework.PostBindPlugin = &SlurmBridge{}
 
 const (
 	Name = "SlurmBridge"
+
+	// defaultPermitTimeout is how long pods of a placeholder job wait at
+	// Permit for the rest of the job's pods before the job is reverted, unless
+	// configured otherwise.
+	defaultPermitTimeout = 60 * time.Second
 )
 
 // Name returns name of the plugin. It is used in logs, etc.
@@ -92,33 +129,152 @@ func New(ctx context.Context, obj runtime.Object, handle framework.Handle) (fram
 		}
 	}
 	cfg := config.UnmarshalOrDie(data)
+	if cfg.Shared != "" && !slurmjobir.IsValidShared(cfg.Shared) {
+		err := fmt.Errorf("invalid shared %q: %w", cfg.Shared, slurmjobir.ErrorSharedInvalid)
+		logger.Error(err, "invalid config", "file", config.ConfigFile)
+		return nil, err
+	}
+	// Placeholder jobs are only submitted as the users which policies restrict
+	// pods to, so impersonation without policies would never be used.
+	if cfg.SlurmJwt.KeySecretRef != nil && len(cfg.Policies) == 0 {
+		err := errors.New("slurmJwt.keySecretRef requires policies restricting the users of pods")
+		logger.Error(err, "invalid config", "file", config.ConfigFile)
+		return nil, err
+	}
+	metrics.Register(legacyregistry.Registerer())
 
 	client, err := client.New(handle.KubeConfig(), client.Options{Scheme: scheme})
 	if err != nil {
 		return nil, err
 	}
+	tokenSource, err := slurmjwt.NewTokenSource(cfg.SlurmJwt, client)
+	if err != nil {
+		return nil, err
+	}
+	token, err := tokenSource.Token(ctx)
+	if err != nil {
+		logger.Error(err, "unable to read slurm token")
+		return nil, err
+	}
 	clientConfig := &slurmclient.Config{
-		Server: cfg.SlurmRestApi,
-		AuthToken: func() string {
-			token, _ := os.LookupEnv("SLURM_JWT")
-			return token
-		}(),
+		Server:    cfg.SlurmRestApi,
+		AuthToken: token,
+		HTTPClient: &http.Client{
+			Transport: slurmjwt.NewTransport(tokenSource, metrics.NewTransport(nil)),
+		},
 	}
 	slurmClient, err := slurmclient.NewClient(clientConfig)
 	if err != nil {
 		logger.Error(err, "unable to create slurm client")
 		return nil, err
 	}
-	sc := slurmcontrol.NewControl(slurmClient, cfg.MCSLabel, cfg.Partition)
+	sc := slurmcontrol.NewControl(slurmClient, cfg.MCSLabel, cfg.Partition, cfg.Shared, cfg.SlurmJwt.KeySecretRef != nil)
 	plugin := &SlurmBridge{
-		Client:        client,
-		schedulerName: cfg.SchedulerName,
-		slurmControl:  sc,
-		handle:        handle,
+		Client:            client,
+		schedulerName:     cfg.SchedulerName,
+		gresMappings:      cfg.GresMappings,
+		namespaceDefaults: cfg.NamespaceDefaults,
+		policies:          cfg.Policies,
+		slurmControl:      sc,
+		handle:            handle,
+		permitTimeout:     defaultPermitTimeout,
 	}
+	if cfg.PermitTimeoutSeconds > 0 {
+		plugin.permitTimeout = time.Duration(cfg.PermitTimeoutSeconds) * time.Second
+	}
+	plugin.jobCache = jobcache.NewJobCache(
+		slurmClient.GetInformer(slurmtypes.ObjectTypeV0043JobInfo), plugin.activatePodsForJob)
+	go slurmClient.Start(ctx)
 	return plugin, nil
 }
 
+// EventsToRegister returns the cluster events which may make a pod rejected by
+// this plugin schedulable. Node allocations made by Slurm are not cluster
+// events, instead the pods of the placeholder job are activated by the job
+// cache when the allocation is observed.
+func (sb *SlurmBridge) EventsToRegister(_ context.Context) ([]fwk.ClusterEventWithHint, error) {
+	return []fwk.ClusterEventWithHint{
+		{Event: fwk.ClusterEvent{Resource: fwk.Pod, ActionType: fwk.Add | fwk.Delete}, QueueingHintFn: sb.isSchedulableAfterPodChange},
+		{Event: fwk.ClusterEvent{Resource: fwk.Node, ActionType: fwk.Add | fwk.UpdateNodeLabel | fwk.UpdateNodeTaint}, QueueingHintFn: sb.isSchedulableAfterNodeChange},
+	}, nil
+}
+
+// isSchedulableAfterNodeChange requeues a pod when a node becomes bridged or
+// its labels come to match the node selection of the pod, as a pod which no
+// bridged node matched may now be submitted.
+func (sb *SlurmBridge) isSchedulableAfterNodeChange(logger klog.Logger, pod *corev1.Pod, oldObj, newObj any) (fwk.QueueingHint, error) {
+	oldNode, newNode, err := schedutil.As[*corev1.Node](oldObj, newObj)
+	if err != nil {
+		return fwk.Queue, err
+	}
+	if !slurmjobir.MatchesNodeSelection(pod, newNode) {
+		return fwk.QueueSkip, nil
+	}
+	if oldNode != nil && slurmjobir.MatchesNodeSelection(pod, oldNode) {
+		logger.V(5).Info("node already matched the node selection of pod", "pod", klog.KObj(pod), "node", klog.KObj(newNode))
+		return fwk.QueueSkip, nil
+	}
+	logger.V(5).Info("node change may make pod schedulable", "pod", klog.KObj(pod), "node", klog.KObj(newNode))
+	return fwk.Queue, nil
+}
+
+// isSchedulableAfterPodChange requeues a pod when another pod with the same
+// placeholder job is added or deleted, as this may satisfy or invalidate the
+// number of pods required by the placeholder job. It also requeues a pod when
+// a pod of another placeholder job is deleted from the node assigned to it, as
+// this frees resources on a node shared between placeholder jobs.
+func (sb *SlurmBridge) isSchedulableAfterPodChange(logger klog.Logger, pod *corev1.Pod, oldObj, newObj any) (fwk.QueueingHint, error) {
+	deletedPod, addedPod, err := schedutil.As[*corev1.Pod](oldObj, newObj)
+	if err != nil {
+		return fwk.Queue, err
+	}
+	changedPod := addedPod
+	if changedPod == nil {
+		changedPod = deletedPod
+	}
+	if changedPod.UID == pod.UID {
+		return fwk.QueueSkip, nil
+	}
+	if deletedPod != nil && deletedPod.Spec.NodeName != "" &&
+		deletedPod.Spec.NodeName == pod.Annotations[wellknown.AnnotationPlaceholderNode] {
+		logger.V(5).Info("pod deleted from assigned node may make pod schedulable", "pod", klog.KObj(pod), "deletedPod", klog.KObj(deletedPod))
+		return fwk.Queue, nil
+	}
+	if changedPod.Namespace != pod.Namespace {
+		return fwk.QueueSkip, nil
+	}
+	if changedPod.Labels[wellknown.LabelPlaceholderJobId] != pod.Labels[wellknown.LabelPlaceholderJobId] {
+		logger.V(5).Info("pod change is for a different placeholder job", "pod", klog.KObj(pod), "changedPod", klog.KObj(changedPod))
+		return fwk.QueueSkip, nil
+	}
+	logger.V(5).Info("pod change may make pod schedulable", "pod", klog.KObj(pod), "changedPod", klog.KObj(changedPod))
+	return fwk.Queue, nil
+}
+
+// activatePodsForJob moves the unscheduled pods of a placeholder job to the
+// active queue once Slurm has allocated nodes to the job.
+func (sb *SlurmBridge) activatePodsForJob(job slurmcontrol.PlaceholderJob) {
+	logger := klog.Background()
+	selector := labels.SelectorFromSet(labels.Set{wellknown.LabelPlaceholderJobId: strconv.Itoa(int(job.JobId))})
+	pods, err := sb.handle.SharedInformerFactory().Core().V1().Pods().Lister().List(selector)
+	if err != nil {
+		logger.Error(err, "failed to list pods for placeholder job", "jobId", job.JobId)
+		return
+	}
+	toActivate := make(map[string]*corev1.Pod)
+	for _, p := range pods {
+		if p.Spec.NodeName != "" {
+			continue
+		}
+		toActivate[p.Namespace+"/"+p.Name] = p
+	}
+	if len(toActivate) == 0 {
+		return
+	}
+	logger.V(4).Info("activating pods for placeholder job", "jobId", job.JobId, "nodes", job.Nodes, "pods", len(toActivate))
+	sb.handle.Activate(logger, toActivate)
+}
+
 // PreFilter will check if a Slurm placeholder job has been created for the pod.
 // If a placeholder job is not found, create one and return the pod to the scheduling
 // queue.
@@ -126,6 +282,8 @@ func New(ctx context.Context, obj runtime.Object, handle framework.Handle) (fram
 // Slurm job and update state so the Filter plugin can filter out the assigned node(s)
 func (sb *SlurmBridge) PreFilter(ctx context.Context, state fwk.CycleState, pod *corev1.Pod, nodeInfo []fwk.NodeInfo) (*framework.PreFilterResult, *fwk.Status) {
 	logger := klog.FromContext(ctx)
+	kind := metrics.WorkloadKind(pod)
+	ctx = metrics.WithWorkloadKind(ctx, kind)
 
 	// Populate podToJob representation to validate pod label and annotation
 	if err := sb.validatePodToJob(ctx, pod); err != nil {
@@ -145,11 +303,20 @@ func (sb *SlurmBridge) PreFilter(ctx context.Context, state fwk.CycleState, pod
 	}
 
 	// Construct an intermediate representation of the Slurm placeholder job
-	slurmJobIR, err := slurmjobir.TranslateToSlurmJobIR(sb.Client, ctx, pod)
-	if err != nil {
+	slurmJobIR, err := slurmjobir.TranslateToSlurmJobIR(sb.Client, ctx, pod, sb.gresMappings, sb.namespaceDefaults)
+	if errors.Is(err, slurmjobir.ErrorNoMatchingNodes) {
+		return nil, fwk.NewStatus(fwk.UnschedulableAndUnresolvable, err.Error())
+	} else if err != nil {
 		return nil, fwk.NewStatus(fwk.Error, err.Error())
 	}
 
+	// The policy of the pod is enforced on the translated annotations, which
+	// are those of its root owner and namespace rather than of the pod.
+	slurmJobIR.Policy = config.PolicyFor(sb.policies, pod.Namespace, utils.PodServiceAccountName(pod))
+	if err := slurmjobir.ApplyPolicy(&slurmJobIR.JobInfo, slurmJobIR.Policy); err != nil {
+		return nil, fwk.NewStatus(fwk.UnschedulableAndUnresolvable, err.Error())
+	}
+
 	// Determine if a placeholder job for the pod exists in Slurm
 	placeholderJob, err := sb.slurmControl.GetJob(ctx, pod)
 	if err != nil {
@@ -174,10 +341,28 @@ func (sb *SlurmBridge) PreFilter(ctx context.Context, state fwk.CycleState, pod
 		return nil, fs
 	}
 
+	// Attach the pods to an existing Slurm job instead of submitting a
+	// placeholder job
+	if placeholderJob.JobId == 0 && slurmJobIR.JobInfo.AttachJobId != nil {
+		return nil, sb.attachJob(ctx, pod, slurmJobIR)
+	}
+
+	// Claim an allocation of an AllocationPool instead of submitting a
+	// placeholder job, if one is idle
+	if placeholderJob.JobId == 0 && slurmJobIR.JobInfo.AllocationPool != nil {
+		if status := sb.claimPoolJob(ctx, pod, slurmJobIR); status != nil {
+			return nil, status
+		}
+	}
+
 	// Create a placeholder job in Slurm if needed
 	if placeholderJob.JobId == 0 {
+		start := time.Now()
 		jobid, err := sb.slurmControl.SubmitJob(ctx, pod, slurmJobIR)
-		if err != nil {
+		metrics.ObservePlaceholderJobOperation(metrics.OperationSubmit, kind, start, err)
+		if errors.Is(err, slurmcontrol.ErrorImpersonateUserNotPinned) || errors.Is(err, slurmcontrol.ErrorImpersonateRoot) {
+			return nil, fwk.NewStatus(fwk.UnschedulableAndUnresolvable, err.Error())
+		} else if err != nil {
 			aggErrors := func() utilerrors.Aggregate {
 				var target utilerrors.Aggregate
 				_ = errors.As(err, &target)
@@ -193,7 +378,9 @@ func (sb *SlurmBridge) PreFilter(ctx context.Context, state fwk.CycleState, pod
 			return nil, fwk.NewStatus(fwk.Error, err.Error())
 		}
 		logger.V(5).Info("submitted placeholder to slurm", klog.KObj(pod))
-		err = sb.labelPodsWithJobId(ctx, jobid, slurmJobIR)
+		sb.recordEvent(pod, corev1.EventTypeNormal, wellknown.ReasonSlurmJobSubmitted,
+			"Submitted Slurm job %d for %d pod(s)", jobid, len(slurmJobIR.Pods.Items))
+		_, err = sb.labelPodsWithJobId(ctx, jobid, slurmJobIR)
 		if err != nil {
 			return nil, fwk.NewStatus(fwk.Error, err.Error())
 		}
@@ -204,26 +391,31 @@ func (sb *SlurmBridge) PreFilter(ctx context.Context, state fwk.CycleState, pod
 			logger.V(4).Info("placeholder job exists but no nodes have been allocated")
 			// As the placeholder job is not yet running, update to the job
 			// to include any changes from slurmJobIR.
+			start := time.Now()
 			jobid, err := sb.slurmControl.UpdateJob(ctx, pod, slurmJobIR)
+			metrics.ObservePlaceholderJobOperation(metrics.OperationUpdate, kind, start, err)
 			if err != nil {
 				logger.Error(err, "error updating Slurm job")
 				return nil, fwk.NewStatus(fwk.Pending, err.Error())
 			}
 			// Update the pods with the jobId label in case there
 			// are new pods included in slurmJobIR after the update.
-			err = sb.labelPodsWithJobId(ctx, jobid, slurmJobIR)
+			added, err := sb.labelPodsWithJobId(ctx, jobid, slurmJobIR)
 			if err != nil {
 				logger.Error(err, "error labeling pods after update")
 				return nil, fwk.NewStatus(fwk.Error, err.Error())
 			}
-			return nil, fwk.NewStatus(fwk.Pending, "no nodes assigned")
-		}
-		slurmNodes, _ := hostlist.Expand(placeholderJob.Nodes)
-		kubeNodes, err := sb.slurmToKubeNodes(ctx, slurmNodes)
-		if err != nil {
-			return nil, fwk.NewStatus(fwk.Error, err.Error())
+			if added > 0 {
+				sb.recordEvent(pod, corev1.EventTypeNormal, wellknown.ReasonSlurmJobUpdated,
+					"Updated Slurm job %d with %d additional pod(s)", jobid, added)
+			}
+			message := pendingMessage(placeholderJob)
+			if err := sb.annotatePodsWithPendingReason(ctx, placeholderJob, message, &slurmJobIR.Pods); err != nil {
+				logger.Error(err, "error annotating pods with pending reason")
+			}
+			return nil, fwk.NewStatus(fwk.Pending, message)
 		}
-		err = sb.annotatePodsWithNodes(ctx, placeholderJob.JobId, kubeNodes.Clone(), &slurmJobIR.Pods)
+		kubeNodes, err := sb.assignNodes(ctx, placeholderJob, slurmJobIR)
 		if err != nil {
 			return nil, fwk.NewStatus(fwk.Error, err.Error())
 		}
@@ -232,38 +424,248 @@ func (sb *SlurmBridge) PreFilter(ctx context.Context, state fwk.CycleState, pod
 		if err := sb.Get(ctx, client.ObjectKeyFromObject(pod), pod); err != nil {
 			return nil, fwk.NewStatus(fwk.Error, err.Error())
 		}
-		return &framework.PreFilterResult{NodeNames: kubeNodes}, fwk.NewStatus(fwk.Success, "")
+		return &framework.PreFilterResult{NodeNames: sets.New(kubeNodes...)}, fwk.NewStatus(fwk.Success, "")
 	}
 }
 
-// annotatePodsWithNodes will annotate a jobid to pods and add a finalizer to
-// ensure there is an opportunity to cleanly reconcile state between k8s and Slurm
-func (sb *SlurmBridge) labelPodsWithJobId(ctx context.Context, jobid int32, slurmJobIR *slurmjobir.SlurmJobIR) error {
+// attachJob attaches the pods to the running Slurm job given by their
+// annotation. The nodes of the job must be bridged and have room for the pods,
+// alongside the pods which are already attached to the job. The pods are
+// labeled with the job and assigned its nodes once they are scheduled again.
+func (sb *SlurmBridge) attachJob(ctx context.Context, pod *corev1.Pod, slurmJobIR *slurmjobir.SlurmJobIR) *fwk.Status {
 	logger := klog.FromContext(ctx)
+	job, err := sb.slurmControl.GetAttachJob(ctx, slurmJobIR)
+	switch {
+	case errors.Is(err, slurmcontrol.ErrorAttachJobNotRunning):
+		return fwk.NewStatus(fwk.Unschedulable, err.Error())
+	case errors.Is(err, slurmcontrol.ErrorAttachJobNotFound),
+		errors.Is(err, slurmcontrol.ErrorAttachJobNotOwned),
+		errors.Is(err, slurmcontrol.ErrorAttachJobInUse),
+		errors.Is(err, slurmcontrol.ErrorAttachUserNotPinned):
+		return fwk.NewStatus(fwk.UnschedulableAndUnresolvable, err.Error())
+	case err != nil:
+		return fwk.NewStatus(fwk.Error, err.Error())
+	}
+	slurmNodes, _ := hostlist.Expand(job.Nodes)
+	kubeNodes, err := sb.bridgedKubeNodes(ctx, slurmNodes)
+	if errors.Is(err, ErrorAttachNodesNotBridged) {
+		return fwk.NewStatus(fwk.UnschedulableAndUnresolvable, err.Error())
+	} else if err != nil {
+		return fwk.NewStatus(fwk.Error, err.Error())
+	}
+	attached, err := sb.attachedPods(ctx, job.JobId, slurmJobIR)
+	if err != nil {
+		return fwk.NewStatus(fwk.Error, err.Error())
+	}
+	tasksPerNode := int(max(ptr.Deref(slurmJobIR.JobInfo.TasksPerNode, 1), 1))
+	if len(attached)+len(slurmJobIR.Pods.Items) > len(kubeNodes)*tasksPerNode {
+		return fwk.NewStatus(fwk.Unschedulable, ErrorAttachJobNoCapacity.Error())
+	}
+	attachedKeys := make([]string, 0, len(attached))
+	for _, p := range attached {
+		attachedKeys = append(attachedKeys, p.Namespace+"/"+p.Name)
+	}
+	if err := sb.slurmControl.AttachJob(ctx, pod, slurmJobIR, attachedKeys); err != nil {
+		logger.Error(err, "error attaching pods to Slurm job", "jobId", job.JobId)
+		return fwk.NewStatus(fwk.Error, err.Error())
+	}
+	sb.recordEvent(pod, corev1.EventTypeNormal, wellknown.ReasonSlurmJobAttached,
+		"Attached %d pod(s) to Slurm job %d", len(slurmJobIR.Pods.Items), job.JobId)
+	if _, err := sb.labelPodsWithJobId(ctx, job.JobId, slurmJobIR); err != nil {
+		return fwk.NewStatus(fwk.Error, err.Error())
+	}
+	return fwk.NewStatus(fwk.Pending)
+}
+
+// claimPoolJob claims an idle allocation of the AllocationPool of the pods,
+// which are labeled with its job and assigned its nodes once they are scheduled
+// again. It returns nil if the pool has no idle allocation for the pods, so a
+// placeholder job is submitted instead.
+func (sb *SlurmBridge) claimPoolJob(ctx context.Context, pod *corev1.Pod, slurmJobIR *slurmjobir.SlurmJobIR) *fwk.Status {
+	logger := klog.FromContext(ctx)
+	pool := ptr.Deref(slurmJobIR.JobInfo.AllocationPool, "")
+	jobId, err := sb.slurmControl.ClaimPoolJob(ctx, pod, slurmJobIR)
+	if errors.Is(err, slurmcontrol.ErrorPoolNoIdleAllocation) {
+		logger.V(4).Info("no idle allocation in pool, submitting placeholder job", "pool", pool)
+		return nil
+	} else if err != nil {
+		logger.Error(err, "error claiming allocation of pool", "pool", pool)
+		return fwk.NewStatus(fwk.Error, err.Error())
+	}
+	sb.recordEvent(pod, corev1.EventTypeNormal, wellknown.ReasonSlurmJobAttached,
+		"Attached %d pod(s) to Slurm job %d of allocation pool %s", len(slurmJobIR.Pods.Items), jobId, pool)
+	if _, err := sb.labelPodsWithJobId(ctx, jobId, slurmJobIR); err != nil {
+		return fwk.NewStatus(fwk.Error, err.Error())
+	}
+	return fwk.NewStatus(fwk.Pending)
+}
+
+// bridgedKubeNodes translates the Slurm nodes to Kubernetes nodes, which must
+// all exist and be bridged.
+func (sb *SlurmBridge) bridgedKubeNodes(ctx context.Context, slurmNodes []string) ([]string, error) {
+	kubeNodes, err := sb.slurmToKubeNodes(ctx, slurmNodes)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range kubeNodes {
+		node := &corev1.Node{}
+		if err := sb.Get(ctx, client.ObjectKey{Name: name}, node); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, ErrorAttachNodesNotBridged
+			}
+			return nil, err
+		}
+		if !utils.IsBridgedNode(node) {
+			return nil, ErrorAttachNodesNotBridged
+		}
+	}
+	return kubeNodes, nil
+}
+
+// attachedPods returns the active pods which are attached to the Slurm job,
+// excluding the pods of slurmJobIR.
+func (sb *SlurmBridge) attachedPods(ctx context.Context, jobId int32, slurmJobIR *slurmjobir.SlurmJobIR) ([]corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	if err := sb.List(ctx, pods, client.MatchingLabels{wellknown.LabelPlaceholderJobId: strconv.Itoa(int(jobId))}); err != nil {
+		return nil, err
+	}
+	attached := []corev1.Pod{}
+	for _, p := range pods.Items {
+		if p.DeletionTimestamp != nil || p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if slices.ContainsFunc(slurmJobIR.Pods.Items, func(ir corev1.Pod) bool {
+			return ir.Namespace == p.Namespace && ir.Name == p.Name
+		}) {
+			continue
+		}
+		attached = append(attached, p)
+	}
+	return attached, nil
+}
+
+// assignNodes annotates the pods of the placeholder job with the nodes
+// allocated to it and returns the Kubernetes nodes of the allocation. The pods
+// of each component of a heterogeneous job are assigned to the nodes of their
+// own component.
+func (sb *SlurmBridge) assignNodes(ctx context.Context, placeholderJob *slurmcontrol.PlaceholderJob, slurmJobIR *slurmjobir.SlurmJobIR) ([]string, error) {
+	if slurmJobIR.JobInfo.AttachJobId != nil {
+		return sb.assignAttachedNodes(ctx, placeholderJob, slurmJobIR)
+	}
+	if len(slurmJobIR.Components) == 0 || len(slurmJobIR.Components) != len(placeholderJob.ComponentNodes) {
+		slurmNodes, _ := hostlist.Expand(placeholderJob.Nodes)
+		kubeNodes, err := sb.slurmToKubeNodes(ctx, slurmNodes)
+		if err != nil {
+			return nil, err
+		}
+		tasksPerNode := ptr.Deref(slurmJobIR.JobInfo.TasksPerNode, 1)
+		if err := sb.annotatePodsWithNodes(ctx, placeholderJob.JobId, kubeNodes, tasksPerNode, &slurmJobIR.Pods); err != nil {
+			return nil, err
+		}
+		return kubeNodes, nil
+	}
+	allKubeNodes := []string{}
+	for i, c := range slurmJobIR.Components {
+		slurmNodes, _ := hostlist.Expand(placeholderJob.ComponentNodes[i])
+		kubeNodes, err := sb.slurmToKubeNodes(ctx, slurmNodes)
+		if err != nil {
+			return nil, err
+		}
+		tasksPerNode := ptr.Deref(c.JobInfo.TasksPerNode, 1)
+		if err := sb.annotatePodsWithNodes(ctx, placeholderJob.JobId, kubeNodes, tasksPerNode, &c.Pods); err != nil {
+			return nil, err
+		}
+		allKubeNodes = append(allKubeNodes, kubeNodes...)
+	}
+	return allKubeNodes, nil
+}
+
+// assignAttachedNodes annotates the pods attached to a Slurm job with the nodes
+// of the job which are not used by the other pods attached to it, and returns
+// those nodes.
+func (sb *SlurmBridge) assignAttachedNodes(ctx context.Context, placeholderJob *slurmcontrol.PlaceholderJob, slurmJobIR *slurmjobir.SlurmJobIR) ([]string, error) {
+	slurmNodes, _ := hostlist.Expand(placeholderJob.Nodes)
+	kubeNodes, err := sb.slurmToKubeNodes(ctx, slurmNodes)
+	if err != nil {
+		return nil, err
+	}
+	attached, err := sb.attachedPods(ctx, placeholderJob.JobId, slurmJobIR)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]int32)
+	for _, p := range attached {
+		used[p.Annotations[wellknown.AnnotationPlaceholderNode]]++
+	}
+	// Each free task slot is assigned to one pod
+	tasksPerNode := max(ptr.Deref(slurmJobIR.JobInfo.TasksPerNode, 1), 1)
+	slots := []string{}
+	for _, node := range kubeNodes {
+		for range tasksPerNode - used[node] {
+			slots = append(slots, node)
+		}
+	}
+	if err := sb.annotatePodsWithNodes(ctx, placeholderJob.JobId, slots, 1, &slurmJobIR.Pods); err != nil {
+		return nil, err
+	}
+	return slices.Compact(slots), nil
+}
+
+// labelPodsWithJobId will label pods with a jobid and add a finalizer to
+// ensure there is an opportunity to cleanly reconcile state between k8s and
+// Slurm. It returns the number of pods which were not yet labeled.
+func (sb *SlurmBridge) labelPodsWithJobId(ctx context.Context, jobid int32, slurmJobIR *slurmjobir.SlurmJobIR) (int, error) {
+	logger := klog.FromContext(ctx)
+	labeled := 0
 	for _, p := range slurmJobIR.Pods.Items {
 		if p.Labels == nil {
 			p.Labels = make(map[string]string)
 		}
-		if p.Labels[wellknown.LabelPlaceholderJobId] == string(jobid) {
+		if p.Labels[wellknown.LabelPlaceholderJobId] == strconv.Itoa(int(jobid)) {
 			continue
 		}
 		toUpdate := p.DeepCopy()
 		toUpdate.Labels[wellknown.LabelPlaceholderJobId] = strconv.Itoa(int(jobid))
-		toUpdate.Finalizers = append(toUpdate.Finalizers, wellknown.FinalizerScheduler)
+		if !slices.Contains(toUpdate.Finalizers, wellknown.FinalizerScheduler) {
+			toUpdate.Finalizers = append(toUpdate.Finalizers, wellknown.FinalizerScheduler)
+		}
 		if err := sb.Patch(ctx, toUpdate, client.StrategicMergeFrom(&p)); err != nil {
 			logger.Error(err, "failed to update pod with slurm job id")
-			return ErrorPodUpdateFailed
+			return labeled, ErrorPodUpdateFailed
 		}
+		labeled++
 	}
-	return nil
+	return labeled, nil
 }
 
-// annotatePodsWithNodes will annotate a node assignment to pods
-func (sb *SlurmBridge) annotatePodsWithNodes(ctx context.Context, jobid int32, kubeNodes sets.Set[string], pods *corev1.PodList) error {
+// annotatePodsWithNodes will annotate a node assignment to pods, assigning
+// each node to at most tasksPerNode pods. Pods are assigned in rank order onto
+// kubeNodes, which is in the order of the Slurm allocation, so the leader pod
+// is placed on the first node. Pods which are already bound keep their node and
+// take up one of its assignments, as a node shared with other placeholder jobs
+// may admit a pod of the job before the others.
+func (sb *SlurmBridge) annotatePodsWithNodes(ctx context.Context, jobid int32, kubeNodes []string, tasksPerNode int32, pods *corev1.PodList) error {
 	logger := klog.FromContext(ctx)
-	for _, p := range pods.Items {
+	sortedPods := slices.Clone(pods.Items)
+	slurmjobir.SortPodsByRank(sortedPods)
+	bound := make(map[string]int32)
+	for _, p := range sortedPods {
+		if p.Spec.NodeName != "" && jobid == slurmjobir.ParseSlurmJobId(p.Labels[wellknown.LabelPlaceholderJobId]) {
+			bound[p.Spec.NodeName]++
+		}
+	}
+	slots := make([]string, 0, len(kubeNodes))
+	for _, node := range kubeNodes {
+		free := max(tasksPerNode, 1)
+		used := min(free, bound[node])
+		bound[node] -= used
+		for range free - used {
+			slots = append(slots, node)
+		}
+	}
+	for _, p := range sortedPods {
 		// Return if there are no nodes left
-		if kubeNodes.Len() == 0 {
+		if len(slots) == 0 {
 			logger.V(5).Info("no nodes left to annotate")
 			break
 		}
@@ -274,28 +676,99 @@ func (sb *SlurmBridge) annotatePodsWithNodes(ctx context.Context, jobid int32, k
 			logger.V(5).Info("pod JobID does not match placeholder JobID")
 			continue
 		}
+		if p.Spec.NodeName != "" {
+			continue
+		}
 		if p.Annotations == nil {
 			p.Annotations = make(map[string]string)
 		}
-		node, ok := kubeNodes.PopAny()
-		if !ok {
-			logger.V(4).Info("could not get a node to assign")
-			return ErrorNoKubeNode
-		}
+		var node string
+		node, slots = slots[0], slots[1:]
 		toUpdate := p.DeepCopy()
 		toUpdate.Annotations[wellknown.AnnotationPlaceholderNode] = node
+		delete(toUpdate.Annotations, wellknown.AnnotationPendingReason)
+		delete(toUpdate.Annotations, wellknown.AnnotationExpectedStartTime)
 		toleration := utils.NewTolerationNodeBridged(sb.schedulerName)
 		toUpdate.Spec.Tolerations = utils.MergeTolerations(toUpdate.Spec.Tolerations, *toleration)
 		if err := sb.Patch(ctx, toUpdate, client.StrategicMergeFrom(&p)); err != nil {
 			logger.Error(err, "failed to update pod with slurm job id")
 			return ErrorPodUpdateFailed
 		}
+		if p.Annotations[wellknown.AnnotationPlaceholderNode] == "" {
+			metrics.PodAllocationDuration.WithLabelValues(metrics.WorkloadKind(&p)).
+				Observe(time.Since(p.CreationTimestamp.Time).Seconds())
+		}
+	}
+	return nil
+}
+
+// pendingMessage describes why the placeholder job has no nodes assigned.
+func pendingMessage(job *slurmcontrol.PlaceholderJob) string {
+	message := "no nodes assigned"
+	if job.StateReason != "" && job.StateReason != "None" {
+		message += fmt.Sprintf(", Slurm job %d is pending: %s", job.JobId, job.StateReason)
+	}
+	if !job.StartTime.IsZero() {
+		message += ", expected to start at " + job.StartTime.UTC().Format(time.RFC3339)
+	}
+	return message
+}
+
+// annotatePodsWithPendingReason will annotate the pods of a pending placeholder
+// job with why it is pending and when it is expected to start. An Event is
+// recorded for each pod when the pending reason changes.
+func (sb *SlurmBridge) annotatePodsWithPendingReason(ctx context.Context, job *slurmcontrol.PlaceholderJob, message string, pods *corev1.PodList) error {
+	logger := klog.FromContext(ctx)
+	var startTime string
+	if !job.StartTime.IsZero() {
+		startTime = job.StartTime.UTC().Format(time.RFC3339)
+	}
+	for _, p := range pods.Items {
+		podJobID := slurmjobir.ParseSlurmJobId(p.Labels[wellknown.LabelPlaceholderJobId])
+		if job.JobId != podJobID {
+			continue
+		}
+		reasonChanged := p.Annotations[wellknown.AnnotationPendingReason] != job.StateReason
+		if !reasonChanged && p.Annotations[wellknown.AnnotationExpectedStartTime] == startTime {
+			continue
+		}
+		toUpdate := p.DeepCopy()
+		if toUpdate.Annotations == nil {
+			toUpdate.Annotations = make(map[string]string)
+		}
+		setOrDelete(toUpdate.Annotations, wellknown.AnnotationPendingReason, job.StateReason)
+		setOrDelete(toUpdate.Annotations, wellknown.AnnotationExpectedStartTime, startTime)
+		if err := sb.Patch(ctx, toUpdate, client.StrategicMergeFrom(&p)); err != nil {
+			logger.Error(err, "failed to update pod with pending reason")
+			return ErrorPodUpdateFailed
+		}
+		if reasonChanged && job.StateReason != "" {
+			sb.recordEvent(&p, corev1.EventTypeNormal, wellknown.ReasonSlurmJobPending, "%s", message)
+		}
 	}
 	return nil
 }
 
-// slurmToKubeNodes will translate slurm node names to kubernetes node names
-func (sb *SlurmBridge) slurmToKubeNodes(ctx context.Context, slurmNodes []string) (sets.Set[string], error) {
+// recordEvent records an Event for the object, if the scheduler has an event
+// recorder.
+func (sb *SlurmBridge) recordEvent(obj runtime.Object, eventtype, reason, note string, args ...any) {
+	if sb.handle == nil || sb.handle.EventRecorder() == nil {
+		return
+	}
+	sb.handle.EventRecorder().Eventf(obj, nil, eventtype, reason, "Scheduling", note, args...)
+}
+
+func setOrDelete(m map[string]string, key, value string) {
+	if value == "" {
+		delete(m, key)
+	} else {
+		m[key] = value
+	}
+}
+
+// slurmToKubeNodes will translate slurm node names to kubernetes node names,
+// preserving the order of slurmNodes.
+func (sb *SlurmBridge) slurmToKubeNodes(ctx context.Context, slurmNodes []string) ([]string, error) {
 	logger := klog.FromContext(ctx)
 
 	nodeList := &corev1.NodeList{}
@@ -304,7 +777,7 @@ func (sb *SlurmBridge) slurmToKubeNodes(ctx context.Context, slurmNodes []string
 		return nil, err
 	}
 
-	kubeNodes := make(sets.Set[string])
+	kubeNodes := make([]string, 0, len(slurmNodes))
 	nodeNameMap := nodecontrollerutils.MakeNodeNameMap(ctx, nodeList)
 	for _, slurmNode := range slurmNodes {
 		kubeNode, ok := nodeNameMap[slurmNode]
@@ -312,7 +785,9 @@ func (sb *SlurmBridge) slurmToKubeNodes(ctx context.Context, slurmNodes []string
 			// Assume the kubeNode == slurmNode Name
 			kubeNode = slurmNode
 		}
-		kubeNodes.Insert(kubeNode)
+		if !slices.Contains(kubeNodes, kubeNode) {
+			kubeNodes = append(kubeNodes, kubeNode)
+		}
 	}
 
 	return kubeNodes, nil
@@ -323,16 +798,31 @@ func (sb *SlurmBridge) slurmToKubeNodes(ctx context.Context, slurmNodes []string
 func (sb *SlurmBridge) deletePlaceholderJob(ctx context.Context, pod *corev1.Pod) error {
 	logger := klog.FromContext(ctx)
 	// Construct an intermediate representation of the Slurm placeholder job
-	slurmJobIR, err := slurmjobir.TranslateToSlurmJobIR(sb.Client, ctx, pod)
+	slurmJobIR, err := slurmjobir.TranslateToSlurmJobIR(sb.Client, ctx, pod, sb.gresMappings, sb.namespaceDefaults)
 	if err != nil {
 		logger.Error(err, "failed to translate to slurmjobir")
 		return err
 	}
 	jobId := pod.Labels[wellknown.LabelPlaceholderJobId]
-	if err := sb.slurmControl.DeleteJob(ctx, pod); err != nil {
-		logger.Error(err, "failed to delete Slurm job for pod", "jobId", jobId, "pod", klog.KObj(pod))
+	placeholderJob, err := sb.slurmControl.GetJob(ctx, pod)
+	if err != nil {
+		logger.Error(err, "failed to get Slurm job for pod", "jobId", jobId, "pod", klog.KObj(pod))
 		return err
 	}
+	// A Slurm job which the pods are attached to is not owned by the pods, so
+	// the pods are only detached from it.
+	if !placeholderJob.Attached {
+		kind := metrics.WorkloadKind(pod)
+		start := time.Now()
+		err = sb.slurmControl.DeleteJob(metrics.WithWorkloadKind(ctx, kind), pod)
+		metrics.ObservePlaceholderJobOperation(metrics.OperationDelete, kind, start, err)
+		if err != nil {
+			logger.Error(err, "failed to delete Slurm job for pod", "jobId", jobId, "pod", klog.KObj(pod))
+			return err
+		}
+		sb.recordEvent(pod, corev1.EventTypeNormal, wellknown.ReasonSlurmJobDeleted,
+			"Deleted Slurm job %s to schedule the pod again", jobId)
+	}
 	for _, p := range slurmJobIR.Pods.Items {
 		toUpdate := p.DeepCopy()
 		if toUpdate.Labels[wellknown.LabelPlaceholderJobId] == "" {
@@ -359,10 +849,75 @@ func (sb *SlurmBridge) PreFilterExtensions() framework.PreFilterExtensions {
 func (sb *SlurmBridge) Filter(ctx context.Context, state fwk.CycleState, pod *corev1.Pod, nodeInfo fwk.NodeInfo) *fwk.Status {
 	logger := klog.FromContext(ctx)
 	logger.V(5).Info("filter func", "pod", klog.KObj(pod), "node", nodeInfo.Node().Name)
-	if pod.Annotations[wellknown.AnnotationPlaceholderNode] == nodeInfo.Node().Name {
+	if pod.Annotations[wellknown.AnnotationPlaceholderNode] != nodeInfo.Node().Name {
+		return fwk.NewStatus(fwk.Unschedulable, "node does not match annotation")
+	}
+	// When Slurm shares a node between placeholder jobs, verify that the
+	// kubelet can still admit this pod alongside pods of the other jobs.
+	if !hasPodsFromOtherJobs(pod, nodeInfo) {
 		return fwk.NewStatus(fwk.Success, "")
 	}
-	return fwk.NewStatus(fwk.Unschedulable, "node does not match annotation")
+	insufficientResources := noderesources.Fits(pod, nodeInfo, noderesources.ResourceRequestsOptions{})
+	if len(insufficientResources) != 0 {
+		reasons := make([]string, 0, len(insufficientResources))
+		for _, r := range insufficientResources {
+			reasons = append(reasons, r.Reason)
+		}
+		return fwk.NewStatus(fwk.Unschedulable, reasons...)
+	}
+	return fwk.NewStatus(fwk.Success, "")
+}
+
+// hasPodsFromOtherJobs returns true if the node is running pods which belong
+// to a different placeholder job than the given pod.
+func hasPodsFromOtherJobs(pod *corev1.Pod, nodeInfo fwk.NodeInfo) bool {
+	jobId := pod.Labels[wellknown.LabelPlaceholderJobId]
+	for _, podInfo := range nodeInfo.GetPods() {
+		p := podInfo.GetPod()
+		if p.UID == pod.UID {
+			continue
+		}
+		if otherJobId, ok := p.Labels[wellknown.LabelPlaceholderJobId]; ok && otherJobId != jobId {
+			return true
+		}
+	}
+	return false
+}
+
+// getJobForPod returns the placeholder job which includes the pod key. Lookups
+// are served by the job cache, falling back to listing all Slurm jobs until the
+// cache has synced.
+func (sb *SlurmBridge) getJobForPod(ctx context.Context, podKey string) (slurmcontrol.PlaceholderJob, bool, error) {
+	if sb.jobCache.HasSynced() {
+		job, ok := sb.jobCache.GetJobForPod(podKey)
+		return job, ok, nil
+	}
+	podToJob, err := sb.slurmControl.GetJobsForPods(ctx)
+	if err != nil {
+		return slurmcontrol.PlaceholderJob{}, false, err
+	}
+	job, ok := (*podToJob)[podKey]
+	return job, ok, nil
+}
+
+// getPodsForJob returns the keys of the pods of the placeholder job. Lookups
+// are served by the job cache, falling back to listing all Slurm jobs until the
+// cache has synced.
+func (sb *SlurmBridge) getPodsForJob(ctx context.Context, jobId int32) ([]string, error) {
+	if sb.jobCache.HasSynced() {
+		return sb.jobCache.GetPods(jobId), nil
+	}
+	podToJob, err := sb.slurmControl.GetJobsForPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	podKeys := []string{}
+	for key, job := range *podToJob {
+		if job.JobId == jobId {
+			podKeys = append(podKeys, key)
+		}
+	}
+	return podKeys, nil
 }
 
 func (sb *SlurmBridge) validatePodToJob(ctx context.Context, pod *corev1.Pod) error {
@@ -372,12 +927,12 @@ func (sb *SlurmBridge) validatePodToJob(ctx context.Context, pod *corev1.Pod) er
 		Name:      pod.Name,
 		Namespace: pod.Namespace,
 	}
-	podToJob, err := sb.slurmControl.GetJobsForPods(ctx)
+	val, ok, err := sb.getJobForPod(ctx, namespacedName.String())
 	if err != nil {
 		logger.Error(err, "error populating podToJob")
 		return err
 	}
-	if val, ok := (*podToJob)[namespacedName.String()]; ok {
+	if ok {
 		toUpdate := pod.DeepCopy()
 		// If the pod has a JobId set, validate it against podToJob
 		if pod.Labels[wellknown.LabelPlaceholderJobId] != "" &&
@@ -408,3 +963,138 @@ func (sb *SlurmBridge) validatePodToJob(ctx context.Context, pod *corev1.Pod) er
 	}
 	return nil
 }
+
+// Reserve is a no-op, as the node reservation is made by Slurm. It exists so
+// Unreserve is called when a pod of a placeholder job fails to bind.
+func (sb *SlurmBridge) Reserve(ctx context.Context, state fwk.CycleState, pod *corev1.Pod, nodeName string) *fwk.Status {
+	return nil
+}
+
+// Unreserve rejects the other waiting pods of the placeholder job and reverts
+// the placeholder job so all of its pods are scheduled again together.
+func (sb *SlurmBridge) Unreserve(ctx context.Context, state fwk.CycleState, pod *corev1.Pod, nodeName string) {
+	logger := klog.FromContext(ctx)
+	jobId := pod.Labels[wellknown.LabelPlaceholderJobId]
+	if jobId == "" || sb.gangSize(ctx, pod) <= 1 {
+		return
+	}
+	// The rejected waiting pods of the job are unreserved in turn, so only the
+	// first pod to be unreserved reverts the placeholder job.
+	if _, reverting := sb.revertingJobs.LoadOrStore(jobId, struct{}{}); reverting {
+		return
+	}
+	defer sb.revertingJobs.Delete(jobId)
+	current := &corev1.Pod{}
+	if err := sb.Get(ctx, client.ObjectKeyFromObject(pod), current); err == nil &&
+		current.Labels[wellknown.LabelPlaceholderJobId] != jobId {
+		logger.V(5).Info("placeholder job was already reverted", "pod", klog.KObj(pod), "jobId", jobId)
+		return
+	}
+	if err := sb.deletePlaceholderJob(ctx, pod); err != nil {
+		logger.Error(err, "failed to revert placeholder job", "pod", klog.KObj(pod), "jobId", jobId)
+	}
+	sb.handle.IterateOverWaitingPods(func(waitingPod framework.WaitingPod) {
+		p := waitingPod.GetPod()
+		if p.Namespace == pod.Namespace && p.Labels[wellknown.LabelPlaceholderJobId] == jobId {
+			logger.V(3).Info("rejecting waiting pod of placeholder job", "pod", klog.KObj(p), "jobId", jobId)
+			waitingPod.Reject(sb.Name(), "pod of placeholder job "+jobId+" was rejected")
+		}
+	})
+}
+
+// Permit holds the pods of a placeholder job until every pod of the job has
+// reserved its node, then allows them to bind together.
+func (sb *SlurmBridge) Permit(ctx context.Context, state fwk.CycleState, pod *corev1.Pod, nodeName string) (*fwk.Status, time.Duration) {
+	logger := klog.FromContext(ctx)
+	jobId := pod.Labels[wellknown.LabelPlaceholderJobId]
+	if jobId == "" {
+		return fwk.NewStatus(fwk.Success), 0
+	}
+	size := sb.gangSize(ctx, pod)
+	// The snapshot does not include the pod being scheduled
+	assigned := sb.assignedPods(pod) + 1
+	if assigned < size {
+		logger.V(4).Info("waiting for pods of placeholder job", "pod", klog.KObj(pod), "jobId", jobId,
+			"assigned", assigned, "size", size)
+		return fwk.NewStatus(fwk.Wait), sb.permitTimeout
+	}
+	sb.handle.IterateOverWaitingPods(func(waitingPod framework.WaitingPod) {
+		p := waitingPod.GetPod()
+		if p.Namespace == pod.Namespace && p.Labels[wellknown.LabelPlaceholderJobId] == jobId {
+			waitingPod.Allow(sb.Name())
+		}
+	})
+	return fwk.NewStatus(fwk.Success), 0
+}
+
+// PostBind records how long the pod took to be bound since it was created.
+func (sb *SlurmBridge) PostBind(ctx context.Context, state fwk.CycleState, pod *corev1.Pod, nodeName string) {
+	metrics.PodBindDuration.WithLabelValues(metrics.WorkloadKind(pod)).
+		Observe(time.Since(pod.CreationTimestamp.Time).Seconds())
+}
+
+// gangSize returns the number of pods of the placeholder job of the pod, as
+// recorded on the job itself. The pod lister may not yet reflect the nodes
+// assigned to the other pods of the job earlier in the same PreFilter, so it
+// only skips the pods which ended since they were added to the job.
+func (sb *SlurmBridge) gangSize(ctx context.Context, pod *corev1.Pod) int {
+	jobId := slurmjobir.ParseSlurmJobId(pod.Labels[wellknown.LabelPlaceholderJobId])
+	podKeys, err := sb.getPodsForJob(ctx, jobId)
+	if err != nil || len(podKeys) == 0 {
+		return sb.assignedGangSize(pod)
+	}
+	lister := sb.handle.SharedInformerFactory().Core().V1().Pods().Lister()
+	size := 0
+	for _, key := range podKeys {
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			continue
+		}
+		p, err := lister.Pods(namespace).Get(name)
+		if err == nil && p.UID != pod.UID && (p.DeletionTimestamp != nil ||
+			p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed) {
+			continue
+		}
+		size++
+	}
+	return max(size, 1)
+}
+
+// assignedGangSize returns the number of pods which the pod lister has seen
+// assigned a node from the placeholder job of the pod. It is used when the
+// placeholder job is not known.
+func (sb *SlurmBridge) assignedGangSize(pod *corev1.Pod) int {
+	selector := labels.SelectorFromSet(labels.Set{wellknown.LabelPlaceholderJobId: pod.Labels[wellknown.LabelPlaceholderJobId]})
+	pods, err := sb.handle.SharedInformerFactory().Core().V1().Pods().Lister().Pods(pod.Namespace).List(selector)
+	if err != nil {
+		return 1
+	}
+	size := 0
+	for _, p := range pods {
+		if p.Annotations[wellknown.AnnotationPlaceholderNode] != "" || p.UID == pod.UID {
+			size++
+		}
+	}
+	return max(size, 1)
+}
+
+// assignedPods returns the number of other pods of the placeholder job which
+// have been reserved or bound to a node in the scheduler snapshot.
+func (sb *SlurmBridge) assignedPods(pod *corev1.Pod) int {
+	nodeInfos, err := sb.handle.SnapshotSharedLister().NodeInfos().List()
+	if err != nil {
+		return 0
+	}
+	jobId := pod.Labels[wellknown.LabelPlaceholderJobId]
+	assigned := 0
+	for _, nodeInfo := range nodeInfos {
+		for _, podInfo := range nodeInfo.GetPods() {
+			p := podInfo.GetPod()
+			if p.UID != pod.UID && p.Namespace == pod.Namespace &&
+				p.Labels[wellknown.LabelPlaceholderJobId] == jobId {
+				assigned++
+			}
+		}
+	}
+	return assigned
+}
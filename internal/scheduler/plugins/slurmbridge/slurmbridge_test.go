@@ -5,11 +5,18 @@ package slurmbridge
 
 import (
 	"context"
+	"maps"
 	"reflect"
+	"slices"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
 	"github.com/SlinkyProject/slurm-bridge/internal/scheduler/plugins/slurmbridge/slurmcontrol"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils/placeholderinfo"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjobir"
 	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 	v0043 "github.com/SlinkyProject/slurm-client/api/v0043"
 	slurmclient "github.com/SlinkyProject/slurm-client/pkg/client"
@@ -26,7 +33,10 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
 	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
 	fwk "k8s.io/kube-scheduler/framework"
+	internalcache "k8s.io/kubernetes/pkg/scheduler/backend/cache"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
@@ -36,6 +46,7 @@ import (
 	"k8s.io/utils/ptr"
 	kubeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	kubefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	lws "sigs.k8s.io/lws/api/leaderworkerset/v1"
 	_ "sigs.k8s.io/scheduler-plugins/apis/config/scheme"
 )
 
@@ -134,6 +145,7 @@ func TestSlurmBridge_PreFilter(t *testing.T) {
 	type fields struct {
 		client        kubeclient.Client
 		schedulerName string
+		policies      []config.Policy
 		slurmControl  slurmcontrol.SlurmControlInterface
 		handle        framework.Handle
 	}
@@ -173,7 +185,7 @@ func TestSlurmBridge_PreFilter(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithLists(list).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 			},
 			args: args{
@@ -201,7 +213,7 @@ func TestSlurmBridge_PreFilter(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithInterceptorFuncs(f).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 				handle: f,
 			},
@@ -226,7 +238,7 @@ func TestSlurmBridge_PreFilter(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithInterceptorFuncs(f).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 				handle: f,
 			},
@@ -251,7 +263,7 @@ func TestSlurmBridge_PreFilter(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithInterceptorFuncs(f).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 				handle: f,
 			},
@@ -263,6 +275,54 @@ func TestSlurmBridge_PreFilter(t *testing.T) {
 			want:  nil,
 			want1: fwk.NewStatus(fwk.UnschedulableAndUnresolvable, ErrorNodeConfigInvalid.Error()),
 		},
+		{
+			name: "No bridged node matches the node selection",
+			fields: fields{
+				client: kubefake.NewFakeClient(pod.DeepCopy()),
+				slurmControl: func() slurmcontrol.SlurmControlInterface {
+					c := fake.NewClientBuilder().Build()
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
+				}(),
+				handle: f,
+			},
+			args: args{
+				ctx:   ctx,
+				state: framework.NewCycleState(),
+				pod: func() *corev1.Pod {
+					p := pod.DeepCopy()
+					p.Spec.NodeSelector = map[string]string{"zone": "a"}
+					return p
+				}(),
+			},
+			want:  nil,
+			want1: fwk.NewStatus(fwk.UnschedulableAndUnresolvable, slurmjobir.ErrorNoMatchingNodes.Error()),
+		},
+		{
+			name: "Account is not allowed by the policy",
+			fields: fields{
+				client: kubefake.NewFakeClient(func() *corev1.Pod {
+					p := pod.DeepCopy()
+					p.Annotations = map[string]string{wellknown.AnnotationAccount: "team-b"}
+					return p
+				}()),
+				policies: []config.Policy{
+					{Account: config.PolicyRule{Allowed: []string{"team-a"}}},
+				},
+				slurmControl: func() slurmcontrol.SlurmControlInterface {
+					c := fake.NewClientBuilder().Build()
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
+				}(),
+				handle: f,
+			},
+			args: args{
+				ctx:   ctx,
+				state: framework.NewCycleState(),
+				pod:   pod.DeepCopy(),
+			},
+			want: nil,
+			want1: fwk.NewStatus(fwk.UnschedulableAndUnresolvable,
+				wellknown.AnnotationAccount+` "team-b" is not allowed, allowed values are: team-a`),
+		},
 		{
 			name: "Create a placeholder job",
 			fields: fields{
@@ -277,7 +337,7 @@ func TestSlurmBridge_PreFilter(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithInterceptorFuncs(f).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 				handle: f,
 			},
@@ -312,7 +372,7 @@ func TestSlurmBridge_PreFilter(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithLists(list).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 				handle: f,
 			},
@@ -348,7 +408,7 @@ func TestSlurmBridge_PreFilter(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithLists(list).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 				handle: f,
 			},
@@ -366,6 +426,7 @@ func TestSlurmBridge_PreFilter(t *testing.T) {
 			sb := &SlurmBridge{
 				Client:        tt.fields.client,
 				schedulerName: tt.fields.schedulerName,
+				policies:      tt.fields.policies,
 				slurmControl:  tt.fields.slurmControl,
 				handle:        tt.fields.handle,
 			}
@@ -420,6 +481,19 @@ func TestSlurmBridge_Filter(t *testing.T) {
 	nodeInfo.SetNode(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
 	podWithAnnotation := st.MakePod().Name("foo").Annotations(map[string]string{wellknown.AnnotationPlaceholderNode: "node1"}).Obj()
 	podWithoutAnnotation := st.MakePod().Name("foo").Obj()
+	sharedNodeInfo := framework.NewNodeInfo(st.MakePod().Name("other").UID("other").Node("node1").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "2"}).
+		Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "3"}).Obj())
+	sharedNodeInfo.SetNode(st.MakeNode().Name("node1").
+		Capacity(map[corev1.ResourceName]string{corev1.ResourceCPU: "4", corev1.ResourcePods: "10"}).Obj())
+	podSharedFits := st.MakePod().Name("foo").UID("foo").
+		Annotations(map[string]string{wellknown.AnnotationPlaceholderNode: "node1"}).
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).
+		Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "1"}).Obj()
+	podSharedTooLarge := st.MakePod().Name("foo").UID("foo").
+		Annotations(map[string]string{wellknown.AnnotationPlaceholderNode: "node1"}).
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).
+		Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "2"}).Obj()
 	type fields struct {
 		client       kubeclient.Client
 		slurmControl slurmcontrol.SlurmControlInterface
@@ -442,7 +516,7 @@ func TestSlurmBridge_Filter(t *testing.T) {
 			fields: fields{
 				client: nil,
 				slurmControl: slurmcontrol.NewControl(
-					fake.NewFakeClient(), "kubernetes", "slurm-bridge"),
+					fake.NewFakeClient(), "kubernetes", "slurm-bridge", "", false),
 			},
 			args: args{
 				ctx:      ctx,
@@ -456,7 +530,7 @@ func TestSlurmBridge_Filter(t *testing.T) {
 			name: "Node in annotation does not match",
 			fields: fields{
 				client:       nil,
-				slurmControl: slurmcontrol.NewControl(fake.NewFakeClient(), "kubernetes", "slurm-bridge"),
+				slurmControl: slurmcontrol.NewControl(fake.NewFakeClient(), "kubernetes", "slurm-bridge", "", false),
 			},
 			args: args{
 				ctx:      ctx,
@@ -466,6 +540,34 @@ func TestSlurmBridge_Filter(t *testing.T) {
 			},
 			want: fwk.NewStatus(fwk.Unschedulable, "node does not match annotation"),
 		},
+		{
+			name: "Shared node has room for pod",
+			fields: fields{
+				client:       nil,
+				slurmControl: slurmcontrol.NewControl(fake.NewFakeClient(), "kubernetes", "slurm-bridge", "oversubscribe", false),
+			},
+			args: args{
+				ctx:      ctx,
+				state:    nil,
+				pod:      podSharedFits.DeepCopy(),
+				nodeInfo: sharedNodeInfo,
+			},
+			want: fwk.NewStatus(fwk.Success, ""),
+		},
+		{
+			name: "Shared node lacks room for pod",
+			fields: fields{
+				client:       nil,
+				slurmControl: slurmcontrol.NewControl(fake.NewFakeClient(), "kubernetes", "slurm-bridge", "oversubscribe", false),
+			},
+			args: args{
+				ctx:      ctx,
+				state:    nil,
+				pod:      podSharedTooLarge.DeepCopy(),
+				nodeInfo: sharedNodeInfo,
+			},
+			want: fwk.NewStatus(fwk.Unschedulable, "Insufficient cpu"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -523,7 +625,7 @@ func TestSlurmBridge_deletePlaceholderJob(t *testing.T) {
 			fields: fields{
 				Client: kubefake.NewFakeClient(pod.DeepCopy()),
 				slurmControl: slurmcontrol.NewControl(
-					fake.NewFakeClient(), "kubernetes", "slurm-bridge"),
+					fake.NewFakeClient(), "kubernetes", "slurm-bridge", "", false),
 				handle: f,
 			},
 			args: args{
@@ -532,6 +634,45 @@ func TestSlurmBridge_deletePlaceholderJob(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Attached job is not deleted",
+			fields: fields{
+				Client: kubefake.NewFakeClient(pod.DeepCopy()),
+				slurmControl: func() slurmcontrol.SlurmControlInterface {
+					list := &types.V0043JobInfoList{
+						Items: []types.V0043JobInfo{
+							{V0043JobInfo: v0043.V0043JobInfo{
+								AdminComment: func() *string {
+									pi := placeholderinfo.PlaceholderInfo{
+										Pods:     []string{"default/pod1"},
+										Attached: true,
+									}
+									return ptr.To(pi.ToString())
+								}(),
+								JobId:    ptr.To[int32](1),
+								JobState: &[]v0043.V0043JobInfoJobState{v0043.V0043JobInfoJobStateRUNNING},
+							}},
+						},
+					}
+					f := interceptor.Funcs{
+						Delete: func(ctx context.Context, obj object.Object, opts ...slurmclient.DeleteOption) error {
+							return ErrorPodUpdateFailed
+						},
+					}
+					c := fake.NewClientBuilder().
+						WithLists(list).
+						WithInterceptorFuncs(f).
+						Build()
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
+				}(),
+				handle: f,
+			},
+			args: args{
+				ctx: context.Background(),
+				pod: pod.DeepCopy(),
+			},
+			wantErr: false,
+		},
 		{
 			name: "Placeholder job is deleted",
 			fields: fields{
@@ -547,7 +688,7 @@ func TestSlurmBridge_deletePlaceholderJob(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithLists(list).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 				handle: f,
 			},
@@ -603,7 +744,7 @@ func TestSlurmBridge_validatePodToJob(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithInterceptorFuncs(f).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 				handle: nil,
 			},
@@ -636,7 +777,7 @@ func TestSlurmBridge_validatePodToJob(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithLists(list).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 				handle: nil,
 			},
@@ -669,7 +810,7 @@ func TestSlurmBridge_validatePodToJob(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithLists(list).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 				handle: nil,
 			},
@@ -702,7 +843,7 @@ func TestSlurmBridge_validatePodToJob(t *testing.T) {
 					c := fake.NewClientBuilder().
 						WithLists(list).
 						Build()
-					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge")
+					return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
 				}(),
 				handle: nil,
 			},
@@ -743,3 +884,872 @@ func TestSlurmBridge_validatePodToJob(t *testing.T) {
 		})
 	}
 }
+
+func TestSlurmBridge_labelPodsWithJobId(t *testing.T) {
+	ctx := context.Background()
+	labeled := st.MakePod().Namespace(metav1.NamespaceDefault).Name("pod1").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).Obj()
+	labeled.Finalizers = []string{wellknown.FinalizerScheduler}
+	unlabeled := st.MakePod().Namespace(metav1.NamespaceDefault).Name("pod2").Obj()
+	sb := &SlurmBridge{Client: kubefake.NewFakeClient(labeled.DeepCopy(), unlabeled.DeepCopy())}
+	slurmJobIR := &slurmjobir.SlurmJobIR{Pods: corev1.PodList{Items: []corev1.Pod{*labeled, *unlabeled}}}
+
+	got, err := sb.labelPodsWithJobId(ctx, 1, slurmJobIR)
+	if err != nil {
+		t.Fatalf("SlurmBridge.labelPodsWithJobId() error = %v", err)
+	}
+	if got != 1 {
+		t.Errorf("SlurmBridge.labelPodsWithJobId() = %v, want %v", got, 1)
+	}
+	podList := &corev1.PodList{}
+	if err := sb.List(ctx, podList); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range podList.Items {
+		if p.Labels[wellknown.LabelPlaceholderJobId] != "1" {
+			t.Errorf("SlurmBridge.labelPodsWithJobId() pod %s labels = %v", p.Name, p.Labels)
+		}
+		if !reflect.DeepEqual(p.Finalizers, []string{wellknown.FinalizerScheduler}) {
+			t.Errorf("SlurmBridge.labelPodsWithJobId() pod %s finalizers = %v", p.Name, p.Finalizers)
+		}
+	}
+}
+
+func TestSlurmBridge_annotatePodsWithNodes(t *testing.T) {
+	// makePods returns pods in reverse rank order
+	makePods := func(n int) *corev1.PodList {
+		pods := &corev1.PodList{}
+		for i := n - 1; i >= 0; i-- {
+			pods.Items = append(pods.Items, *st.MakePod().Namespace(metav1.NamespaceDefault).
+				Name("pod" + strconv.Itoa(i)).
+				Labels(map[string]string{
+					wellknown.LabelPlaceholderJobId: "1",
+					lws.WorkerIndexLabelKey:         strconv.Itoa(i),
+				}).Obj())
+		}
+		return pods
+	}
+	type args struct {
+		kubeNodes    []string
+		tasksPerNode int32
+		pods         *corev1.PodList
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "One pod per node in rank order",
+			args: args{
+				kubeNodes:    []string{"node2", "node1"},
+				tasksPerNode: 1,
+				pods:         makePods(2),
+			},
+			want: map[string]string{"pod0": "node2", "pod1": "node1"},
+		},
+		{
+			name: "Multiple pods per node",
+			args: args{
+				kubeNodes:    []string{"node1", "node2"},
+				tasksPerNode: 2,
+				pods:         makePods(4),
+			},
+			want: map[string]string{"pod0": "node1", "pod1": "node1", "pod2": "node2", "pod3": "node2"},
+		},
+		{
+			name: "Last node partially filled",
+			args: args{
+				kubeNodes:    []string{"node1", "node2"},
+				tasksPerNode: 3,
+				pods:         makePods(4),
+			},
+			want: map[string]string{"pod0": "node1", "pod1": "node1", "pod2": "node1", "pod3": "node2"},
+		},
+		{
+			name: "Bound pods keep their node",
+			args: args{
+				kubeNodes:    []string{"node1", "node2"},
+				tasksPerNode: 1,
+				pods: func() *corev1.PodList {
+					pods := makePods(2)
+					// pod1 was admitted first on a shared node
+					pods.Items[0].Spec.NodeName = "node1"
+					pods.Items[0].Annotations = map[string]string{wellknown.AnnotationPlaceholderNode: "node1"}
+					return pods
+				}(),
+			},
+			want: map[string]string{"pod0": "node2", "pod1": "node1"},
+		},
+		{
+			name: "More pods than nodes",
+			args: args{
+				kubeNodes:    []string{"node1"},
+				tasksPerNode: 1,
+				pods:         makePods(2),
+			},
+			want: map[string]string{"pod0": "node1", "pod1": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []runtime.Object{}
+			for i := range tt.args.pods.Items {
+				objs = append(objs, tt.args.pods.Items[i].DeepCopy())
+			}
+			sb := &SlurmBridge{Client: kubefake.NewFakeClient(objs...)}
+			err := sb.annotatePodsWithNodes(context.Background(), 1, tt.args.kubeNodes, tt.args.tasksPerNode, tt.args.pods)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SlurmBridge.annotatePodsWithNodes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			podList := &corev1.PodList{}
+			if err := sb.List(context.Background(), podList); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, p := range podList.Items {
+				got[p.Name] = p.Annotations[wellknown.AnnotationPlaceholderNode]
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SlurmBridge.annotatePodsWithNodes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlurmBridge_assignNodes(t *testing.T) {
+	makePod := func(i int) corev1.Pod {
+		return *st.MakePod().Namespace(metav1.NamespaceDefault).
+			Name("pod" + strconv.Itoa(i)).
+			Labels(map[string]string{
+				wellknown.LabelPlaceholderJobId: "1",
+				lws.WorkerIndexLabelKey:         strconv.Itoa(i),
+			}).Obj()
+	}
+	pods := []corev1.Pod{makePod(0), makePod(1), makePod(2)}
+	tests := []struct {
+		name           string
+		placeholderJob *slurmcontrol.PlaceholderJob
+		slurmJobIR     *slurmjobir.SlurmJobIR
+		attached       []corev1.Pod
+		wantNodes      []string
+		want           map[string]string
+	}{
+		{
+			name:           "Homogeneous job",
+			placeholderJob: &slurmcontrol.PlaceholderJob{JobId: 1, Nodes: "node[1-3]"},
+			slurmJobIR: &slurmjobir.SlurmJobIR{
+				Pods: corev1.PodList{Items: pods},
+			},
+			wantNodes: []string{"node1", "node2", "node3"},
+			want:      map[string]string{"pod0": "node1", "pod1": "node2", "pod2": "node3"},
+		},
+		{
+			name: "Heterogeneous job",
+			placeholderJob: &slurmcontrol.PlaceholderJob{
+				JobId:          1,
+				Nodes:          "cpu1,gpu[1-2]",
+				ComponentNodes: []string{"cpu1", "gpu[1-2]"},
+			},
+			slurmJobIR: &slurmjobir.SlurmJobIR{
+				Pods: corev1.PodList{Items: pods},
+				Components: []slurmjobir.SlurmJobIRComponent{
+					{Pods: corev1.PodList{Items: pods[:1]}},
+					{Pods: corev1.PodList{Items: pods[1:]}},
+				},
+			},
+			wantNodes: []string{"cpu1", "gpu1", "gpu2"},
+			want:      map[string]string{"pod0": "cpu1", "pod1": "gpu1", "pod2": "gpu2"},
+		},
+		{
+			name:           "Attached job",
+			placeholderJob: &slurmcontrol.PlaceholderJob{JobId: 1, Nodes: "node[1-3]"},
+			slurmJobIR: &slurmjobir.SlurmJobIR{
+				Pods:    corev1.PodList{Items: pods[:2]},
+				JobInfo: slurmjobir.SlurmJobIRJobInfo{AttachJobId: ptr.To[int32](1)},
+			},
+			attached: []corev1.Pod{
+				*st.MakePod().Namespace(metav1.NamespaceDefault).Name("pod3").
+					Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).
+					Annotations(map[string]string{wellknown.AnnotationPlaceholderNode: "node1"}).Obj(),
+			},
+			wantNodes: []string{"node2", "node3"},
+			want:      map[string]string{"pod0": "node2", "pod1": "node3", "pod2": "", "pod3": "node1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []runtime.Object{}
+			for i := range pods {
+				objs = append(objs, pods[i].DeepCopy())
+			}
+			for i := range tt.attached {
+				objs = append(objs, tt.attached[i].DeepCopy())
+			}
+			sb := &SlurmBridge{Client: kubefake.NewFakeClient(objs...)}
+			gotNodes, err := sb.assignNodes(context.Background(), tt.placeholderJob, tt.slurmJobIR)
+			if err != nil {
+				t.Fatalf("SlurmBridge.assignNodes() error = %v", err)
+			}
+			if !reflect.DeepEqual(gotNodes, tt.wantNodes) {
+				t.Errorf("SlurmBridge.assignNodes() = %v, want %v", gotNodes, tt.wantNodes)
+			}
+			podList := &corev1.PodList{}
+			if err := sb.List(context.Background(), podList); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, p := range podList.Items {
+				got[p.Name] = p.Annotations[wellknown.AnnotationPlaceholderNode]
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SlurmBridge.assignNodes() annotations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlurmBridge_attachJob(t *testing.T) {
+	newNode := func(name string, bridged bool) *corev1.Node {
+		node := st.MakeNode().Name(name).Obj()
+		if bridged {
+			node.Spec.Taints = []corev1.Taint{*utils.NewTaintNodeBridged("slurm-bridge")}
+		}
+		return node
+	}
+	newPod := func(name string) *corev1.Pod {
+		return st.MakePod().Namespace(metav1.NamespaceDefault).Name(name).Obj()
+	}
+	attachedPod := st.MakePod().Namespace(metav1.NamespaceDefault).Name("bar").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).
+		Annotations(map[string]string{wellknown.AnnotationPlaceholderNode: "node1"}).Obj()
+	slurmControl := func() slurmcontrol.SlurmControlInterface {
+		list := &types.V0043JobInfoList{
+			Items: []types.V0043JobInfo{
+				{V0043JobInfo: v0043.V0043JobInfo{
+					JobId:    ptr.To[int32](1),
+					JobState: &[]v0043.V0043JobInfoJobState{v0043.V0043JobInfoJobStateRUNNING},
+					Nodes:    ptr.To("node[1-2]"),
+					UserId:   ptr.To[int32](1000),
+				}},
+			},
+		}
+		c := fake.NewClientBuilder().WithLists(list).Build()
+		return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
+	}
+	newSlurmJobIR := func(userId string, pods ...*corev1.Pod) *slurmjobir.SlurmJobIR {
+		slurmJobIR := &slurmjobir.SlurmJobIR{
+			JobInfo: slurmjobir.SlurmJobIRJobInfo{
+				AttachJobId: ptr.To[int32](1),
+				UserId:      ptr.To(userId),
+			},
+			Policy: &config.Policy{
+				UserId: config.PolicyRule{Allowed: []string{userId}},
+			},
+		}
+		for _, p := range pods {
+			slurmJobIR.Pods.Items = append(slurmJobIR.Pods.Items, *p)
+		}
+		return slurmJobIR
+	}
+	tests := []struct {
+		name       string
+		objs       []runtime.Object
+		slurmJobIR *slurmjobir.SlurmJobIR
+		want       *fwk.Status
+		wantLabel  string
+	}{
+		{
+			name:       "Attach pods",
+			objs:       []runtime.Object{newNode("node1", true), newNode("node2", true), attachedPod.DeepCopy(), newPod("foo")},
+			slurmJobIR: newSlurmJobIR("1000", newPod("foo")),
+			want:       fwk.NewStatus(fwk.Pending),
+			wantLabel:  "1",
+		},
+		{
+			name:       "Job of other user",
+			objs:       []runtime.Object{newNode("node1", true), newNode("node2", true), newPod("foo")},
+			slurmJobIR: newSlurmJobIR("1001", newPod("foo")),
+			want:       fwk.NewStatus(fwk.UnschedulableAndUnresolvable, slurmcontrol.ErrorAttachJobNotOwned.Error()),
+		},
+		{
+			name:       "Nodes not bridged",
+			objs:       []runtime.Object{newNode("node1", true), newNode("node2", false), newPod("foo")},
+			slurmJobIR: newSlurmJobIR("1000", newPod("foo")),
+			want:       fwk.NewStatus(fwk.UnschedulableAndUnresolvable, ErrorAttachNodesNotBridged.Error()),
+		},
+		{
+			name:       "Nodes not found",
+			objs:       []runtime.Object{newNode("node1", true), newPod("foo")},
+			slurmJobIR: newSlurmJobIR("1000", newPod("foo")),
+			want:       fwk.NewStatus(fwk.UnschedulableAndUnresolvable, ErrorAttachNodesNotBridged.Error()),
+		},
+		{
+			name:       "No capacity",
+			objs:       []runtime.Object{newNode("node1", true), newNode("node2", true), attachedPod.DeepCopy(), newPod("foo"), newPod("baz")},
+			slurmJobIR: newSlurmJobIR("1000", newPod("foo"), newPod("baz")),
+			want:       fwk.NewStatus(fwk.Unschedulable, ErrorAttachJobNoCapacity.Error()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &SlurmBridge{
+				Client:       kubefake.NewFakeClient(tt.objs...),
+				slurmControl: slurmControl(),
+			}
+			pod := newPod("foo")
+			got := sb.attachJob(context.Background(), pod, tt.slurmJobIR)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SlurmBridge.attachJob() = %v, want %v", got, tt.want)
+			}
+			if err := sb.Get(context.Background(), kubeclient.ObjectKeyFromObject(pod), pod); err != nil {
+				t.Fatal(err)
+			}
+			if got := pod.Labels[wellknown.LabelPlaceholderJobId]; got != tt.wantLabel {
+				t.Errorf("SlurmBridge.attachJob() label = %v, want %v", got, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestSlurmBridge_claimPoolJob(t *testing.T) {
+	newPod := func(name string) *corev1.Pod {
+		return st.MakePod().Namespace(metav1.NamespaceDefault).Name(name).Obj()
+	}
+	slurmControl := func(pool string) slurmcontrol.SlurmControlInterface {
+		phInfo := placeholderinfo.PlaceholderInfo{Attached: true, Pool: pool}
+		list := &types.V0043JobInfoList{
+			Items: []types.V0043JobInfo{
+				{V0043JobInfo: v0043.V0043JobInfo{
+					AdminComment: ptr.To(phInfo.ToString()),
+					JobId:        ptr.To[int32](1),
+					JobState:     &[]v0043.V0043JobInfoJobState{v0043.V0043JobInfoJobStateRUNNING},
+					Nodes:        ptr.To("node1"),
+				}},
+			},
+		}
+		c := fake.NewClientBuilder().WithLists(list).Build()
+		return slurmcontrol.NewControl(c, "kubernetes", "slurm-bridge", "", false)
+	}
+	tests := []struct {
+		name         string
+		slurmControl slurmcontrol.SlurmControlInterface
+		want         *fwk.Status
+		wantLabel    string
+	}{
+		{
+			name:         "Claim idle allocation",
+			slurmControl: slurmControl("default/pool"),
+			want:         fwk.NewStatus(fwk.Pending),
+			wantLabel:    "1",
+		},
+		{
+			name:         "No idle allocation",
+			slurmControl: slurmControl("default/other"),
+			want:         nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &SlurmBridge{
+				Client:       kubefake.NewFakeClient(newPod("foo")),
+				slurmControl: tt.slurmControl,
+			}
+			pod := newPod("foo")
+			slurmJobIR := &slurmjobir.SlurmJobIR{
+				JobInfo: slurmjobir.SlurmJobIRJobInfo{AllocationPool: ptr.To("pool")},
+				Pods:    corev1.PodList{Items: []corev1.Pod{*newPod("foo")}},
+			}
+			got := sb.claimPoolJob(context.Background(), pod, slurmJobIR)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SlurmBridge.claimPoolJob() = %v, want %v", got, tt.want)
+			}
+			if err := sb.Get(context.Background(), kubeclient.ObjectKeyFromObject(pod), pod); err != nil {
+				t.Fatal(err)
+			}
+			if got := pod.Labels[wellknown.LabelPlaceholderJobId]; got != tt.wantLabel {
+				t.Errorf("SlurmBridge.claimPoolJob() label = %v, want %v", got, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func Test_pendingMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		job  *slurmcontrol.PlaceholderJob
+		want string
+	}{
+		{
+			name: "No reason",
+			job:  &slurmcontrol.PlaceholderJob{JobId: 1},
+			want: "no nodes assigned",
+		},
+		{
+			name: "Reason None",
+			job:  &slurmcontrol.PlaceholderJob{JobId: 1, StateReason: "None"},
+			want: "no nodes assigned",
+		},
+		{
+			name: "Reason",
+			job:  &slurmcontrol.PlaceholderJob{JobId: 1, StateReason: "Priority"},
+			want: "no nodes assigned, Slurm job 1 is pending: Priority",
+		},
+		{
+			name: "Reason and start time",
+			job:  &slurmcontrol.PlaceholderJob{JobId: 1, StateReason: "Resources", StartTime: time.Unix(1700000000, 0)},
+			want: "no nodes assigned, Slurm job 1 is pending: Resources, expected to start at 2023-11-14T22:13:20Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pendingMessage(tt.job); got != tt.want {
+				t.Errorf("pendingMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlurmBridge_annotatePodsWithPendingReason(t *testing.T) {
+	ctx := context.Background()
+	recorder := events.NewFakeRecorder(10)
+	cs := clientsetfake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(cs, 0)
+	registeredPlugins := []tf.RegisterPluginFunc{
+		tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+		tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+	}
+	f, err := tf.NewFramework(
+		ctx,
+		registeredPlugins,
+		"slurm-bridge",
+		fwkruntime.WithInformerFactory(informerFactory),
+		fwkruntime.WithEventRecorder(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod1 := st.MakePod().Namespace(metav1.NamespaceDefault).Name("pod1").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).Obj()
+	pod2 := st.MakePod().Namespace(metav1.NamespaceDefault).Name("pod2").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "2"}).Obj()
+	pods := &corev1.PodList{Items: []corev1.Pod{*pod1, *pod2}}
+	sb := &SlurmBridge{Client: kubefake.NewFakeClient(pod1.DeepCopy(), pod2.DeepCopy()), handle: f}
+
+	job := &slurmcontrol.PlaceholderJob{JobId: 1, StateReason: "Resources", StartTime: time.Unix(1700000000, 0)}
+	if err := sb.annotatePodsWithPendingReason(ctx, job, pendingMessage(job), pods); err != nil {
+		t.Fatalf("SlurmBridge.annotatePodsWithPendingReason() error = %v", err)
+	}
+	got := &corev1.Pod{}
+	if err := sb.Get(ctx, kubeclient.ObjectKeyFromObject(pod1), got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		wellknown.AnnotationPendingReason:     "Resources",
+		wellknown.AnnotationExpectedStartTime: "2023-11-14T22:13:20Z",
+	}
+	if !reflect.DeepEqual(got.Annotations, want) {
+		t.Errorf("SlurmBridge.annotatePodsWithPendingReason() annotations = %v, want %v", got.Annotations, want)
+	}
+	if err := sb.Get(ctx, kubeclient.ObjectKeyFromObject(pod2), got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Annotations) != 0 {
+		t.Errorf("SlurmBridge.annotatePodsWithPendingReason() annotated pod of another job: %v", got.Annotations)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("SlurmBridge.annotatePodsWithPendingReason() recorded %d events, want 1", len(recorder.Events))
+	}
+}
+
+func TestSlurmBridge_isSchedulableAfterPodChange(t *testing.T) {
+	pod := st.MakePod().Namespace("default").Name("pod1").UID("pod1").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).
+		Annotations(map[string]string{wellknown.AnnotationPlaceholderNode: "node1"}).Obj()
+	otherJobOnNode := st.MakePod().Namespace("other").Name("pod5").UID("pod5").Node("node1").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "3"}).Obj()
+	sameJob := st.MakePod().Namespace("default").Name("pod2").UID("pod2").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).Obj()
+	otherJob := st.MakePod().Namespace("default").Name("pod3").UID("pod3").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "2"}).Obj()
+	otherNamespace := st.MakePod().Namespace("other").Name("pod2").UID("pod4").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).Obj()
+	type args struct {
+		oldObj any
+		newObj any
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    fwk.QueueingHint
+		wantErr bool
+	}{
+		{
+			name: "Pod of same job added",
+			args: args{newObj: sameJob},
+			want: fwk.Queue,
+		},
+		{
+			name: "Pod of same job deleted",
+			args: args{oldObj: sameJob},
+			want: fwk.Queue,
+		},
+		{
+			name: "Pod of other job added",
+			args: args{newObj: otherJob},
+			want: fwk.QueueSkip,
+		},
+		{
+			name: "Pod of other job deleted from assigned node",
+			args: args{oldObj: otherJobOnNode},
+			want: fwk.Queue,
+		},
+		{
+			name: "Pod of other job added to assigned node",
+			args: args{newObj: otherJobOnNode},
+			want: fwk.QueueSkip,
+		},
+		{
+			name: "Pod in other namespace added",
+			args: args{newObj: otherNamespace},
+			want: fwk.QueueSkip,
+		},
+		{
+			name: "Pod itself added",
+			args: args{newObj: pod},
+			want: fwk.QueueSkip,
+		},
+		{
+			name:    "Unexpected object",
+			args:    args{newObj: &corev1.Node{}},
+			want:    fwk.Queue,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &SlurmBridge{}
+			got, err := sb.isSchedulableAfterPodChange(klog.Background(), pod, tt.args.oldObj, tt.args.newObj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SlurmBridge.isSchedulableAfterPodChange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SlurmBridge.isSchedulableAfterPodChange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlurmBridge_isSchedulableAfterNodeChange(t *testing.T) {
+	pod := st.MakePod().Namespace("default").Name("pod1").UID("pod1").
+		NodeSelector(map[string]string{"gpu": "true"}).Obj()
+	anyNodePod := st.MakePod().Namespace("default").Name("pod2").UID("pod2").Obj()
+	bridged := st.MakeNode().Name("node1").Label("gpu", "true").Obj()
+	bridged.Spec.Taints = []corev1.Taint{utils.TaintNodeBridged}
+	bridgedNoLabel := st.MakeNode().Name("node1").Obj()
+	bridgedNoLabel.Spec.Taints = []corev1.Taint{utils.TaintNodeBridged}
+	notBridged := st.MakeNode().Name("node1").Label("gpu", "true").Obj()
+	type args struct {
+		pod    *corev1.Pod
+		oldObj any
+		newObj any
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    fwk.QueueingHint
+		wantErr bool
+	}{
+		{
+			name: "Matching bridged node added",
+			args: args{pod: pod, newObj: bridged},
+			want: fwk.Queue,
+		},
+		{
+			name: "Bridged node without matching labels added",
+			args: args{pod: pod, newObj: bridgedNoLabel},
+			want: fwk.QueueSkip,
+		},
+		{
+			name: "Node which is not bridged added",
+			args: args{pod: pod, newObj: notBridged},
+			want: fwk.QueueSkip,
+		},
+		{
+			name: "Node becomes bridged",
+			args: args{pod: pod, oldObj: notBridged, newObj: bridged},
+			want: fwk.Queue,
+		},
+		{
+			name: "Bridged node labels come to match",
+			args: args{pod: pod, oldObj: bridgedNoLabel, newObj: bridged},
+			want: fwk.Queue,
+		},
+		{
+			name: "Bridged node already matched",
+			args: args{pod: pod, oldObj: bridged, newObj: bridged},
+			want: fwk.QueueSkip,
+		},
+		{
+			name: "Bridged node added for pod without node selection",
+			args: args{pod: anyNodePod, newObj: bridgedNoLabel},
+			want: fwk.Queue,
+		},
+		{
+			name:    "Unexpected object",
+			args:    args{pod: pod, newObj: &corev1.Pod{}},
+			want:    fwk.Queue,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &SlurmBridge{}
+			got, err := sb.isSchedulableAfterNodeChange(klog.Background(), tt.args.pod, tt.args.oldObj, tt.args.newObj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SlurmBridge.isSchedulableAfterNodeChange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SlurmBridge.isSchedulableAfterNodeChange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type fakePodActivator struct {
+	activated map[string]*corev1.Pod
+}
+
+func (f *fakePodActivator) Activate(_ klog.Logger, pods map[string]*corev1.Pod) {
+	maps.Copy(f.activated, pods)
+}
+
+func TestSlurmBridge_activatePodsForJob(t *testing.T) {
+	pending := st.MakePod().Namespace("default").Name("pending").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).Obj()
+	bound := st.MakePod().Namespace("default").Name("bound").Node("node1").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).Obj()
+	otherJob := st.MakePod().Namespace("default").Name("other").
+		Labels(map[string]string{wellknown.LabelPlaceholderJobId: "2"}).Obj()
+
+	ctx := context.Background()
+	cs := clientsetfake.NewSimpleClientset(pending, bound, otherJob)
+	informerFactory := informers.NewSharedInformerFactory(cs, 0)
+	activator := &fakePodActivator{activated: map[string]*corev1.Pod{}}
+	registeredPlugins := []tf.RegisterPluginFunc{
+		tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+		tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+	}
+	f, err := tf.NewFramework(ctx, registeredPlugins, "slurm-bridge",
+		fwkruntime.WithInformerFactory(informerFactory),
+		fwkruntime.WithPodActivator(activator))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = informerFactory.Core().V1().Pods().Lister()
+	informerFactory.Start(ctx.Done())
+	informerFactory.WaitForCacheSync(ctx.Done())
+
+	sb := &SlurmBridge{handle: f}
+	sb.activatePodsForJob(slurmcontrol.PlaceholderJob{JobId: 1, Nodes: "node1"})
+	want := []string{"default/pending"}
+	if got := slices.Sorted(maps.Keys(activator.activated)); !reflect.DeepEqual(got, want) {
+		t.Errorf("SlurmBridge.activatePodsForJob() activated = %v, want %v", got, want)
+	}
+}
+
+func TestSlurmBridge_Permit(t *testing.T) {
+	makePod := func(name, jobId, node string) *corev1.Pod {
+		return st.MakePod().Namespace("default").Name(name).UID(name).
+			Labels(map[string]string{wellknown.LabelPlaceholderJobId: jobId}).
+			Annotations(map[string]string{wellknown.AnnotationPlaceholderNode: node}).Obj()
+	}
+	pod1 := makePod("pod1", "1", "node1")
+	pod2 := makePod("pod2", "1", "node2")
+	single := makePod("single", "2", "node1")
+	noJob := st.MakePod().Namespace("default").Name("nojob").UID("nojob").Obj()
+	assignedPod2 := pod2.DeepCopy()
+	assignedPod2.Spec.NodeName = "node2"
+	// The pod lister has not yet seen the node assigned to pod2 by PreFilter
+	unannotatedPod2 := pod2.DeepCopy()
+	unannotatedPod2.Annotations = nil
+	succeededPod2 := pod2.DeepCopy()
+	succeededPod2.Status.Phase = corev1.PodSucceeded
+	nodes := []*corev1.Node{st.MakeNode().Name("node1").Obj(), st.MakeNode().Name("node2").Obj()}
+	makeJob := func(jobId int32, pods ...string) types.V0043JobInfo {
+		phInfo := placeholderinfo.PlaceholderInfo{Pods: pods}
+		return types.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{
+			AdminComment: ptr.To(phInfo.ToString()),
+			JobId:        ptr.To(jobId),
+			Nodes:        ptr.To("node[1-2]"),
+		}}
+	}
+	jobs := &types.V0043JobInfoList{
+		Items: []types.V0043JobInfo{
+			makeJob(1, "default/pod1", "default/pod2"),
+			makeJob(2, "default/single"),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		listed   []runtime.Object
+		assigned []*corev1.Pod
+		want     fwk.Code
+	}{
+		{
+			name: "Pod without placeholder job",
+			pod:  noJob,
+			want: fwk.Success,
+		},
+		{
+			name: "Single pod placeholder job",
+			pod:  single,
+			want: fwk.Success,
+		},
+		{
+			name: "Waiting for other pods of placeholder job",
+			pod:  pod1,
+			want: fwk.Wait,
+		},
+		{
+			name:   "Waiting for pods whose nodes the lister has not seen",
+			pod:    pod1,
+			listed: []runtime.Object{pod1, unannotatedPod2},
+			want:   fwk.Wait,
+		},
+		{
+			name:   "Ended pods of placeholder job are not waited for",
+			pod:    pod1,
+			listed: []runtime.Object{pod1, succeededPod2},
+			want:   fwk.Success,
+		},
+		{
+			name:     "All pods of placeholder job assigned",
+			pod:      pod1,
+			assigned: []*corev1.Pod{assignedPod2},
+			want:     fwk.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			listed := tt.listed
+			if listed == nil {
+				listed = []runtime.Object{pod1, pod2, single, noJob}
+			}
+			cs := clientsetfake.NewSimpleClientset(listed...)
+			informerFactory := informers.NewSharedInformerFactory(cs, 0)
+			registeredPlugins := []tf.RegisterPluginFunc{
+				tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+				tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+			}
+			f, err := tf.NewFramework(ctx, registeredPlugins, "slurm-bridge",
+				fwkruntime.WithInformerFactory(informerFactory),
+				fwkruntime.WithSnapshotSharedLister(internalcache.NewSnapshot(tt.assigned, nodes)),
+				fwkruntime.WithWaitingPods(fwkruntime.NewWaitingPodsMap()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = informerFactory.Core().V1().Pods().Lister()
+			informerFactory.Start(ctx.Done())
+			informerFactory.WaitForCacheSync(ctx.Done())
+
+			slurmClient := fake.NewClientBuilder().WithLists(jobs).Build()
+			sb := &SlurmBridge{
+				slurmControl:  slurmcontrol.NewControl(slurmClient, "kubernetes", "slurm-bridge", "", false),
+				handle:        f,
+				permitTimeout: defaultPermitTimeout,
+			}
+			got, timeout := sb.Permit(ctx, nil, tt.pod, tt.pod.Annotations[wellknown.AnnotationPlaceholderNode])
+			if got.Code() != tt.want {
+				t.Errorf("SlurmBridge.Permit() = %v, want %v", got.Code(), tt.want)
+			}
+			if tt.want == fwk.Wait && timeout != defaultPermitTimeout {
+				t.Errorf("SlurmBridge.Permit() timeout = %v, want %v", timeout, defaultPermitTimeout)
+			}
+		})
+	}
+}
+
+func TestSlurmBridge_Unreserve(t *testing.T) {
+	makePod := func(name, jobId string) *corev1.Pod {
+		return st.MakePod().Namespace("default").Name(name).UID(name).
+			Labels(map[string]string{wellknown.LabelPlaceholderJobId: jobId}).
+			Annotations(map[string]string{wellknown.AnnotationPlaceholderNode: "node1"}).Obj()
+	}
+	pod1 := makePod("pod1", "1")
+	pod2 := makePod("pod2", "1")
+	single := makePod("single", "2")
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		// reverted is true if the placeholder job was already reverted by
+		// another pod of the gang, which removed its label from the pods.
+		reverted    bool
+		wantDeleted bool
+	}{
+		{
+			name:        "Single pod placeholder job is kept",
+			pod:         single,
+			wantDeleted: false,
+		},
+		{
+			name:        "Placeholder job of gang is reverted",
+			pod:         pod1,
+			wantDeleted: true,
+		},
+		{
+			name:        "Placeholder job of gang was already reverted",
+			pod:         pod1,
+			reverted:    true,
+			wantDeleted: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cs := clientsetfake.NewSimpleClientset(pod1, pod2, single)
+			informerFactory := informers.NewSharedInformerFactory(cs, 0)
+			registeredPlugins := []tf.RegisterPluginFunc{
+				tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+				tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+			}
+			f, err := tf.NewFramework(ctx, registeredPlugins, "slurm-bridge",
+				fwkruntime.WithInformerFactory(informerFactory),
+				fwkruntime.WithWaitingPods(fwkruntime.NewWaitingPodsMap()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = informerFactory.Core().V1().Pods().Lister()
+			informerFactory.Start(ctx.Done())
+			informerFactory.WaitForCacheSync(ctx.Done())
+
+			jobId := slurmjobir.ParseSlurmJobId(tt.pod.Labels[wellknown.LabelPlaceholderJobId])
+			slurmClient := fake.NewClientBuilder().
+				WithLists(&types.V0043JobInfoList{
+					Items: []types.V0043JobInfo{
+						{V0043JobInfo: v0043.V0043JobInfo{JobId: ptr.To(jobId)}},
+					},
+				}).
+				Build()
+			current := tt.pod.DeepCopy()
+			if tt.reverted {
+				delete(current.Labels, wellknown.LabelPlaceholderJobId)
+			}
+			sb := &SlurmBridge{
+				Client:       kubefake.NewFakeClient(current, pod2.DeepCopy()),
+				slurmControl: slurmcontrol.NewControl(slurmClient, "kubernetes", "slurm-bridge", "", false),
+				handle:       f,
+			}
+			sb.Unreserve(ctx, nil, tt.pod.DeepCopy(), "node1")
+
+			job := &types.V0043JobInfo{}
+			err = slurmClient.Get(ctx, object.ObjectKey(strconv.Itoa(int(jobId))), job)
+			if gotDeleted := err != nil; gotDeleted != tt.wantDeleted {
+				t.Errorf("SlurmBridge.Unreserve() job deleted = %v, want %v", gotDeleted, tt.wantDeleted)
+			}
+		})
+	}
+}
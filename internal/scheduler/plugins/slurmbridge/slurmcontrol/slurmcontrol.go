@@ -4,8 +4,16 @@
 package slurmcontrol
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
@@ -16,20 +24,50 @@ import (
 	"github.com/SlinkyProject/slurm-client/pkg/object"
 	slurmtypes "github.com/SlinkyProject/slurm-client/pkg/types"
 
+	"github.com/SlinkyProject/slurm-bridge/internal/metrics"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils/placeholderinfo"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjobir"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjwt"
 	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+
+	"github.com/puttsk/hostlist"
+)
+
+var (
+	ErrorAttachJobNotFound   = errors.New("slurm job to attach to was not found")
+	ErrorAttachJobNotRunning = errors.New("slurm job to attach to is not running")
+	ErrorAttachJobNotOwned   = errors.New("slurm job to attach to is not owned by the user of the pods")
+	ErrorAttachJobInUse      = errors.New("slurm job to attach to is a placeholder job of other pods")
+	ErrorAttachUserNotPinned = errors.New("pods only attach to slurm jobs when a policy restricts their user")
+
+	ErrorPoolNoIdleAllocation = errors.New("allocation pool has no idle allocation for the pods")
+
+	ErrorImpersonateUserNotPinned = errors.New("placeholder jobs are only submitted as a user which a policy restricts the pods to")
+	ErrorImpersonateRoot          = errors.New("placeholder jobs are not submitted as root")
 )
 
 type PlaceholderJob struct {
 	JobId int32
 	Nodes string
+	// ComponentNodes are the nodes of each component of a heterogeneous job,
+	// in order of their offset.
+	ComponentNodes []string
+	// Attached is true if the pods were attached to the Slurm job, which they
+	// do not own.
+	Attached bool
+	// StateReason is why a pending job is pending (e.g. Priority, Resources).
+	StateReason string
+	// StartTime is when a pending job is expected to start, if known.
+	StartTime time.Time
 }
 
 type SlurmControlInterface interface {
+	AttachJob(ctx context.Context, pod *corev1.Pod, slurmJobIR *slurmjobir.SlurmJobIR, attachedPods []string) error
+	ClaimPoolJob(ctx context.Context, pod *corev1.Pod, slurmJobIR *slurmjobir.SlurmJobIR) (int32, error)
 	DeleteJob(ctx context.Context, pod *corev1.Pod) error
 	GetJobsForPods(ctx context.Context) (*map[string]PlaceholderJob, error)
 	GetJob(ctx context.Context, pod *corev1.Pod) (*PlaceholderJob, error)
+	GetAttachJob(ctx context.Context, slurmJobIR *slurmjobir.SlurmJobIR) (*PlaceholderJob, error)
 	SubmitJob(ctx context.Context, pod *corev1.Pod, slurmJobIR *slurmjobir.SlurmJobIR) (int32, error)
 	UpdateJob(ctx context.Context, pod *corev1.Pod, slurmJobIR *slurmjobir.SlurmJobIR) (int32, error)
 }
@@ -39,6 +77,10 @@ type realSlurmControl struct {
 	client.Client
 	mcsLabel  string
 	partition string
+	shared    string
+	// impersonate submits placeholder jobs as their user instead of the
+	// user of the token.
+	impersonate bool
 }
 
 // DeleteSlurmJob will delete a placeholder job
@@ -63,20 +105,30 @@ func (r *realSlurmControl) GetJobsForPods(ctx context.Context) (*map[string]Plac
 
 	jobs := &slurmtypes.V0043JobInfoList{}
 
-	err := r.List(ctx, jobs)
+	// Skip the informer cache, which this is the fallback for until it has
+	// synced.
+	err := r.List(ctx, jobs, &client.ListOptions{SkipCache: true})
 	if err != nil {
 		logger.Error(err, "could not list jobs")
 		return nil, err
 	}
+	nodes := make(map[int32]string, len(jobs.Items))
+	for _, j := range jobs.Items {
+		nodes[ptr.Deref(j.JobId, 0)] = ptr.Deref(j.Nodes, "")
+	}
 	podToJob := make(map[string]PlaceholderJob)
 	for _, j := range jobs.Items {
 		phInfo := placeholderinfo.PlaceholderInfo{}
 		if err := placeholderinfo.ParseIntoPlaceholderInfo(j.AdminComment, &phInfo); err == nil {
+			placeholderJob := PlaceholderJob{
+				JobId: *j.JobId,
+				Nodes: *j.Nodes,
+			}
+			if components := HetJobComponents(&j); components != nil {
+				SetComponentNodes(&placeholderJob, components, nodes)
+			}
 			for _, pod := range phInfo.Pods {
-				podToJob[pod] = PlaceholderJob{
-					JobId: *j.JobId,
-					Nodes: *j.Nodes,
-				}
+				podToJob[pod] = placeholderJob
 			}
 		}
 	}
@@ -95,7 +147,8 @@ func (r *realSlurmControl) GetJob(ctx context.Context, pod *corev1.Pod) (*Placeh
 		return &jobOut, nil
 	}
 
-	err := r.Get(ctx, jobId, job)
+	// Skip the informer cache, it may not yet include a newly submitted job.
+	err := r.Get(ctx, jobId, job, &client.GetOptions{SkipCache: true})
 	if err != nil {
 		if err.Error() == http.StatusText(http.StatusNotFound) {
 			return &jobOut, nil
@@ -108,11 +161,338 @@ func (r *realSlurmControl) GetJob(ctx context.Context, pod *corev1.Pod) (*Placeh
 		return &jobOut, nil
 	}
 	logger.V(5).Info("found matching job")
-	jobOut.JobId = *job.JobId
-	jobOut.Nodes = *job.Nodes
+	jobOut.JobId = ptr.Deref(job.JobId, 0)
+	jobOut.Nodes = ptr.Deref(job.Nodes, "")
+	phInfo := placeholderinfo.PlaceholderInfo{}
+	if err := placeholderinfo.ParseIntoPlaceholderInfo(job.AdminComment, &phInfo); err == nil {
+		jobOut.Attached = phInfo.Attached
+	}
+	if components := HetJobComponents(job); components != nil {
+		nodes := map[int32]string{jobOut.JobId: jobOut.Nodes}
+		for _, jobId := range components[1:] {
+			component := &slurmtypes.V0043JobInfo{}
+			key := object.ObjectKey(strconv.Itoa(int(jobId)))
+			if err := r.Get(ctx, key, component, &client.GetOptions{SkipCache: true}); err != nil {
+				logger.Error(err, "could not get heterogeneous job component", "jobId", jobId)
+				return nil, err
+			}
+			nodes[jobId] = ptr.Deref(component.Nodes, "")
+		}
+		SetComponentNodes(&jobOut, components, nodes)
+	}
+	if job.GetStateAsSet().Has(v0043.V0043JobInfoJobStatePENDING) {
+		jobOut.StateReason = ptr.Deref(job.StateReason, "")
+		jobOut.StartTime = toTime(job.StartTime)
+	}
 	return &jobOut, nil
 }
 
+// GetAttachJob gets the running Slurm job which the pods of slurmJobIR attach
+// to. The job must be owned by the user of the pods and must not be the
+// placeholder job of other pods. The user of the pods is only trusted when a
+// policy restricts it, as the pods otherwise choose it themselves.
+func (r *realSlurmControl) GetAttachJob(ctx context.Context, slurmJobIR *slurmjobir.SlurmJobIR) (*PlaceholderJob, error) {
+	logger := klog.FromContext(ctx)
+	if !slurmJobIR.Policy.PinsUser() {
+		return nil, ErrorAttachUserNotPinned
+	}
+	jobId := ptr.Deref(slurmJobIR.JobInfo.AttachJobId, 0)
+	job := &slurmtypes.V0043JobInfo{}
+	key := object.ObjectKey(strconv.Itoa(int(jobId)))
+	if err := r.Get(ctx, key, job, &client.GetOptions{SkipCache: true}); err != nil {
+		if err.Error() == http.StatusText(http.StatusNotFound) {
+			return nil, ErrorAttachJobNotFound
+		}
+		logger.Error(err, "could not get job to attach to", "jobId", jobId)
+		return nil, err
+	}
+	if !job.GetStateAsSet().Has(v0043.V0043JobInfoJobStateRUNNING) {
+		return nil, ErrorAttachJobNotRunning
+	}
+	if !isJobOwner(job, slurmJobIR.JobInfo.UserId) {
+		return nil, ErrorAttachJobNotOwned
+	}
+	if ptr.Deref(job.AdminComment, "") != "" {
+		phInfo := placeholderinfo.PlaceholderInfo{}
+		if err := placeholderinfo.ParseIntoPlaceholderInfo(job.AdminComment, &phInfo); err != nil || !phInfo.Attached {
+			return nil, ErrorAttachJobInUse
+		}
+	}
+	return &PlaceholderJob{
+		JobId: jobId,
+		Nodes: ptr.Deref(job.Nodes, ""),
+	}, nil
+}
+
+// isJobOwner returns true if the job is owned by the user, given by user ID or
+// name. A job has no owner when the user is not known.
+func isJobOwner(job *slurmtypes.V0043JobInfo, user *string) bool {
+	if user == nil || *user == "" {
+		return false
+	}
+	if uid, err := strconv.ParseInt(*user, 10, 32); err == nil {
+		return job.UserId != nil && int64(*job.UserId) == uid
+	}
+	return ptr.Deref(job.UserName, "") == *user
+}
+
+// AttachJob records the pods of slurmJobIR, along with the pods already
+// attached to the job, in the placeholder info of the Slurm job which they
+// attach to. The job is marked as attached, so it is not cancelled when the
+// pods end.
+func (r *realSlurmControl) AttachJob(ctx context.Context, pod *corev1.Pod, slurmJobIR *slurmjobir.SlurmJobIR, attachedPods []string) error {
+	logger := klog.FromContext(ctx)
+	jobId := ptr.Deref(slurmJobIR.JobInfo.AttachJobId, 0)
+	phInfo := placeholderinfo.PlaceholderInfo{
+		Pods:     slices.Clone(attachedPods),
+		Kind:     metrics.WorkloadKind(pod),
+		Attached: true,
+	}
+	for _, p := range slurmJobIR.Pods.Items {
+		if key := p.Namespace + "/" + p.Name; !slices.Contains(phInfo.Pods, key) {
+			phInfo.Pods = append(phInfo.Pods, key)
+		}
+	}
+	job := &slurmtypes.V0043JobInfo{}
+	job.JobId = ptr.To(jobId)
+	req := v0043.V0043JobDescMsg{AdminComment: ptr.To(phInfo.ToString())}
+	if err := r.Update(ctx, job, req); err != nil {
+		logger.Error(err, "could not attach pods to job", "pod", klog.KObj(pod), "jobId", jobId)
+		return err
+	}
+	return nil
+}
+
+// ClaimPoolJob claims an idle allocation of the AllocationPool of slurmJobIR,
+// in the namespace of the pod, with enough nodes for its pods. The pods are
+// recorded in the placeholder info of the allocation, which is returned to the
+// pool once they end. An allocation which was claimed by the same pods before
+// is claimed again.
+func (r *realSlurmControl) ClaimPoolJob(ctx context.Context, pod *corev1.Pod, slurmJobIR *slurmjobir.SlurmJobIR) (int32, error) {
+	logger := klog.FromContext(ctx)
+	pool := pod.Namespace + "/" + ptr.Deref(slurmJobIR.JobInfo.AllocationPool, "")
+	pods := make([]string, 0, len(slurmJobIR.Pods.Items))
+	for _, p := range slurmJobIR.Pods.Items {
+		pods = append(pods, p.Namespace+"/"+p.Name)
+	}
+	tasksPerNode := int(max(ptr.Deref(slurmJobIR.JobInfo.TasksPerNode, 1), 1))
+
+	// Skip the informer cache, as an allocation claimed by other pods may not
+	// be observed yet, and claiming it again would share it between them.
+	jobs := &slurmtypes.V0043JobInfoList{}
+	if err := r.List(ctx, jobs, &client.ListOptions{SkipCache: true}); err != nil {
+		logger.Error(err, "could not list jobs")
+		return 0, err
+	}
+	// Prefer the allocation which the pods claimed before, if any, such that
+	// a retried claim does not take a second allocation. Otherwise prefer the
+	// smallest allocation, leaving larger ones to larger workloads.
+	slices.SortFunc(jobs.Items, func(a, b slurmtypes.V0043JobInfo) int {
+		return cmp.Or(
+			claimedPodCount(&b)-claimedPodCount(&a),
+			nodeCount(&a)-nodeCount(&b),
+			cmp.Compare(ptr.Deref(a.JobId, 0), ptr.Deref(b.JobId, 0)),
+		)
+	})
+	for _, j := range jobs.Items {
+		if !isIdlePoolJob(&j, pool, pods) {
+			continue
+		}
+		if nodeCount(&j)*tasksPerNode < len(pods) || !fitsPoolJob(&j, slurmJobIR.JobInfo) {
+			continue
+		}
+		// The listed jobs may be stale, the allocation may have been claimed
+		// or released since.
+		job := &slurmtypes.V0043JobInfo{}
+		key := object.ObjectKey(strconv.Itoa(int(ptr.Deref(j.JobId, 0))))
+		if err := r.Get(ctx, key, job, &client.GetOptions{SkipCache: true}); err != nil {
+			logger.V(4).Info("could not get allocation of pool", "pool", pool, "jobId", key, "err", err)
+			continue
+		}
+		if !isIdlePoolJob(job, pool, pods) {
+			continue
+		}
+		phInfo := placeholderinfo.PlaceholderInfo{
+			Pods:     pods,
+			Kind:     metrics.WorkloadKind(pod),
+			Attached: true,
+			Pool:     pool,
+		}
+		req := v0043.V0043JobDescMsg{AdminComment: ptr.To(phInfo.ToString())}
+		if err := r.Update(ctx, job, req); err != nil {
+			logger.Error(err, "could not claim allocation of pool", "pool", pool, "jobId", key)
+			return 0, err
+		}
+		return ptr.Deref(job.JobId, 0), nil
+	}
+	return 0, ErrorPoolNoIdleAllocation
+}
+
+// isIdlePoolJob returns true if the job is a running allocation of the pool
+// which is not claimed by other pods than the given pods.
+func isIdlePoolJob(job *slurmtypes.V0043JobInfo, pool string, pods []string) bool {
+	if !job.GetStateAsSet().Has(v0043.V0043JobInfoJobStateRUNNING) {
+		return false
+	}
+	phInfo := placeholderinfo.PlaceholderInfo{}
+	if err := placeholderinfo.ParseIntoPlaceholderInfo(job.AdminComment, &phInfo); err != nil || phInfo.Pool != pool {
+		return false
+	}
+	for _, p := range phInfo.Pods {
+		if !slices.Contains(pods, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// fitsPoolJob returns true if the allocation was submitted to the partition,
+// account and QOS which the pods request, and each of its nodes has the CPUs,
+// memory and GRES which the pods need on a node.
+func fitsPoolJob(job *slurmtypes.V0043JobInfo, jobInfo slurmjobir.SlurmJobIRJobInfo) bool {
+	if jobInfo.Partition != nil &&
+		!slices.Contains(strings.Split(*jobInfo.Partition, ","), ptr.Deref(job.Partition, "")) {
+		return false
+	}
+	if jobInfo.Account != nil && *jobInfo.Account != ptr.Deref(job.Account, "") {
+		return false
+	}
+	if jobInfo.QOS != nil && *jobInfo.QOS != ptr.Deref(job.Qos, "") {
+		return false
+	}
+	nodes := int64(nodeCount(job))
+	if nodes == 0 {
+		return false
+	}
+	allocated, err := parseTres(ptr.Deref(job.TresAllocStr, ""))
+	if err != nil {
+		return false
+	}
+	tasksPerNode := int64(max(ptr.Deref(jobInfo.TasksPerNode, 1), 1))
+	needed := map[string]int64{
+		"cpu": int64(ptr.Deref(jobInfo.CpuPerTask, 0)) * tasksPerNode,
+		"mem": ptr.Deref(jobInfo.MemPerNode, 0),
+	}
+	if jobInfo.Gres != nil {
+		gres, err := parseTres(*jobInfo.Gres)
+		if err != nil {
+			return false
+		}
+		maps.Copy(needed, gres)
+	}
+	for tres, count := range needed {
+		if count > allocated[tres]/nodes {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTres parses a comma separated list of TRES (e.g. "cpu=8,mem=16G,
+// gres/gpu=2") into their counts. Memory is counted in megabytes.
+func parseTres(tres string) (map[string]int64, error) {
+	counts := map[string]int64{}
+	if tres == "" {
+		return counts, nil
+	}
+	for _, item := range strings.Split(tres, ",") {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid TRES %q", item)
+		}
+		multiplier := int64(1)
+		if name == "mem" && value != "" {
+			if m, ok := memoryUnits[value[len(value)-1:]]; ok {
+				multiplier = m
+				value = value[:len(value)-1]
+			}
+		}
+		count, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRES %q: %w", item, err)
+		}
+		counts[name] = int64(count * float64(multiplier))
+	}
+	return counts, nil
+}
+
+// memoryUnits are the units of memory in TRES, in megabytes.
+var memoryUnits = map[string]int64{
+	"M": 1,
+	"G": 1024,
+	"T": 1024 * 1024,
+	"P": 1024 * 1024 * 1024,
+}
+
+// nodeCount returns the number of nodes allocated to the job.
+func nodeCount(job *slurmtypes.V0043JobInfo) int {
+	nodes, _ := hostlist.Expand(ptr.Deref(job.Nodes, ""))
+	return len(nodes)
+}
+
+// claimedPodCount returns the number of pods in the placeholder info of the job.
+func claimedPodCount(job *slurmtypes.V0043JobInfo) int {
+	phInfo := placeholderinfo.PlaceholderInfo{}
+	if err := placeholderinfo.ParseIntoPlaceholderInfo(job.AdminComment, &phInfo); err != nil {
+		return 0
+	}
+	return len(phInfo.Pods)
+}
+
+// HetJobComponents returns the job IDs of the components of a heterogeneous
+// job, starting with the leader, or nil if the job is not heterogeneous.
+func HetJobComponents(job *slurmtypes.V0043JobInfo) []int32 {
+	var jobIds []int32
+	for _, part := range strings.Split(ptr.Deref(job.HetJobIdSet, ""), ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.ParseInt(first, 10, 32)
+		if err != nil {
+			return nil
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.ParseInt(last, 10, 32); err != nil {
+				return nil
+			}
+		}
+		for jobId := start; jobId <= end; jobId++ {
+			jobIds = append(jobIds, int32(jobId)) //nolint:gosec // disable G115
+		}
+	}
+	if len(jobIds) < 2 {
+		return nil
+	}
+	return jobIds
+}
+
+// SetComponentNodes sets the nodes of a heterogeneous placeholder job from the
+// nodes of its components, given by job ID. The nodes are only set once every
+// component has nodes allocated, as the components start together.
+func SetComponentNodes(job *PlaceholderJob, components []int32, nodes map[int32]string) {
+	job.Nodes = ""
+	job.ComponentNodes = nil
+	componentNodes := make([]string, 0, len(components))
+	for _, jobId := range components {
+		if nodes[jobId] == "" {
+			return
+		}
+		componentNodes = append(componentNodes, nodes[jobId])
+	}
+	job.Nodes = strings.Join(componentNodes, ",")
+	job.ComponentNodes = componentNodes
+}
+
+// toTime converts a Slurm timestamp, returning the zero time if it is not set.
+func toTime(timestamp *v0043.V0043Uint64NoValStruct) time.Time {
+	if timestamp == nil || !ptr.Deref(timestamp.Set, false) || ptr.Deref(timestamp.Infinite, false) {
+		return time.Time{}
+	}
+	if seconds := ptr.Deref(timestamp.Number, 0); seconds > 0 {
+		return time.Unix(seconds, 0)
+	}
+	return time.Time{}
+}
+
 // SubmitJob submits a placeholder job to Slurm for a node placement decision. The
 // placeholder job is later used to determine which node to bind a k8s pod to.
 func (r *realSlurmControl) SubmitJob(ctx context.Context, pod *corev1.Pod, slurmJobIR *slurmjobir.SlurmJobIR) (int32, error) {
@@ -127,77 +507,81 @@ func (r *realSlurmControl) UpdateJob(ctx context.Context, pod *corev1.Pod, slurm
 // submitJob will create or update a placeholder job Slurm.
 func (r *realSlurmControl) submitJob(ctx context.Context, pod *corev1.Pod, slurmJobIR *slurmjobir.SlurmJobIR, update bool) (int32, error) {
 	logger := klog.FromContext(ctx)
-	phInfo := placeholderinfo.PlaceholderInfo{}
+	phInfo := placeholderinfo.PlaceholderInfo{
+		Kind: metrics.WorkloadKind(pod),
+	}
 	for _, p := range slurmJobIR.Pods.Items {
 		phInfo.Pods = append(phInfo.Pods, p.Namespace+"/"+p.Name)
 	}
 	job := &slurmtypes.V0043JobInfo{}
-	jobSubmit := v0043.V0043JobSubmitReq{
-		Job: &v0043.V0043JobDescMsg{
-			Account:                 slurmJobIR.JobInfo.Account,
-			AdminComment:            ptr.To(phInfo.ToString()),
-			CpusPerTask:             slurmJobIR.JobInfo.CpuPerTask,
-			Constraints:             slurmJobIR.JobInfo.Constraints,
-			CurrentWorkingDirectory: ptr.To("/tmp"),
-			Environment: &v0043.V0043StringArray{
-				"/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin",
-			},
-			Flags: &[]v0043.V0043JobDescMsgFlags{
-				v0043.V0043JobDescMsgFlagsEXTERNALJOB,
-			},
-			GroupId:      slurmJobIR.JobInfo.GroupId,
-			Licenses:     slurmJobIR.JobInfo.Licenses,
-			MaximumNodes: slurmJobIR.JobInfo.MaxNodes,
-			McsLabel:     ptr.To(r.mcsLabel),
-			MemoryPerNode: func() *v0043.V0043Uint64NoValStruct {
-				if slurmJobIR.JobInfo.MemPerNode != nil {
-					return &v0043.V0043Uint64NoValStruct{
-						Infinite: ptr.To(false),
-						Number:   slurmJobIR.JobInfo.MemPerNode,
-						Set:      ptr.To(true),
-					}
-				} else {
-					return &v0043.V0043Uint64NoValStruct{Set: ptr.To(false)}
-				}
-			}(),
-			MinimumNodes: slurmJobIR.JobInfo.MinNodes,
-			Name:         slurmJobIR.JobInfo.JobName,
-			Partition: func() *string {
-				if slurmJobIR.JobInfo.Partition == nil {
-					return &r.partition
-				} else {
-					return slurmJobIR.JobInfo.Partition
-				}
-			}(),
-			Qos:         slurmJobIR.JobInfo.QOS,
-			Reservation: slurmJobIR.JobInfo.Reservation,
-			// SharedNone is effectively Exclusive
-			Shared:       &[]v0043.V0043JobDescMsgShared{v0043.V0043JobDescMsgSharedNone},
-			TasksPerNode: slurmJobIR.JobInfo.TasksPerNode,
-			TimeLimit: func() *v0043.V0043Uint32NoValStruct {
-				if slurmJobIR.JobInfo.TimeLimit != nil {
-					return &v0043.V0043Uint32NoValStruct{
-						Infinite: ptr.To(false),
-						Number:   slurmJobIR.JobInfo.TimeLimit,
-						Set:      ptr.To(true),
-					}
-				} else {
-					return &v0043.V0043Uint32NoValStruct{Set: ptr.To(false)}
-				}
-			}(),
-			TresPerNode: slurmJobIR.JobInfo.Gres,
-			UserId:      slurmJobIR.JobInfo.UserId,
-			Wckey:       slurmJobIR.JobInfo.Wckey,
-		},
+	jobSubmit := v0043.V0043JobSubmitReq{}
+	if len(slurmJobIR.Components) == 0 {
+		jobSubmit.Job = r.jobDesc(slurmJobIR.JobInfo)
+		jobSubmit.Job.AdminComment = ptr.To(phInfo.ToString())
+	} else {
+		// Each component of a heterogeneous job is submitted with its own
+		// description. The admin comment is only set on the leader, which
+		// represents the whole job.
+		jobs := v0043.V0043JobDescMsgList{}
+		for _, c := range slurmJobIR.Components {
+			jobs = append(jobs, *r.jobDesc(c.JobInfo))
+		}
+		jobs[0].AdminComment = ptr.To(phInfo.ToString())
+		jobSubmit.Jobs = &jobs
 	}
 	if !update {
+		// Only the leader of a heterogeneous job runs the batch script.
+		leader := jobSubmit.Job
+		if leader == nil {
+			leader = &(*jobSubmit.Jobs)[0]
+		}
+		leader.Script = ptr.To(placeholderScript)
+		leader.StandardOutput = ptr.To("/dev/null")
+	}
+	if !update && r.impersonate && slurmJobIR.JobInfo.UserId != nil {
+		// Without a policy restricting the user of the pods, the user would be
+		// chosen by the author of the pods.
+		if !slurmJobIR.Policy.PinsUser() {
+			return 0, ErrorImpersonateUserNotPinned
+		}
+		if user := *slurmJobIR.JobInfo.UserId; user == "0" || user == "root" {
+			return 0, ErrorImpersonateRoot
+		}
+		// Only administrators may set the admin comment, so it is set by the
+		// user of the token once the job was submitted as its user.
+		if jobSubmit.Job != nil {
+			jobSubmit.Job.AdminComment = nil
+		} else {
+			(*jobSubmit.Jobs)[0].AdminComment = nil
+		}
+		if err := r.Create(slurmjwt.WithUser(ctx, *slurmJobIR.JobInfo.UserId), job, jobSubmit); err != nil {
+			logger.Error(err, "could not create placeholder job", "pod", klog.KObj(pod), "user", *slurmJobIR.JobInfo.UserId)
+			return 0, err
+		}
+		req := v0043.V0043JobDescMsg{AdminComment: ptr.To(phInfo.ToString())}
+		if err := r.Update(ctx, job, req); err != nil {
+			logger.Error(err, "could not update placeholder job", "pod", klog.KObj(pod))
+			// Without its placeholder info the job is never found again, so
+			// it is cancelled rather than left holding its resources.
+			if err := r.Delete(ctx, job); err != nil {
+				logger.Error(err, "could not cancel placeholder job", "pod", klog.KObj(pod), "jobId", ptr.Deref(job.JobId, 0))
+			}
+			return 0, err
+		}
+	} else if !update {
 		if err := r.Create(ctx, job, jobSubmit); err != nil {
 			logger.Error(err, "could not create placeholder job", "pod", klog.KObj(pod))
 			return 0, err
 		}
 	} else {
 		job.JobId = ptr.To(slurmjobir.ParseSlurmJobId(pod.Labels[wellknown.LabelPlaceholderJobId]))
-		if err := r.Update(ctx, job, *jobSubmit.Job); err != nil {
+		// The components of a heterogeneous job are not updated, only the
+		// pods it represents.
+		req := v0043.V0043JobDescMsg{AdminComment: ptr.To(phInfo.ToString())}
+		if jobSubmit.Job != nil {
+			req = *jobSubmit.Job
+		}
+		if err := r.Update(ctx, job, req); err != nil {
 			logger.Error(err, "could not update placeholder job", "pod", klog.KObj(pod))
 			return 0, err
 		}
@@ -205,12 +589,117 @@ func (r *realSlurmControl) submitJob(ctx context.Context, pod *corev1.Pod, slurm
 	return ptr.Deref(job.JobId, 0), nil
 }
 
+// placeholderScript is the batch script of a placeholder job. It holds the
+// allocation until the pods of the job end, then exits with their outcome so
+// Slurm accounts the job as COMPLETED or FAILED instead of CANCELLED. The pod
+// controller signals the batch step with wellknown.SignalPodsSucceeded or
+// wellknown.SignalPodsFailed, having recorded the exit code of the failed pod
+// as the comment of the job.
+const placeholderScript = `#!/bin/sh
+exit_failed() {
+	code=$(scontrol show job "$SLURM_JOB_ID" 2>/dev/null |
+		sed -n 's/.*Comment=Failed: exit code \([0-9]*\).*/\1/p')
+	exit "${code:-1}"
+}
+trap 'exit 0' USR1
+trap 'exit_failed' USR2
+while :; do
+	sleep 3600 &
+	wait $!
+done
+`
+
+// jobDesc returns the description of a placeholder job, or of a component of a
+// heterogeneous placeholder job.
+func (r *realSlurmControl) jobDesc(jobInfo slurmjobir.SlurmJobIRJobInfo) *v0043.V0043JobDescMsg {
+	return &v0043.V0043JobDescMsg{
+		Account:                 jobInfo.Account,
+		CpusPerTask:             jobInfo.CpuPerTask,
+		Constraints:             jobInfo.Constraints,
+		CurrentWorkingDirectory: ptr.To("/tmp"),
+		Environment: &v0043.V0043StringArray{
+			"/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin",
+		},
+		ExcludedNodes: toCsvString(jobInfo.ExcludeNodes),
+		GroupId:       jobInfo.GroupId,
+		Licenses:      jobInfo.Licenses,
+		MaximumNodes:  jobInfo.MaxNodes,
+		McsLabel:      ptr.To(r.mcsLabel),
+		MemoryPerNode: func() *v0043.V0043Uint64NoValStruct {
+			if jobInfo.MemPerNode != nil {
+				return &v0043.V0043Uint64NoValStruct{
+					Infinite: ptr.To(false),
+					Number:   jobInfo.MemPerNode,
+					Set:      ptr.To(true),
+				}
+			} else {
+				return &v0043.V0043Uint64NoValStruct{Set: ptr.To(false)}
+			}
+		}(),
+		MinimumNodes: jobInfo.MinNodes,
+		Name:         jobInfo.JobName,
+		Partition: func() *string {
+			if jobInfo.Partition == nil {
+				return &r.partition
+			} else {
+				return jobInfo.Partition
+			}
+		}(),
+		Qos:           jobInfo.QOS,
+		RequiredNodes: toCsvString(jobInfo.NodeList),
+		Reservation:   jobInfo.Reservation,
+		Shared:        toJobDescShared(ptr.Deref(jobInfo.Shared, r.shared)),
+		Tasks:         jobInfo.Tasks,
+		TasksPerNode:  jobInfo.TasksPerNode,
+		TimeLimit: func() *v0043.V0043Uint32NoValStruct {
+			if jobInfo.TimeLimit != nil {
+				return &v0043.V0043Uint32NoValStruct{
+					Infinite: ptr.To(false),
+					Number:   jobInfo.TimeLimit,
+					Set:      ptr.To(true),
+				}
+			} else {
+				return &v0043.V0043Uint32NoValStruct{Set: ptr.To(false)}
+			}
+		}(),
+		TresPerNode: jobInfo.Gres,
+		UserId:      jobInfo.UserId,
+		Wckey:       jobInfo.Wckey,
+	}
+}
+
+// toJobDescShared translates a sharing mode into the Slurm job description
+// equivalent. Unknown or unset modes default to exclusive allocations.
+func toJobDescShared(shared string) *[]v0043.V0043JobDescMsgShared {
+	switch shared {
+	case slurmjobir.SharedOversubscribe:
+		return &[]v0043.V0043JobDescMsgShared{v0043.V0043JobDescMsgSharedOversubscribe}
+	case slurmjobir.SharedUser:
+		return &[]v0043.V0043JobDescMsgShared{v0043.V0043JobDescMsgSharedUser}
+	case slurmjobir.SharedMcs:
+		return &[]v0043.V0043JobDescMsgShared{v0043.V0043JobDescMsgSharedMcs}
+	default:
+		// SharedNone is effectively Exclusive
+		return &[]v0043.V0043JobDescMsgShared{v0043.V0043JobDescMsgSharedNone}
+	}
+}
+
+// toCsvString splits a comma separated list, as used by the Slurm CLI.
+func toCsvString(list *string) *v0043.V0043CsvString {
+	if list == nil || *list == "" {
+		return nil
+	}
+	return ptr.To(strings.Split(*list, ","))
+}
+
 var _ SlurmControlInterface = &realSlurmControl{}
 
-func NewControl(client client.Client, mcsLabel string, partition string) SlurmControlInterface {
+func NewControl(client client.Client, mcsLabel string, partition string, shared string, impersonate bool) SlurmControlInterface {
 	return &realSlurmControl{
-		Client:    client,
-		mcsLabel:  mcsLabel,
-		partition: partition,
+		Client:      client,
+		mcsLabel:    mcsLabel,
+		partition:   partition,
+		shared:      shared,
+		impersonate: impersonate,
 	}
 }
@@ -5,10 +5,15 @@ package slurmcontrol
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"testing"
+	"time"
 
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/metrics"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils/placeholderinfo"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils/slurmjobir"
 	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
@@ -169,6 +174,29 @@ func Test_realSlurmControl_GetJobsForPods(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "List jobs skips the cache",
+			fields: fields{
+				Client: func() client.Client {
+					f := interceptor.Funcs{
+						List: func(ctx context.Context, list object.ObjectList, opts ...client.ListOption) error {
+							if !(&client.ListOptions{}).ApplyOptions(opts).SkipCache {
+								return fmt.Errorf("listed from cache")
+							}
+							return nil
+						},
+					}
+					return fake.NewClientBuilder().
+						WithInterceptorFuncs(f).
+						Build()
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+			},
+			want:    &map[string]PlaceholderJob{},
+			wantErr: false,
+		},
 		{
 			name: "List jobs",
 			fields: fields{
@@ -348,6 +376,80 @@ func Test_realSlurmControl_GetJob(t *testing.T) {
 			want:    &PlaceholderJob{JobId: 1, Nodes: "node1"},
 			wantErr: false,
 		},
+		{
+			name: "Attached job found and running",
+			fields: fields{
+				Client: func() client.Client {
+					list := &slurmtypes.V0043JobInfoList{
+						Items: []slurmtypes.V0043JobInfo{
+							{V0043JobInfo: v0043.V0043JobInfo{
+								AdminComment: func() *string {
+									pi := placeholderinfo.PlaceholderInfo{
+										Pods:     []string{"slurm/foo"},
+										Attached: true,
+									}
+									return ptr.To(pi.ToString())
+								}(),
+								JobId:    ptr.To[int32](1),
+								JobState: &[]v0043.V0043JobInfoJobState{v0043.V0043JobInfoJobStateRUNNING},
+								Nodes:    ptr.To("node1"),
+							}},
+						},
+					}
+					return fake.NewClientBuilder().
+						WithLists(list).
+						Build()
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				pod: st.MakePod().Name("foo").Namespace("slurm-bridge").Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).Obj(),
+			},
+			want:    &PlaceholderJob{JobId: 1, Nodes: "node1", Attached: true},
+			wantErr: false,
+		},
+		{
+			name: "Heterogeneous job found and running",
+			fields: fields{
+				Client: func() client.Client {
+					list := &slurmtypes.V0043JobInfoList{
+						Items: []slurmtypes.V0043JobInfo{
+							{V0043JobInfo: v0043.V0043JobInfo{
+								AdminComment: func() *string {
+									pi := placeholderinfo.PlaceholderInfo{
+										Pods: []string{"slurm/foo", "slurm/bar"},
+									}
+									return ptr.To(pi.ToString())
+								}(),
+								HetJobIdSet: ptr.To("1-2"),
+								JobId:       ptr.To[int32](1),
+								JobState:    &[]v0043.V0043JobInfoJobState{v0043.V0043JobInfoJobStateRUNNING},
+								Nodes:       ptr.To("node1"),
+							}},
+							{V0043JobInfo: v0043.V0043JobInfo{
+								HetJobIdSet: ptr.To("1-2"),
+								JobId:       ptr.To[int32](2),
+								JobState:    &[]v0043.V0043JobInfoJobState{v0043.V0043JobInfoJobStateRUNNING},
+								Nodes:       ptr.To("node[2-3]"),
+							}},
+						},
+					}
+					return fake.NewClientBuilder().
+						WithLists(list).
+						Build()
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				pod: st.MakePod().Name("foo").Namespace("slurm-bridge").Labels(map[string]string{wellknown.LabelPlaceholderJobId: "1"}).Obj(),
+			},
+			want: &PlaceholderJob{
+				JobId:          1,
+				Nodes:          "node1,node[2-3]",
+				ComponentNodes: []string{"node1", "node[2-3]"},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -368,9 +470,11 @@ func Test_realSlurmControl_GetJob(t *testing.T) {
 }
 
 func Test_realSlurmControl_SubmitJob(t *testing.T) {
+	var cancelled []int32
 	type fields struct {
-		Client    client.Client
-		partition string
+		Client      client.Client
+		partition   string
+		impersonate bool
 	}
 	type args struct {
 		ctx        context.Context
@@ -378,11 +482,12 @@ func Test_realSlurmControl_SubmitJob(t *testing.T) {
 		slurmJobIR *slurmjobir.SlurmJobIR
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    int32
-		wantErr bool
+		name          string
+		fields        fields
+		args          args
+		want          int32
+		wantErr       bool
+		wantCancelled []int32
 	}{
 		{
 			name: "Could not submit placeholder job",
@@ -412,6 +517,10 @@ func Test_realSlurmControl_SubmitJob(t *testing.T) {
 				Client: func() client.Client {
 					f := interceptor.Funcs{
 						Create: func(ctx context.Context, obj object.Object, req any, opts ...client.CreateOption) error {
+							job := req.(v0043.V0043JobSubmitReq).Job
+							if ptr.Deref(job.Script, "") != placeholderScript || job.Flags != nil {
+								return fmt.Errorf("expected a batch job running the placeholder script")
+							}
 							obj.(*slurmtypes.V0043JobInfo).JobId = ptr.To(int32(1))
 							return nil
 						},
@@ -429,12 +538,167 @@ func Test_realSlurmControl_SubmitJob(t *testing.T) {
 			want:    1,
 			wantErr: false,
 		},
+		{
+			name: "Submit placeholder job as user",
+			fields: fields{
+				Client: func() client.Client {
+					f := interceptor.Funcs{
+						Create: func(ctx context.Context, obj object.Object, req any, opts ...client.CreateOption) error {
+							if req.(v0043.V0043JobSubmitReq).Job.AdminComment != nil {
+								return fmt.Errorf("only administrators may set the admin comment")
+							}
+							obj.(*slurmtypes.V0043JobInfo).JobId = ptr.To(int32(1))
+							return nil
+						},
+						Update: func(ctx context.Context, obj object.Object, req any, opts ...client.UpdateOption) error {
+							if req.(v0043.V0043JobDescMsg).AdminComment == nil {
+								return fmt.Errorf("expected admin comment")
+							}
+							return nil
+						},
+					}
+					return fake.NewClientBuilder().
+						WithInterceptorFuncs(f).
+						Build()
+				}(),
+				impersonate: true,
+			},
+			args: args{
+				ctx: context.Background(),
+				pod: st.MakePod().Name("foo").Namespace("slurm-bridge").Obj(),
+				slurmJobIR: &slurmjobir.SlurmJobIR{
+					JobInfo: slurmjobir.SlurmJobIRJobInfo{
+						UserId: ptr.To("1000"),
+					},
+					Policy: &config.Policy{UserId: config.PolicyRule{Allowed: []string{"1000"}}},
+				},
+			},
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name: "Cancel placeholder job submitted as user if it can not be updated",
+			fields: fields{
+				Client: func() client.Client {
+					f := interceptor.Funcs{
+						Create: func(ctx context.Context, obj object.Object, req any, opts ...client.CreateOption) error {
+							obj.(*slurmtypes.V0043JobInfo).JobId = ptr.To(int32(1))
+							return nil
+						},
+						Update: func(ctx context.Context, obj object.Object, req any, opts ...client.UpdateOption) error {
+							return fmt.Errorf("failed to update resource")
+						},
+						Delete: func(ctx context.Context, obj object.Object, opts ...client.DeleteOption) error {
+							cancelled = append(cancelled, ptr.Deref(obj.(*slurmtypes.V0043JobInfo).JobId, 0))
+							return nil
+						},
+					}
+					return fake.NewClientBuilder().
+						WithInterceptorFuncs(f).
+						Build()
+				}(),
+				impersonate: true,
+			},
+			args: args{
+				ctx: context.Background(),
+				pod: st.MakePod().Name("foo").Namespace("slurm-bridge").Obj(),
+				slurmJobIR: &slurmjobir.SlurmJobIR{
+					JobInfo: slurmjobir.SlurmJobIRJobInfo{
+						UserId: ptr.To("1000"),
+					},
+					Policy: &config.Policy{UserId: config.PolicyRule{Allowed: []string{"1000"}}},
+				},
+			},
+			want:          0,
+			wantErr:       true,
+			wantCancelled: []int32{1},
+		},
+		{
+			name: "Do not submit as user without a policy",
+			fields: fields{
+				Client:      fake.NewClientBuilder().Build(),
+				impersonate: true,
+			},
+			args: args{
+				ctx: context.Background(),
+				pod: st.MakePod().Name("foo").Namespace("slurm-bridge").Obj(),
+				slurmJobIR: &slurmjobir.SlurmJobIR{
+					JobInfo: slurmjobir.SlurmJobIRJobInfo{
+						UserId: ptr.To("1000"),
+					},
+				},
+			},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "Do not submit as root",
+			fields: fields{
+				Client:      fake.NewClientBuilder().Build(),
+				impersonate: true,
+			},
+			args: args{
+				ctx: context.Background(),
+				pod: st.MakePod().Name("foo").Namespace("slurm-bridge").Obj(),
+				slurmJobIR: &slurmjobir.SlurmJobIR{
+					JobInfo: slurmjobir.SlurmJobIRJobInfo{
+						UserId: ptr.To("0"),
+					},
+					Policy: &config.Policy{UserId: config.PolicyRule{Allowed: []string{"0"}}},
+				},
+			},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "Submit heterogeneous placeholder job",
+			fields: fields{
+				Client: func() client.Client {
+					f := interceptor.Funcs{
+						Create: func(ctx context.Context, obj object.Object, req any, opts ...client.CreateOption) error {
+							jobs := req.(v0043.V0043JobSubmitReq).Jobs
+							if jobs == nil || len(*jobs) != 2 {
+								return fmt.Errorf("expected two components")
+							}
+							if (*jobs)[0].AdminComment == nil || (*jobs)[1].AdminComment != nil {
+								return fmt.Errorf("expected admin comment on the leader only")
+							}
+							if (*jobs)[0].Script == nil || (*jobs)[1].Script != nil {
+								return fmt.Errorf("expected batch script on the leader only")
+							}
+							if ptr.Deref((*jobs)[1].TresPerNode, "") != "gres/gpu=8" {
+								return fmt.Errorf("expected gres of the workers")
+							}
+							obj.(*slurmtypes.V0043JobInfo).JobId = ptr.To(int32(1))
+							return nil
+						},
+					}
+					return fake.NewClientBuilder().
+						WithInterceptorFuncs(f).
+						Build()
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				pod: st.MakePod().Name("foo").Namespace("slurm-bridge").Obj(),
+				slurmJobIR: &slurmjobir.SlurmJobIR{
+					Components: []slurmjobir.SlurmJobIRComponent{
+						{JobInfo: slurmjobir.SlurmJobIRJobInfo{MinNodes: ptr.To(int32(1))}},
+						{JobInfo: slurmjobir.SlurmJobIRJobInfo{MinNodes: ptr.To(int32(2)), Gres: ptr.To("gres/gpu=8")}},
+					},
+				},
+			},
+			want:    1,
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			cancelled = nil
 			r := &realSlurmControl{
-				Client:    tt.fields.Client,
-				partition: tt.fields.partition,
+				Client:      tt.fields.Client,
+				partition:   tt.fields.partition,
+				impersonate: tt.fields.impersonate,
 			}
 			got, err := r.SubmitJob(tt.args.ctx, tt.args.pod, tt.args.slurmJobIR)
 			if (err != nil) != tt.wantErr {
@@ -443,15 +707,404 @@ func Test_realSlurmControl_SubmitJob(t *testing.T) {
 			if got != tt.want {
 				t.Errorf("realSlurmControl.SubmitSlurmJob() got= %v, want %v", got, tt.want)
 			}
+			if !slices.Equal(cancelled, tt.wantCancelled) {
+				t.Errorf("realSlurmControl.SubmitSlurmJob() cancelled = %v, want %v", cancelled, tt.wantCancelled)
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_GetAttachJob(t *testing.T) {
+	newJob := func(jobId int32, state v0043.V0043JobInfoJobState, adminComment string) slurmtypes.V0043JobInfo {
+		return slurmtypes.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{
+			AdminComment: ptr.To(adminComment),
+			JobId:        ptr.To(jobId),
+			JobState:     &[]v0043.V0043JobInfoJobState{state},
+			Nodes:        ptr.To("node[1-2]"),
+			UserId:       ptr.To[int32](1000),
+			UserName:     ptr.To("alice"),
+		}}
+	}
+	attached := placeholderinfo.PlaceholderInfo{Pods: []string{"slurm/bar"}, Attached: true}
+	placeholder := placeholderinfo.PlaceholderInfo{Pods: []string{"slurm/bar"}}
+	jobs := &slurmtypes.V0043JobInfoList{
+		Items: []slurmtypes.V0043JobInfo{
+			newJob(1, v0043.V0043JobInfoJobStateRUNNING, ""),
+			newJob(2, v0043.V0043JobInfoJobStatePENDING, ""),
+			newJob(3, v0043.V0043JobInfoJobStateRUNNING, attached.ToString()),
+			newJob(4, v0043.V0043JobInfoJobStateRUNNING, placeholder.ToString()),
+			newJob(5, v0043.V0043JobInfoJobStateRUNNING, "not a placeholder job"),
+		},
+	}
+	newSlurmJobIR := func(jobId int32, userId *string) *slurmjobir.SlurmJobIR {
+		return &slurmjobir.SlurmJobIR{
+			JobInfo: slurmjobir.SlurmJobIRJobInfo{
+				AttachJobId: ptr.To(jobId),
+				UserId:      userId,
+			},
+			Policy: &config.Policy{
+				UserId: config.PolicyRule{Allowed: []string{"1000", "1001", "alice"}},
+			},
+		}
+	}
+	tests := []struct {
+		name       string
+		client     client.Client
+		slurmJobIR *slurmjobir.SlurmJobIR
+		want       *PlaceholderJob
+		wantErr    error
+	}{
+		{
+			name:       "Attach by user ID",
+			client:     fake.NewClientBuilder().WithLists(jobs).Build(),
+			slurmJobIR: newSlurmJobIR(1, ptr.To("1000")),
+			want:       &PlaceholderJob{JobId: 1, Nodes: "node[1-2]"},
+		},
+		{
+			name:       "Attach by user name",
+			client:     fake.NewClientBuilder().WithLists(jobs).Build(),
+			slurmJobIR: newSlurmJobIR(1, ptr.To("alice")),
+			want:       &PlaceholderJob{JobId: 1, Nodes: "node[1-2]"},
+		},
+		{
+			name:       "Attach to job with attached pods",
+			client:     fake.NewClientBuilder().WithLists(jobs).Build(),
+			slurmJobIR: newSlurmJobIR(3, ptr.To("1000")),
+			want:       &PlaceholderJob{JobId: 3, Nodes: "node[1-2]"},
+		},
+		{
+			name:       "Job not found",
+			client:     fake.NewClientBuilder().WithLists(jobs).Build(),
+			slurmJobIR: newSlurmJobIR(6, ptr.To("1000")),
+			wantErr:    ErrorAttachJobNotFound,
+		},
+		{
+			name:       "Job not running",
+			client:     fake.NewClientBuilder().WithLists(jobs).Build(),
+			slurmJobIR: newSlurmJobIR(2, ptr.To("1000")),
+			wantErr:    ErrorAttachJobNotRunning,
+		},
+		{
+			name:       "Job of other user",
+			client:     fake.NewClientBuilder().WithLists(jobs).Build(),
+			slurmJobIR: newSlurmJobIR(1, ptr.To("1001")),
+			wantErr:    ErrorAttachJobNotOwned,
+		},
+		{
+			name:       "No user",
+			client:     fake.NewClientBuilder().WithLists(jobs).Build(),
+			slurmJobIR: newSlurmJobIR(1, nil),
+			wantErr:    ErrorAttachJobNotOwned,
+		},
+		{
+			name:   "User not restricted by a policy",
+			client: fake.NewClientBuilder().WithLists(jobs).Build(),
+			slurmJobIR: func() *slurmjobir.SlurmJobIR {
+				slurmJobIR := newSlurmJobIR(1, ptr.To("1000"))
+				slurmJobIR.Policy = nil
+				return slurmJobIR
+			}(),
+			wantErr: ErrorAttachUserNotPinned,
+		},
+		{
+			name:       "Placeholder job",
+			client:     fake.NewClientBuilder().WithLists(jobs).Build(),
+			slurmJobIR: newSlurmJobIR(4, ptr.To("1000")),
+			wantErr:    ErrorAttachJobInUse,
+		},
+		{
+			name:       "Admin comment in use",
+			client:     fake.NewClientBuilder().WithLists(jobs).Build(),
+			slurmJobIR: newSlurmJobIR(5, ptr.To("1000")),
+			wantErr:    ErrorAttachJobInUse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &realSlurmControl{
+				Client: tt.client,
+			}
+			got, err := r.GetAttachJob(context.Background(), tt.slurmJobIR)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("realSlurmControl.GetAttachJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("realSlurmControl.GetAttachJob() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_AttachJob(t *testing.T) {
+	slurmJobIR := &slurmjobir.SlurmJobIR{
+		Pods: corev1.PodList{
+			Items: []corev1.Pod{
+				*st.MakePod().Name("foo").Namespace("slurm").Obj(),
+			},
+		},
+		JobInfo: slurmjobir.SlurmJobIRJobInfo{
+			AttachJobId: ptr.To[int32](1),
+		},
+	}
+	tests := []struct {
+		name         string
+		attachedPods []string
+		updateErr    error
+		want         *placeholderinfo.PlaceholderInfo
+		wantErr      bool
+	}{
+		{
+			name: "Attach pods",
+			want: &placeholderinfo.PlaceholderInfo{
+				Pods:     []string{"slurm/foo"},
+				Kind:     metrics.KindPod,
+				Attached: true,
+			},
+		},
+		{
+			name:         "Attach pods alongside attached pods",
+			attachedPods: []string{"slurm/bar", "slurm/foo"},
+			want: &placeholderinfo.PlaceholderInfo{
+				Pods:     []string{"slurm/bar", "slurm/foo"},
+				Kind:     metrics.KindPod,
+				Attached: true,
+			},
+		},
+		{
+			name:      "Could not update job",
+			updateErr: fmt.Errorf("failed to update resource"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *placeholderinfo.PlaceholderInfo
+			f := interceptor.Funcs{
+				Update: func(ctx context.Context, obj object.Object, req any, opts ...client.UpdateOption) error {
+					if tt.updateErr != nil {
+						return tt.updateErr
+					}
+					got = &placeholderinfo.PlaceholderInfo{}
+					return placeholderinfo.ParseIntoPlaceholderInfo(req.(v0043.V0043JobDescMsg).AdminComment, got)
+				},
+			}
+			r := &realSlurmControl{
+				Client: fake.NewClientBuilder().WithInterceptorFuncs(f).Build(),
+			}
+			pod := st.MakePod().Name("foo").Namespace("slurm").Obj()
+			err := r.AttachJob(context.Background(), pod, slurmJobIR, tt.attachedPods)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("realSlurmControl.AttachJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("realSlurmControl.AttachJob() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_realSlurmControl_ClaimPoolJob(t *testing.T) {
+	newPoolJob := func(jobId int32, state v0043.V0043JobInfoJobState, nodes, pool string, pods ...string) slurmtypes.V0043JobInfo {
+		phInfo := placeholderinfo.PlaceholderInfo{Pods: pods, Attached: true, Pool: pool}
+		return slurmtypes.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{
+			AdminComment: ptr.To(phInfo.ToString()),
+			JobId:        ptr.To(jobId),
+			JobState:     &[]v0043.V0043JobInfoJobState{state},
+			Nodes:        ptr.To(nodes),
+		}}
+	}
+	newSlurmJobIR := func(pool string, pods ...string) *slurmjobir.SlurmJobIR {
+		slurmJobIR := &slurmjobir.SlurmJobIR{
+			JobInfo: slurmjobir.SlurmJobIRJobInfo{AllocationPool: ptr.To(pool)},
+		}
+		for _, name := range pods {
+			slurmJobIR.Pods.Items = append(slurmJobIR.Pods.Items, *st.MakePod().Name(name).Namespace("slurm").Obj())
+		}
+		return slurmJobIR
+	}
+	newJobList := func() *slurmtypes.V0043JobInfoList {
+		return &slurmtypes.V0043JobInfoList{Items: []slurmtypes.V0043JobInfo{
+			newPoolJob(1, v0043.V0043JobInfoJobStatePENDING, "", "slurm/pool"),
+			newPoolJob(2, v0043.V0043JobInfoJobStateRUNNING, "node1", "slurm/pool", "slurm/bar"),
+			newPoolJob(3, v0043.V0043JobInfoJobStateRUNNING, "node2", "other/pool"),
+			newPoolJob(4, v0043.V0043JobInfoJobStateRUNNING, "node3", "slurm/pool"),
+			newPoolJob(5, v0043.V0043JobInfoJobStateRUNNING, "node[4-5]", "slurm/pool"),
+			func() slurmtypes.V0043JobInfo {
+				job := newPoolJob(6, v0043.V0043JobInfoJobStateRUNNING, "node6", "slurm/gpu")
+				job.Account = ptr.To("team-a")
+				job.Partition = ptr.To("gpu")
+				job.TresAllocStr = ptr.To("cpu=8,mem=32G,node=1,billing=8,gres/gpu=4")
+				return job
+			}(),
+		}}
+	}
+	newGpuSlurmJobIR := func(jobInfo slurmjobir.SlurmJobIRJobInfo) *slurmjobir.SlurmJobIR {
+		slurmJobIR := newSlurmJobIR("gpu", "foo")
+		jobInfo.AllocationPool = slurmJobIR.JobInfo.AllocationPool
+		slurmJobIR.JobInfo = jobInfo
+		return slurmJobIR
+	}
+	gpuJobInfo := slurmjobir.SlurmJobIRJobInfo{
+		Account:    ptr.To("team-a"),
+		CpuPerTask: ptr.To[int32](4),
+		Gres:       ptr.To("gres/gpu=4"),
+		MemPerNode: ptr.To[int64](16384),
+		Partition:  ptr.To("cpu,gpu"),
+	}
+	tests := []struct {
+		name       string
+		slurmJobIR *slurmjobir.SlurmJobIR
+		want       int32
+		wantErr    error
+	}{
+		{
+			name:       "Claim idle allocation",
+			slurmJobIR: newSlurmJobIR("pool", "foo"),
+			want:       4,
+		},
+		{
+			name:       "Claim allocation with enough nodes",
+			slurmJobIR: newSlurmJobIR("pool", "foo", "baz"),
+			want:       5,
+		},
+		{
+			name:       "Claim allocation of the same pods again",
+			slurmJobIR: newSlurmJobIR("pool", "bar"),
+			want:       2,
+		},
+		{
+			name:       "No idle allocation",
+			slurmJobIR: newSlurmJobIR("pool", "foo", "bar", "baz"),
+			wantErr:    ErrorPoolNoIdleAllocation,
+		},
+		{
+			name:       "Unknown pool",
+			slurmJobIR: newSlurmJobIR("foo", "foo"),
+			wantErr:    ErrorPoolNoIdleAllocation,
+		},
+		{
+			name:       "Claim allocation which fits the pods",
+			slurmJobIR: newGpuSlurmJobIR(gpuJobInfo),
+			want:       6,
+		},
+		{
+			name: "Allocation has not enough CPUs",
+			slurmJobIR: func() *slurmjobir.SlurmJobIR {
+				jobInfo := gpuJobInfo
+				jobInfo.CpuPerTask = ptr.To[int32](16)
+				return newGpuSlurmJobIR(jobInfo)
+			}(),
+			wantErr: ErrorPoolNoIdleAllocation,
+		},
+		{
+			name: "Allocation has not enough memory",
+			slurmJobIR: func() *slurmjobir.SlurmJobIR {
+				jobInfo := gpuJobInfo
+				jobInfo.MemPerNode = ptr.To[int64](65536)
+				return newGpuSlurmJobIR(jobInfo)
+			}(),
+			wantErr: ErrorPoolNoIdleAllocation,
+		},
+		{
+			name: "Allocation has not enough GRES",
+			slurmJobIR: func() *slurmjobir.SlurmJobIR {
+				jobInfo := gpuJobInfo
+				jobInfo.Gres = ptr.To("gres/gpu=8")
+				return newGpuSlurmJobIR(jobInfo)
+			}(),
+			wantErr: ErrorPoolNoIdleAllocation,
+		},
+		{
+			name: "Allocation is of another partition",
+			slurmJobIR: func() *slurmjobir.SlurmJobIR {
+				jobInfo := gpuJobInfo
+				jobInfo.Partition = ptr.To("cpu")
+				return newGpuSlurmJobIR(jobInfo)
+			}(),
+			wantErr: ErrorPoolNoIdleAllocation,
+		},
+		{
+			name: "Allocation is of another account",
+			slurmJobIR: func() *slurmjobir.SlurmJobIR {
+				jobInfo := gpuJobInfo
+				jobInfo.Account = ptr.To("team-b")
+				return newGpuSlurmJobIR(jobInfo)
+			}(),
+			wantErr: ErrorPoolNoIdleAllocation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &realSlurmControl{
+				Client: fake.NewClientBuilder().WithLists(newJobList()).Build(),
+			}
+			pod := st.MakePod().Name("foo").Namespace("slurm").Obj()
+			got, err := r.ClaimPoolJob(context.Background(), pod, tt.slurmJobIR)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("realSlurmControl.ClaimPoolJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("realSlurmControl.ClaimPoolJob() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseTres(t *testing.T) {
+	tests := []struct {
+		name    string
+		tres    string
+		want    map[string]int64
+		wantErr bool
+	}{
+		{
+			name: "Empty",
+			tres: "",
+			want: map[string]int64{},
+		},
+		{
+			name: "Allocated TRES",
+			tres: "cpu=8,mem=1.5G,node=2,billing=8,gres/gpu=4,gres/gpu:a100=4",
+			want: map[string]int64{
+				"cpu":           8,
+				"mem":           1536,
+				"node":          2,
+				"billing":       8,
+				"gres/gpu":      4,
+				"gres/gpu:a100": 4,
+			},
+		},
+		{
+			name: "Memory in megabytes",
+			tres: "mem=512M",
+			want: map[string]int64{"mem": 512},
+		},
+		{
+			name:    "Invalid",
+			tres:    "gpu:2",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTres(tt.tres)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTres() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTres() = %v, want %v", got, tt.want)
+			}
 		})
 	}
 }
 
 func TestNewControl(t *testing.T) {
 	type args struct {
-		client    client.Client
-		mcsLabel  string
-		partition string
+		client      client.Client
+		mcsLabel    string
+		partition   string
+		shared      string
+		impersonate bool
 	}
 	tests := []struct {
 		name string
@@ -464,19 +1117,208 @@ func TestNewControl(t *testing.T) {
 				client:    fake.NewFakeClient(),
 				mcsLabel:  "kubernetes",
 				partition: "slurm-bridge",
+				shared:    "user",
 			},
 			want: &realSlurmControl{
 				Client:    fake.NewFakeClient(),
 				mcsLabel:  "kubernetes",
 				partition: "slurm-bridge",
+				shared:    "user",
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewControl(tt.args.client, tt.args.mcsLabel, tt.args.partition); !reflect.DeepEqual(got, tt.want) {
+			if got := NewControl(tt.args.client, tt.args.mcsLabel, tt.args.partition, tt.args.shared, tt.args.impersonate); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewControl() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_toJobDescShared(t *testing.T) {
+	tests := []struct {
+		name   string
+		shared string
+		want   *[]v0043.V0043JobDescMsgShared
+	}{
+		{
+			name:   "Default is exclusive",
+			shared: "",
+			want:   &[]v0043.V0043JobDescMsgShared{v0043.V0043JobDescMsgSharedNone},
+		},
+		{
+			name:   "Exclusive",
+			shared: "exclusive",
+			want:   &[]v0043.V0043JobDescMsgShared{v0043.V0043JobDescMsgSharedNone},
+		},
+		{
+			name:   "Oversubscribe",
+			shared: "oversubscribe",
+			want:   &[]v0043.V0043JobDescMsgShared{v0043.V0043JobDescMsgSharedOversubscribe},
+		},
+		{
+			name:   "User",
+			shared: "user",
+			want:   &[]v0043.V0043JobDescMsgShared{v0043.V0043JobDescMsgSharedUser},
+		},
+		{
+			name:   "MCS",
+			shared: "mcs",
+			want:   &[]v0043.V0043JobDescMsgShared{v0043.V0043JobDescMsgSharedMcs},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toJobDescShared(tt.shared); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toJobDescShared() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_toCsvString(t *testing.T) {
+	tests := []struct {
+		name string
+		list *string
+		want *v0043.V0043CsvString
+	}{
+		{
+			name: "Nil",
+			list: nil,
+			want: nil,
+		},
+		{
+			name: "Empty",
+			list: ptr.To(""),
+			want: nil,
+		},
+		{
+			name: "List",
+			list: ptr.To("node1,node2"),
+			want: &v0043.V0043CsvString{"node1", "node2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toCsvString(tt.list); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toCsvString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_toTime(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp *v0043.V0043Uint64NoValStruct
+		want      time.Time
+	}{
+		{
+			name:      "Nil",
+			timestamp: nil,
+			want:      time.Time{},
+		},
+		{
+			name:      "Not set",
+			timestamp: &v0043.V0043Uint64NoValStruct{Set: ptr.To(false), Number: ptr.To(int64(1700000000))},
+			want:      time.Time{},
+		},
+		{
+			name:      "Infinite",
+			timestamp: &v0043.V0043Uint64NoValStruct{Set: ptr.To(true), Infinite: ptr.To(true)},
+			want:      time.Time{},
+		},
+		{
+			name:      "Zero",
+			timestamp: &v0043.V0043Uint64NoValStruct{Set: ptr.To(true), Number: ptr.To(int64(0))},
+			want:      time.Time{},
+		},
+		{
+			name:      "Set",
+			timestamp: &v0043.V0043Uint64NoValStruct{Set: ptr.To(true), Number: ptr.To(int64(1700000000))},
+			want:      time.Unix(1700000000, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toTime(tt.timestamp); !got.Equal(tt.want) {
+				t.Errorf("toTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHetJobComponents(t *testing.T) {
+	tests := []struct {
+		name        string
+		hetJobIdSet *string
+		want        []int32
+	}{
+		{
+			name:        "Not heterogeneous",
+			hetJobIdSet: nil,
+			want:        nil,
+		},
+		{
+			name:        "Single component",
+			hetJobIdSet: ptr.To("1"),
+			want:        nil,
+		},
+		{
+			name:        "Range",
+			hetJobIdSet: ptr.To("1-3"),
+			want:        []int32{1, 2, 3},
+		},
+		{
+			name:        "List",
+			hetJobIdSet: ptr.To("1,3-4"),
+			want:        []int32{1, 3, 4},
+		},
+		{
+			name:        "Invalid",
+			hetJobIdSet: ptr.To("foo"),
+			want:        nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &slurmtypes.V0043JobInfo{V0043JobInfo: v0043.V0043JobInfo{HetJobIdSet: tt.hetJobIdSet}}
+			if got := HetJobComponents(job); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HetJobComponents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetComponentNodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes map[int32]string
+		want  PlaceholderJob
+	}{
+		{
+			name:  "Component without nodes",
+			nodes: map[int32]string{1: "node1", 2: ""},
+			want:  PlaceholderJob{JobId: 1},
+		},
+		{
+			name:  "All components with nodes",
+			nodes: map[int32]string{1: "node1", 2: "node[2-3]"},
+			want: PlaceholderJob{
+				JobId:          1,
+				Nodes:          "node1,node[2-3]",
+				ComponentNodes: []string{"node1", "node[2-3]"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := PlaceholderJob{JobId: 1, Nodes: "node1"}
+			SetComponentNodes(&job, []int32{1, 2}, tt.nodes)
+			if !reflect.DeepEqual(job, tt.want) {
+				t.Errorf("SetComponentNodes() = %v, want %v", job, tt.want)
+			}
+		})
+	}
+}
@@ -12,6 +12,17 @@ import (
 
 type PlaceholderInfo struct {
 	Pods []string `json:"pods"`
+	// Kind is the kind of workload which the pods belong to.
+	Kind string `json:"kind,omitempty"`
+	// Attached is true if the pods were attached to an existing Slurm job,
+	// which is not cancelled when the pods end.
+	Attached bool `json:"attached,omitempty"`
+	// Pool is the AllocationPool, by namespace and name, which the Slurm job
+	// is an allocation of.
+	Pool string `json:"pool,omitempty"`
+	// SignaledAt is the Unix time at which the Slurm job was sent the
+	// teardown signal, once its pods ended.
+	SignaledAt int64 `json:"signaledAt,omitempty"`
 }
 
 func (phInfo *PlaceholderInfo) Equal(cmp PlaceholderInfo) bool {
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodRunAsUser returns the user which the pod runs as, from the pod's security
+// context or else the first container's, or nil if neither sets it.
+func PodRunAsUser(pod *corev1.Pod) *int64 {
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.RunAsUser != nil {
+		return sc.RunAsUser
+	}
+	if len(pod.Spec.Containers) > 0 {
+		if sc := pod.Spec.Containers[0].SecurityContext; sc != nil {
+			return sc.RunAsUser
+		}
+	}
+	return nil
+}
+
+// PodRunAsGroup returns the group which the pod runs as, from the pod's
+// security context or else the first container's, or nil if neither sets it.
+func PodRunAsGroup(pod *corev1.Pod) *int64 {
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.RunAsGroup != nil {
+		return sc.RunAsGroup
+	}
+	if len(pod.Spec.Containers) > 0 {
+		if sc := pod.Spec.Containers[0].SecurityContext; sc != nil {
+			return sc.RunAsGroup
+		}
+	}
+	return nil
+}
+
+// PodServiceAccountName returns the name of the ServiceAccount which the pod
+// runs as.
+func PodServiceAccountName(pod *corev1.Pod) string {
+	if pod.Spec.ServiceAccountName == "" {
+		return "default"
+	}
+	return pod.Spec.ServiceAccountName
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestPodRunAsUserAndGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		pod       *corev1.Pod
+		wantUser  *int64
+		wantGroup *int64
+	}{
+		{
+			name:      "Unset",
+			pod:       &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{}}}},
+			wantUser:  nil,
+			wantGroup: nil,
+		},
+		{
+			name: "Pod",
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsUser:  ptr.To[int64](1000),
+						RunAsGroup: ptr.To[int64](100),
+					},
+					Containers: []corev1.Container{
+						{
+							SecurityContext: &corev1.SecurityContext{
+								RunAsUser:  ptr.To[int64](2000),
+								RunAsGroup: ptr.To[int64](200),
+							},
+						},
+					},
+				},
+			},
+			wantUser:  ptr.To[int64](1000),
+			wantGroup: ptr.To[int64](100),
+		},
+		{
+			name: "Container",
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsGroup: ptr.To[int64](100),
+					},
+					Containers: []corev1.Container{
+						{
+							SecurityContext: &corev1.SecurityContext{
+								RunAsUser: ptr.To[int64](2000),
+							},
+						},
+					},
+				},
+			},
+			wantUser:  ptr.To[int64](2000),
+			wantGroup: ptr.To[int64](100),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PodRunAsUser(tt.pod); ptr.Deref(got, -1) != ptr.Deref(tt.wantUser, -1) {
+				t.Errorf("PodRunAsUser() = %v, want %v", ptr.Deref(got, -1), ptr.Deref(tt.wantUser, -1))
+			}
+			if got := PodRunAsGroup(tt.pod); ptr.Deref(got, -1) != ptr.Deref(tt.wantGroup, -1) {
+				t.Errorf("PodRunAsGroup() = %v, want %v", ptr.Deref(got, -1), ptr.Deref(tt.wantGroup, -1))
+			}
+		})
+	}
+}
+
+func TestPodServiceAccountName(t *testing.T) {
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want string
+	}{
+		{
+			name: "Default",
+			pod:  &corev1.Pod{},
+			want: "default",
+		},
+		{
+			name: "ServiceAccount",
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					ServiceAccountName: "trainer",
+				},
+			},
+			want: "trainer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PodServiceAccountName(tt.pod); got != tt.want {
+				t.Errorf("PodServiceAccountName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,7 +4,9 @@
 package slurmjobir
 
 import (
+	"cmp"
 	"errors"
+	"slices"
 	"strconv"
 
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -13,8 +15,34 @@ import (
 var (
 	ErrorInsuffientPods        = errors.New("not enough pending pods to create placeholder job")
 	ErrorPlaceholderJobInvalid = errors.New("not enough pending pods for created placeholder job")
+	ErrorNoMatchingNodes       = errors.New("no bridged node matches the node selection of the pods")
+	ErrorSharedInvalid         = errors.New("invalid sharing mode, expected one of: exclusive, oversubscribe, user, mcs")
+	ErrorTasksPerNodeInvalid   = errors.New("invalid tasks per node, expected a positive integer")
+	ErrorTasksPerNodeConflict  = errors.New("tasks per node can not be combined with min-nodes, max-nodes or cpu-per-task")
 )
 
+// Node sharing modes for the placeholder job.
+const (
+	// SharedExclusive allocates whole nodes to the placeholder job.
+	SharedExclusive = "exclusive"
+	// SharedOversubscribe allows nodes to be shared with other jobs, including
+	// oversubscription of resources if permitted by the partition.
+	SharedOversubscribe = "oversubscribe"
+	// SharedUser allows nodes to be shared with other jobs of the same user.
+	SharedUser = "user"
+	// SharedMcs allows nodes to be shared with other jobs of the same MCS label.
+	SharedMcs = "mcs"
+)
+
+// IsValidShared returns true if the input is a known sharing mode.
+func IsValidShared(input string) bool {
+	switch input {
+	case SharedExclusive, SharedOversubscribe, SharedUser, SharedMcs:
+		return true
+	}
+	return false
+}
+
 func ConvStrTo32(input string) (output *int32, err error) {
 	out, err := strconv.ParseInt(input, 10, 32)
 	if err != nil {
@@ -37,3 +65,29 @@ func GetMemoryFromQuantity(quantity *resource.Quantity) int64 {
 	val := quantity.Value()
 	return val / 1048576 // value for 1024x1024 to follow what we need for slurm job IR
 }
+
+// divideRoundUp returns the quotient of a and b, rounded up.
+func divideRoundUp[T int32 | int64](a, b T) T {
+	return (a + b - 1) / b
+}
+
+// maxQuantity returns the larger of two quantities.
+func maxQuantity(a, b resource.Quantity) resource.Quantity {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// sumLargest returns the sum of the n largest quantities.
+func sumLargest(quantities []resource.Quantity, n int32) resource.Quantity {
+	sorted := slices.Clone(quantities)
+	slices.SortFunc(sorted, func(a, b resource.Quantity) int {
+		return cmp.Compare(0, a.Cmp(b))
+	})
+	var sum resource.Quantity
+	for i := 0; i < len(sorted) && i < int(max(n, 1)); i++ {
+		sum.Add(sorted[i])
+	}
+	return sum
+}
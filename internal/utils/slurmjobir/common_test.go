@@ -116,3 +116,45 @@ func Test_GetMemoryFromQuantity(t *testing.T) {
 		})
 	}
 }
+
+func Test_sumLargest(t *testing.T) {
+	quantities := []resource.Quantity{
+		resource.MustParse("1"),
+		resource.MustParse("4"),
+		resource.MustParse("500m"),
+		resource.MustParse("2"),
+	}
+	tests := []struct {
+		name string
+		n    int32
+		want resource.Quantity
+	}{
+		{
+			name: "Largest",
+			n:    1,
+			want: resource.MustParse("4"),
+		},
+		{
+			name: "Two largest",
+			n:    2,
+			want: resource.MustParse("6"),
+		},
+		{
+			name: "More than available",
+			n:    8,
+			want: resource.MustParse("7500m"),
+		},
+		{
+			name: "Zero defaults to largest",
+			n:    0,
+			want: resource.MustParse("4"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sumLargest(quantities, tt.n); got.Cmp(tt.want) != 0 {
+				t.Errorf("sumLargest() = %v, want %v", got.String(), tt.want.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+// DefaultAnnotations are the annotations which may be defaulted by the
+// configuration or the namespace of a workload.
+var DefaultAnnotations = []string{
+	wellknown.AnnotationAccount,
+	wellknown.AnnotationGroupId,
+	wellknown.AnnotationPartition,
+	wellknown.AnnotationQOS,
+	wellknown.AnnotationReservation,
+	wellknown.AnnotationUserId,
+	wellknown.AnnotationWckey,
+}
+
+// copyDefaults copies the default annotations of src into dst.
+func copyDefaults(dst, src map[string]string) {
+	for key, value := range src {
+		if slices.Contains(DefaultAnnotations, key) {
+			dst[key] = value
+		}
+	}
+}
+
+/*
+Get the annotations of the workload merged over its defaults: the defaults of
+the configuration for all namespaces, then for the namespace, then the
+annotations of the namespace itself.
+*/
+func (t *translator) withDefaults(namespaceName string, anno map[string]string, namespaceDefaults []config.NamespaceDefaults) (map[string]string, error) {
+	merged := map[string]string{}
+	for _, d := range namespaceDefaults {
+		if d.Namespace == "" {
+			copyDefaults(merged, d.Annotations)
+		}
+	}
+	for _, d := range namespaceDefaults {
+		if d.Namespace != "" && d.Namespace == namespaceName {
+			copyDefaults(merged, d.Annotations)
+		}
+	}
+	namespace := &corev1.Namespace{}
+	if err := t.Get(t.ctx, client.ObjectKey{Name: namespaceName}, namespace); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	copyDefaults(merged, namespace.Annotations)
+	maps.Copy(merged, anno)
+	return merged, nil
+}
+
+/*
+Set the user and group of the placeholder job from the security context of the
+pod when they are not set by annotations.
+*/
+func parseSecurityContext(slurmJobIR *SlurmJobIR, pod *corev1.Pod) {
+	if slurmJobIR.JobInfo.UserId == nil {
+		if uid := utils.PodRunAsUser(pod); uid != nil {
+			slurmJobIR.JobInfo.UserId = ptr.To(strconv.FormatInt(*uid, 10))
+		}
+	}
+	if slurmJobIR.JobInfo.GroupId == nil {
+		if gid := utils.PodRunAsGroup(pod); gid != nil {
+			slurmJobIR.JobInfo.GroupId = ptr.To(strconv.FormatInt(*gid, 10))
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+func Test_translator_withDefaults(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "foo"},
+	}
+	namespaceDefaults := []config.NamespaceDefaults{
+		{
+			Namespace: "team-a",
+			Annotations: map[string]string{
+				wellknown.AnnotationAccount: "team-a",
+				wellknown.AnnotationQOS:     "normal",
+			},
+		},
+		{
+			Annotations: map[string]string{
+				wellknown.AnnotationAccount:   "default",
+				wellknown.AnnotationPartition: "slurm-bridge",
+				wellknown.AnnotationWckey:     "default",
+			},
+		},
+		{
+			Namespace: "team-b",
+			Annotations: map[string]string{
+				wellknown.AnnotationAccount: "team-b",
+			},
+		},
+	}
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "team-a",
+			Annotations: map[string]string{
+				wellknown.AnnotationQOS:      "high",
+				wellknown.AnnotationMaxNodes: "10",
+			},
+		},
+	}
+	tests := []struct {
+		name              string
+		client            client.Client
+		anno              map[string]string
+		namespaceDefaults []config.NamespaceDefaults
+		want              map[string]string
+	}{
+		{
+			name:   "No defaults",
+			client: fake.NewFakeClient(),
+			anno: map[string]string{
+				wellknown.AnnotationAccount: "foo",
+			},
+			want: map[string]string{
+				wellknown.AnnotationAccount: "foo",
+			},
+		},
+		{
+			name:              "Config defaults",
+			client:            fake.NewFakeClient(),
+			namespaceDefaults: namespaceDefaults,
+			want: map[string]string{
+				wellknown.AnnotationAccount:   "team-a",
+				wellknown.AnnotationPartition: "slurm-bridge",
+				wellknown.AnnotationQOS:       "normal",
+				wellknown.AnnotationWckey:     "default",
+			},
+		},
+		{
+			name:              "Namespace defaults",
+			client:            fake.NewFakeClient(namespace),
+			namespaceDefaults: namespaceDefaults,
+			want: map[string]string{
+				wellknown.AnnotationAccount:   "team-a",
+				wellknown.AnnotationPartition: "slurm-bridge",
+				wellknown.AnnotationQOS:       "high",
+				wellknown.AnnotationWckey:     "default",
+			},
+		},
+		{
+			name:   "Workload annotations",
+			client: fake.NewFakeClient(namespace),
+			anno: map[string]string{
+				wellknown.AnnotationQOS:   "low",
+				wellknown.AnnotationWckey: "foo",
+			},
+			namespaceDefaults: namespaceDefaults,
+			want: map[string]string{
+				wellknown.AnnotationAccount:   "team-a",
+				wellknown.AnnotationPartition: "slurm-bridge",
+				wellknown.AnnotationQOS:       "low",
+				wellknown.AnnotationWckey:     "foo",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &translator{Reader: tt.client, ctx: context.Background()}
+			got, err := tr.withDefaults(pod.Namespace, tt.anno, tt.namespaceDefaults)
+			if err != nil {
+				t.Fatalf("translator.withDefaults() error = %v", err)
+			}
+			if !apiequality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("translator.withDefaults() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseSecurityContext(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsUser:  ptr.To[int64](1000),
+				RunAsGroup: ptr.To[int64](100),
+			},
+		},
+	}
+	tests := []struct {
+		name    string
+		jobInfo SlurmJobIRJobInfo
+		pod     *corev1.Pod
+		want    SlurmJobIRJobInfo
+	}{
+		{
+			name:    "No security context",
+			jobInfo: SlurmJobIRJobInfo{},
+			pod:     &corev1.Pod{},
+			want:    SlurmJobIRJobInfo{},
+		},
+		{
+			name:    "Security context",
+			jobInfo: SlurmJobIRJobInfo{},
+			pod:     pod,
+			want: SlurmJobIRJobInfo{
+				UserId:  ptr.To("1000"),
+				GroupId: ptr.To("100"),
+			},
+		},
+		{
+			name: "Annotations take precedence",
+			jobInfo: SlurmJobIRJobInfo{
+				UserId: ptr.To("2000"),
+			},
+			pod: pod,
+			want: SlurmJobIRJobInfo{
+				UserId:  ptr.To("2000"),
+				GroupId: ptr.To("100"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slurmJobIR := &SlurmJobIR{JobInfo: tt.jobInfo}
+			parseSecurityContext(slurmJobIR, tt.pod)
+			if !apiequality.Semantic.DeepEqual(slurmJobIR.JobInfo, tt.want) {
+				t.Errorf("parseSecurityContext() = %v, want %v", slurmJobIR.JobInfo, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+)
+
+// SlurmJobIRComponent is a component of a heterogeneous placeholder job, with
+// the pods which are placed on its nodes.
+type SlurmJobIRComponent struct {
+	Pods    corev1.PodList
+	JobInfo SlurmJobIRJobInfo
+}
+
+/*
+Set the job info of each component of a heterogeneous placeholder job. The
+components share the job info of the placeholder job (e.g. account, partition,
+time limit), while the nodes, CPU, memory, and GRES of each component are sized
+to its own pods, with one pod per node.
+*/
+func (t *translator) parseComponents(slurmJobIR *SlurmJobIR, gresMappings []config.GresMapping) error {
+	for i := range slurmJobIR.Components {
+		c := &slurmJobIR.Components[i]
+		jobInfo := slurmJobIR.JobInfo
+		jobInfo.MinNodes = c.JobInfo.MinNodes
+		jobInfo.MaxNodes = c.JobInfo.MaxNodes
+		jobInfo.Tasks = nil
+		jobInfo.TasksPerNode = ptr.To(int32(1))
+		jobInfo.CpuPerTask = nil
+		jobInfo.MemPerNode = nil
+		jobInfo.Gres = nil
+		// Required nodes apply to the whole placeholder job, not to each of
+		// its components.
+		jobInfo.NodeList = nil
+		component := &SlurmJobIR{Pods: c.Pods, JobInfo: jobInfo}
+		parsePodsCpuAndMemory(component)
+		if err := t.parseGres(component, gresMappings); err != nil {
+			return err
+		}
+		c.JobInfo = component.JobInfo
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newComponentPod(cpu, gpu string) corev1.Pod {
+	limits := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+	if gpu != "" {
+		limits["nvidia.com/gpu"] = resource.MustParse(gpu)
+	}
+	return corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Resources: corev1.ResourceRequirements{Limits: limits},
+			}},
+		},
+	}
+}
+
+func Test_translator_parseComponents(t *testing.T) {
+	jobInfo := SlurmJobIRJobInfo{
+		Account:      ptr.To("foo"),
+		CpuPerTask:   ptr.To(int32(4)),
+		Gres:         ptr.To("gres/gpu=8"),
+		MinNodes:     ptr.To(int32(3)),
+		MaxNodes:     ptr.To(int32(3)),
+		NodeList:     ptr.To("node1,node2,node3"),
+		TasksPerNode: ptr.To(int32(1)),
+	}
+	tests := []struct {
+		name       string
+		slurmJobIR *SlurmJobIR
+		want       []SlurmJobIRComponent
+	}{
+		{
+			name:       "Not heterogeneous",
+			slurmJobIR: &SlurmJobIR{JobInfo: jobInfo},
+			want:       nil,
+		},
+		{
+			name: "Leader and workers",
+			slurmJobIR: &SlurmJobIR{
+				JobInfo: jobInfo,
+				Components: []SlurmJobIRComponent{
+					{
+						Pods: corev1.PodList{Items: []corev1.Pod{newComponentPod("1", "")}},
+						JobInfo: SlurmJobIRJobInfo{
+							MinNodes: ptr.To(int32(1)),
+							MaxNodes: ptr.To(int32(1)),
+						},
+					},
+					{
+						Pods: corev1.PodList{Items: []corev1.Pod{
+							newComponentPod("4", "8"),
+							newComponentPod("4", "8"),
+						}},
+						JobInfo: SlurmJobIRJobInfo{
+							MinNodes: ptr.To(int32(2)),
+							MaxNodes: ptr.To(int32(2)),
+						},
+					},
+				},
+			},
+			want: []SlurmJobIRComponent{
+				{
+					Pods: corev1.PodList{Items: []corev1.Pod{newComponentPod("1", "")}},
+					JobInfo: SlurmJobIRJobInfo{
+						Account:      ptr.To("foo"),
+						CpuPerTask:   ptr.To(int32(1)),
+						MinNodes:     ptr.To(int32(1)),
+						MaxNodes:     ptr.To(int32(1)),
+						TasksPerNode: ptr.To(int32(1)),
+					},
+				},
+				{
+					Pods: corev1.PodList{Items: []corev1.Pod{
+						newComponentPod("4", "8"),
+						newComponentPod("4", "8"),
+					}},
+					JobInfo: SlurmJobIRJobInfo{
+						Account:      ptr.To("foo"),
+						CpuPerTask:   ptr.To(int32(4)),
+						Gres:         ptr.To("gres/gpu=8"),
+						MinNodes:     ptr.To(int32(2)),
+						MaxNodes:     ptr.To(int32(2)),
+						TasksPerNode: ptr.To(int32(1)),
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &translator{Reader: fake.NewFakeClient(), ctx: context.Background()}
+			if err := tr.parseComponents(tt.slurmJobIR, nil); err != nil {
+				t.Fatalf("translator.parseComponents() error = %v", err)
+			}
+			if !apiequality.Semantic.DeepEqual(tt.slurmJobIR.Components, tt.want) {
+				t.Errorf("translator.parseComponents() = %v, want %v", tt.slurmJobIR.Components, tt.want)
+			}
+		})
+	}
+}
@@ -4,18 +4,71 @@
 package slurmjobir
 
 import (
+	"errors"
+
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	fwk "k8s.io/kube-scheduler/framework"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 )
 
 var (
 	job_v1 = metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"}
+
+	ErrorJobCouldNotGet = errors.New("could not get job")
 )
 
-// fromJobSet will translate a pod from a Job into a SlurmJobIR.
+// isGang returns true if the pods of the workload are scheduled together.
+func isGang(rootPOM *metav1.PartialObjectMetadata) bool {
+	return rootPOM.GetAnnotations()[wellknown.AnnotationGang] == "true"
+}
+
+// gangSize returns the number of pods of the Job which run at once: its
+// parallelism, bounded by the completions which remain.
+func gangSize(job *batchv1.Job) int32 {
+	size := ptr.Deref(job.Spec.Parallelism, 1)
+	if job.Spec.Completions != nil {
+		size = min(size, *job.Spec.Completions-job.Status.Succeeded)
+	}
+	return max(size, 1)
+}
+
+// PreFilterJob performs Job specific PreFilter functions
+func (t *translator) PreFilterJob(pod *corev1.Pod, slurmJobIR *SlurmJobIR) *fwk.Status {
+	if !isGang(&slurmJobIR.RootPOM) {
+		return fwk.NewStatus(fwk.Success)
+	}
+	job := &batchv1.Job{}
+	key := client.ObjectKey{Namespace: slurmJobIR.RootPOM.GetNamespace(), Name: slurmJobIR.RootPOM.GetName()}
+	if err := t.Get(t.ctx, key, job); err != nil {
+		return fwk.NewStatus(fwk.Error, ErrorJobCouldNotGet.Error())
+	}
+
+	// Ensure there are enough pods for the gang. Don't count pods that may
+	// already have a placeholderjob label.
+	numPodsWaiting := 0
+	for _, p := range slurmJobIR.Pods.Items {
+		if p.Labels[wellknown.LabelPlaceholderJobId] ==
+			pod.Labels[wellknown.LabelPlaceholderJobId] {
+			numPodsWaiting++
+		}
+	}
+	if numPodsWaiting < int(gangSize(job)) {
+		if pod.Labels[wellknown.LabelPlaceholderJobId] == "" {
+			return fwk.NewStatus(fwk.Error, ErrorInsuffientPods.Error())
+		} else {
+			return fwk.NewStatus(fwk.Error, ErrorPlaceholderJobInvalid.Error())
+		}
+	}
+	return fwk.NewStatus(fwk.Success)
+}
+
+// fromJob will translate a pod from a Job into a SlurmJobIR.
 func (t *translator) fromJob(pod *corev1.Pod, rootPOM *metav1.PartialObjectMetadata) (*SlurmJobIR, error) {
 	job := &batchv1.Job{}
 	key := client.ObjectKey{Namespace: rootPOM.GetNamespace(), Name: rootPOM.Name}
@@ -24,8 +77,14 @@ func (t *translator) fromJob(pod *corev1.Pod, rootPOM *metav1.PartialObjectMetad
 	}
 
 	slurmJobIR := &SlurmJobIR{}
-	slurmJobIR.Pods.Items = append(slurmJobIR.Pods.Items, *pod)
-	slurmJobIR.JobInfo.MinNodes = ptr.To(int32(1))
+	if isGang(rootPOM) {
+		if err := t.fromJobGang(job, slurmJobIR); err != nil {
+			return nil, err
+		}
+	} else {
+		slurmJobIR.Pods.Items = append(slurmJobIR.Pods.Items, *pod)
+		slurmJobIR.JobInfo.MinNodes = ptr.To(int32(1))
+	}
 	if job.Spec.Template.Spec.Resources != nil {
 		slurmJobIR.JobInfo.CpuPerTask = ptr.To(int32(job.Spec.Template.Spec.Resources.Limits.Cpu().Value())) //nolint:gosec // disable G115
 		slurmJobIR.JobInfo.MemPerNode = ptr.To(int64(GetMemoryFromQuantity(job.Spec.Template.Spec.Resources.Limits.Memory())))
@@ -33,3 +92,34 @@ func (t *translator) fromJob(pod *corev1.Pod, rootPOM *metav1.PartialObjectMetad
 
 	return slurmJobIR, nil
 }
+
+// fromJobGang will add the active pods of a Job to the SlurmJobIR, with a node
+// for each pod which runs at once. Pods are mapped to the nodes of the
+// placeholder job in the order of their completion index.
+func (t *translator) fromJobGang(job *batchv1.Job, slurmJobIR *SlurmJobIR) error {
+	podList := &corev1.PodList{}
+	if err := t.List(t.ctx, podList, client.InNamespace(job.Namespace),
+		&client.ListOptions{LabelSelector: labels.SelectorFromSet(
+			labels.Set{batchv1.ControllerUidLabel: string(job.UID)},
+		)}); err != nil {
+		return err
+	}
+
+	for _, p := range podList.Items {
+		// Pods of the Job which ended or are being replaced are not part of
+		// the gang.
+		if p.DeletionTimestamp != nil ||
+			p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		slurmJobIR.Pods.Items = append(slurmJobIR.Pods.Items, p)
+	}
+
+	size := gangSize(job)
+	slurmJobIR.JobInfo.JobName = ptr.To(job.Name)
+	slurmJobIR.JobInfo.MinNodes = ptr.To(size)
+	slurmJobIR.JobInfo.MaxNodes = ptr.To(size)
+	slurmJobIR.JobInfo.TasksPerNode = ptr.To(int32(1))
+
+	return nil
+}
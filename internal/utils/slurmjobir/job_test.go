@@ -13,11 +13,15 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	fwk "k8s.io/kube-scheduler/framework"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 )
 
 func newJob(name string) *batchv1.Job {
@@ -53,6 +57,23 @@ func newJobPod(name, jobName string) *corev1.Pod {
 	}
 }
 
+func newGangJob(name string, parallelism, completions int32) *batchv1.Job {
+	job := newJob(name)
+	job.UID = "uid-" + types.UID(name)
+	job.Annotations = map[string]string{wellknown.AnnotationGang: "true"}
+	job.Spec.Parallelism = ptr.To(parallelism)
+	job.Spec.Completions = ptr.To(completions)
+	job.Spec.CompletionMode = ptr.To(batchv1.IndexedCompletion)
+	return job
+}
+
+func newGangJobPod(name string, job *batchv1.Job, phase corev1.PodPhase) *corev1.Pod {
+	pod := newJobPod(name, job.Name)
+	pod.Labels[batchv1.ControllerUidLabel] = string(job.UID)
+	pod.Status.Phase = phase
+	return pod
+}
+
 func Test_translator_fromJob(t *testing.T) {
 	type fields struct {
 		Reader client.Reader
@@ -140,6 +161,60 @@ func Test_translator_fromJob(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Gang Job to SlurmJobIR",
+			fields: fields{
+				Reader: func() client.Reader {
+					scheme := runtime.NewScheme()
+					utilruntime.Must(kubescheme.AddToScheme(scheme))
+					utilruntime.Must(batchv1.AddToScheme(scheme))
+					job := newGangJob("foo", 4, 5)
+					job.Status.Succeeded = 2
+					return fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+						job,
+						newGangJobPod("foo-0", job, corev1.PodSucceeded),
+						newGangJobPod("foo-1", job, corev1.PodPending),
+						newGangJobPod("foo-2", job, corev1.PodPending),
+						newJobPod("bar-0", "bar"),
+					).Build()
+				}(),
+				ctx: context.Background(),
+			},
+			args: args{
+				pod: newJobPod("foo-1", "foo"),
+				rootPOM: &metav1.PartialObjectMetadata{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "foo",
+						Namespace:   metav1.NamespaceDefault,
+						Annotations: map[string]string{wellknown.AnnotationGang: "true"},
+					},
+				},
+			},
+			want: &SlurmJobIR{
+				JobInfo: SlurmJobIRJobInfo{
+					JobName:      ptr.To("foo"),
+					MinNodes:     ptr.To(int32(3)),
+					MaxNodes:     ptr.To(int32(3)),
+					TasksPerNode: ptr.To(int32(1)),
+					CpuPerTask:   ptr.To(int32(22)),
+					MemPerNode:   ptr.To(int64(1)),
+				},
+				Pods: corev1.PodList{
+					Items: func() []corev1.Pod {
+						job := newGangJob("foo", 4, 5)
+						pods := []corev1.Pod{
+							*newGangJobPod("foo-1", job, corev1.PodPending),
+							*newGangJobPod("foo-2", job, corev1.PodPending),
+						}
+						for i := range pods {
+							pods[i].ResourceVersion = "999"
+						}
+						return pods
+					}(),
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -158,3 +233,119 @@ func Test_translator_fromJob(t *testing.T) {
 		})
 	}
 }
+
+func Test_translator_PreFilterJob(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(kubescheme.AddToScheme(scheme))
+	utilruntime.Must(batchv1.AddToScheme(scheme))
+	rootPOM := metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   metav1.NamespaceDefault,
+			Annotations: map[string]string{wellknown.AnnotationGang: "true"},
+		},
+	}
+	job := newGangJob("foo", 2, 2)
+	newPods := func(placeholder ...string) corev1.PodList {
+		pods := corev1.PodList{}
+		for _, p := range placeholder {
+			pod := newGangJobPod("foo", job, corev1.PodPending)
+			if p != "" {
+				pod.Labels[wellknown.LabelPlaceholderJobId] = p
+			}
+			pods.Items = append(pods.Items, *pod)
+		}
+		return pods
+	}
+	type fields struct {
+		Reader client.Reader
+		ctx    context.Context
+	}
+	type args struct {
+		pod        *corev1.Pod
+		slurmJobIR *SlurmJobIR
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   *fwk.Status
+	}{
+		{
+			name: "Not a gang",
+			fields: fields{
+				Reader: fake.NewFakeClient(),
+				ctx:    context.Background(),
+			},
+			args: args{
+				pod:        &corev1.Pod{},
+				slurmJobIR: &SlurmJobIR{},
+			},
+			want: fwk.NewStatus(fwk.Success),
+		},
+		{
+			name: "Fail to get Job",
+			fields: fields{
+				Reader: fake.NewClientBuilder().WithScheme(scheme).Build(),
+				ctx:    context.Background(),
+			},
+			args: args{
+				pod:        &corev1.Pod{},
+				slurmJobIR: &SlurmJobIR{RootPOM: rootPOM},
+			},
+			want: fwk.NewStatus(fwk.Error, ErrorJobCouldNotGet.Error()),
+		},
+		{
+			name: "Not enough pods for gang",
+			fields: fields{
+				Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(job).Build(),
+				ctx:    context.Background(),
+			},
+			args: args{
+				pod:        newGangJobPod("foo", job, corev1.PodPending),
+				slurmJobIR: &SlurmJobIR{RootPOM: rootPOM, Pods: newPods("")},
+			},
+			want: fwk.NewStatus(fwk.Error, ErrorInsuffientPods.Error()),
+		},
+		{
+			name: "Invalid state with placeholder and insufficient pods",
+			fields: fields{
+				Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(job).Build(),
+				ctx:    context.Background(),
+			},
+			args: args{
+				pod: func() *corev1.Pod {
+					pod := newGangJobPod("foo", job, corev1.PodPending)
+					pod.Labels[wellknown.LabelPlaceholderJobId] = "1"
+					return pod
+				}(),
+				slurmJobIR: &SlurmJobIR{RootPOM: rootPOM, Pods: newPods("1", "")},
+			},
+			want: fwk.NewStatus(fwk.Error, ErrorPlaceholderJobInvalid.Error()),
+		},
+		{
+			name: "Enough pods for gang",
+			fields: fields{
+				Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(job).Build(),
+				ctx:    context.Background(),
+			},
+			args: args{
+				pod:        newGangJobPod("foo", job, corev1.PodPending),
+				slurmJobIR: &SlurmJobIR{RootPOM: rootPOM, Pods: newPods("", "")},
+			},
+			want: fwk.NewStatus(fwk.Success),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &translator{
+				Reader: tt.fields.Reader,
+				ctx:    tt.fields.ctx,
+			}
+			got := tr.PreFilterJob(tt.args.pod, tt.args.slurmJobIR)
+			if got.Code() != tt.want.Code() || got.Message() != tt.want.Message() {
+				t.Errorf("translator.PreFilterJob() = %v, want %v", got.AsError(), tt.want.AsError())
+			}
+		})
+	}
+}
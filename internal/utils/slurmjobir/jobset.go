@@ -4,17 +4,68 @@
 package slurmjobir
 
 import (
+	"errors"
+
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	fwk "k8s.io/kube-scheduler/framework"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	jobset "sigs.k8s.io/jobset/api/jobset/v1alpha2"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 )
 
 var (
 	// Ref: https://jobset.sigs.k8s.io/docs/
 	jobSet_v1alpha2 = metav1.TypeMeta{APIVersion: "jobset.x-k8s.io/v1alpha2", Kind: "JobSet"}
+
+	ErrorJobSetCouldNotGet = errors.New("could not get jobset")
 )
 
+// jobSetGangSize returns the number of pods of the JobSet which run at once,
+// across all of its ReplicatedJobs.
+func jobSetGangSize(jobSet *jobset.JobSet) int32 {
+	size := int32(0)
+	for _, rj := range jobSet.Spec.ReplicatedJobs {
+		job := &batchv1.Job{Spec: rj.Template.Spec}
+		size += rj.Replicas * gangSize(job)
+	}
+	return max(size, 1)
+}
+
+// PreFilterJobSet performs JobSet specific PreFilter functions
+func (t *translator) PreFilterJobSet(pod *corev1.Pod, slurmJobIR *SlurmJobIR) *fwk.Status {
+	if !isGang(&slurmJobIR.RootPOM) {
+		return fwk.NewStatus(fwk.Success)
+	}
+	jobSet := &jobset.JobSet{}
+	key := client.ObjectKey{Namespace: slurmJobIR.RootPOM.GetNamespace(), Name: slurmJobIR.RootPOM.GetName()}
+	if err := t.Get(t.ctx, key, jobSet); err != nil {
+		return fwk.NewStatus(fwk.Error, ErrorJobSetCouldNotGet.Error())
+	}
+
+	// Ensure there are enough pods for the gang. Don't count pods that may
+	// already have a placeholderjob label.
+	numPodsWaiting := 0
+	for _, p := range slurmJobIR.Pods.Items {
+		if p.Labels[wellknown.LabelPlaceholderJobId] ==
+			pod.Labels[wellknown.LabelPlaceholderJobId] {
+			numPodsWaiting++
+		}
+	}
+	if numPodsWaiting < int(jobSetGangSize(jobSet)) {
+		if pod.Labels[wellknown.LabelPlaceholderJobId] == "" {
+			return fwk.NewStatus(fwk.Error, ErrorInsuffientPods.Error())
+		} else {
+			return fwk.NewStatus(fwk.Error, ErrorPlaceholderJobInvalid.Error())
+		}
+	}
+	return fwk.NewStatus(fwk.Success)
+}
+
 // fromJobSet will translate a pod from a JobSet into a SlurmJobIR.
 func (t *translator) fromJobSet(pod *corev1.Pod, rootPOM *metav1.PartialObjectMetadata) (*SlurmJobIR, error) {
 	jobSet := &jobset.JobSet{}
@@ -23,6 +74,10 @@ func (t *translator) fromJobSet(pod *corev1.Pod, rootPOM *metav1.PartialObjectMe
 		return nil, err
 	}
 
+	if isGang(rootPOM) {
+		return t.fromJobSetGang(jobSet)
+	}
+
 	// Construct the rootPOM representing the job for this
 	// pod and use fromJob to populate slurmJobIR.
 	jobRootPOM := &metav1.PartialObjectMetadata{
@@ -39,3 +94,34 @@ func (t *translator) fromJobSet(pod *corev1.Pod, rootPOM *metav1.PartialObjectMe
 
 	return slurmJobIR, nil
 }
+
+// fromJobSetGang will translate the active pods of all ReplicatedJobs of a
+// JobSet into a SlurmJobIR with a node for each pod which runs at once.
+func (t *translator) fromJobSetGang(jobSet *jobset.JobSet) (*SlurmJobIR, error) {
+	podList := &corev1.PodList{}
+	if err := t.List(t.ctx, podList, client.InNamespace(jobSet.Namespace),
+		&client.ListOptions{LabelSelector: labels.SelectorFromSet(
+			labels.Set{jobset.JobSetNameKey: jobSet.Name},
+		)}); err != nil {
+		return nil, err
+	}
+
+	slurmJobIR := &SlurmJobIR{}
+	for _, p := range podList.Items {
+		// Pods of the JobSet which ended or are being replaced are not part
+		// of the gang.
+		if p.DeletionTimestamp != nil ||
+			p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		slurmJobIR.Pods.Items = append(slurmJobIR.Pods.Items, p)
+	}
+
+	size := jobSetGangSize(jobSet)
+	slurmJobIR.JobInfo.JobName = ptr.To(jobSet.Name)
+	slurmJobIR.JobInfo.MinNodes = ptr.To(size)
+	slurmJobIR.JobInfo.MaxNodes = ptr.To(size)
+	slurmJobIR.JobInfo.TasksPerNode = ptr.To(int32(1))
+
+	return slurmJobIR, nil
+}
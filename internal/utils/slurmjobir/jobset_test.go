@@ -14,10 +14,13 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	fwk "k8s.io/kube-scheduler/framework"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	jobset "sigs.k8s.io/jobset/api/jobset/v1alpha2"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 )
 
 func newJobSet(name string) *jobset.JobSet {
@@ -29,6 +32,37 @@ func newJobSet(name string) *jobset.JobSet {
 	}
 }
 
+// newGangJobSet returns a gang JobSet with a driver and two workers.
+func newGangJobSet(name string) *jobset.JobSet {
+	jobSet := newJobSet(name)
+	jobSet.Annotations = map[string]string{wellknown.AnnotationGang: "true"}
+	jobSet.Spec.ReplicatedJobs = []jobset.ReplicatedJob{
+		{Name: "driver", Replicas: 1},
+		{
+			Name:     "workers",
+			Replicas: 1,
+			Template: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{Parallelism: ptr.To(int32(2))},
+			},
+		},
+	}
+	return jobSet
+}
+
+func newJobSetPod(name, jobSetName, globalIndex string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      name,
+			Labels: map[string]string{
+				jobset.JobSetNameKey:     jobSetName,
+				jobset.JobGlobalIndexKey: globalIndex,
+			},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
 func Test_translator_fromJobSet(t *testing.T) {
 	type fields struct {
 		Reader client.Reader
@@ -139,6 +173,56 @@ func Test_translator_fromJobSet(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Gang JobSet to SlurmJobIR",
+			fields: fields{
+				Reader: func() client.Reader {
+					scheme := runtime.NewScheme()
+					utilruntime.Must(kubescheme.AddToScheme(scheme))
+					utilruntime.Must(batchv1.AddToScheme(scheme))
+					utilruntime.Must(jobset.AddToScheme(scheme))
+					return fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+						newGangJobSet("foo"),
+						newJobSetPod("foo-driver-0-0", "foo", "0", corev1.PodPending),
+						newJobSetPod("foo-workers-0-0", "foo", "1", corev1.PodPending),
+						newJobSetPod("foo-workers-0-1", "foo", "1", corev1.PodFailed),
+						newJobSetPod("bar-driver-0-0", "bar", "0", corev1.PodPending),
+					).Build()
+				}(),
+				ctx: context.Background(),
+			},
+			args: args{
+				pod: newJobSetPod("foo-driver-0-0", "foo", "0", corev1.PodPending),
+				rootPOM: &metav1.PartialObjectMetadata{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:   metav1.NamespaceDefault,
+						Name:        "foo",
+						Annotations: map[string]string{wellknown.AnnotationGang: "true"},
+					},
+				},
+			},
+			want: &SlurmJobIR{
+				JobInfo: SlurmJobIRJobInfo{
+					JobName:      ptr.To("foo"),
+					MinNodes:     ptr.To(int32(3)),
+					MaxNodes:     ptr.To(int32(3)),
+					TasksPerNode: ptr.To(int32(1)),
+				},
+				Pods: corev1.PodList{
+					Items: func() []corev1.Pod {
+						pods := []corev1.Pod{
+							*newJobSetPod("foo-driver-0-0", "foo", "0", corev1.PodPending),
+							*newJobSetPod("foo-workers-0-0", "foo", "1", corev1.PodPending),
+						}
+						for i := range pods {
+							pods[i].ResourceVersion = "999"
+						}
+						return pods
+					}(),
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -157,3 +241,118 @@ func Test_translator_fromJobSet(t *testing.T) {
 		})
 	}
 }
+
+func Test_translator_PreFilterJobSet(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(kubescheme.AddToScheme(scheme))
+	utilruntime.Must(jobset.AddToScheme(scheme))
+	rootPOM := metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   metav1.NamespaceDefault,
+			Name:        "foo",
+			Annotations: map[string]string{wellknown.AnnotationGang: "true"},
+		},
+	}
+	newPods := func(placeholder ...string) corev1.PodList {
+		pods := corev1.PodList{}
+		for _, p := range placeholder {
+			pod := newJobSetPod("foo", "foo", "0", corev1.PodPending)
+			if p != "" {
+				pod.Labels[wellknown.LabelPlaceholderJobId] = p
+			}
+			pods.Items = append(pods.Items, *pod)
+		}
+		return pods
+	}
+	type fields struct {
+		Reader client.Reader
+		ctx    context.Context
+	}
+	type args struct {
+		pod        *corev1.Pod
+		slurmJobIR *SlurmJobIR
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   *fwk.Status
+	}{
+		{
+			name: "Not a gang",
+			fields: fields{
+				Reader: fake.NewFakeClient(),
+				ctx:    context.Background(),
+			},
+			args: args{
+				pod:        &corev1.Pod{},
+				slurmJobIR: &SlurmJobIR{},
+			},
+			want: fwk.NewStatus(fwk.Success),
+		},
+		{
+			name: "Fail to get JobSet",
+			fields: fields{
+				Reader: fake.NewClientBuilder().WithScheme(scheme).Build(),
+				ctx:    context.Background(),
+			},
+			args: args{
+				pod:        &corev1.Pod{},
+				slurmJobIR: &SlurmJobIR{RootPOM: rootPOM},
+			},
+			want: fwk.NewStatus(fwk.Error, ErrorJobSetCouldNotGet.Error()),
+		},
+		{
+			name: "Not enough pods for gang",
+			fields: fields{
+				Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(newGangJobSet("foo")).Build(),
+				ctx:    context.Background(),
+			},
+			args: args{
+				pod:        newJobSetPod("foo", "foo", "0", corev1.PodPending),
+				slurmJobIR: &SlurmJobIR{RootPOM: rootPOM, Pods: newPods("", "")},
+			},
+			want: fwk.NewStatus(fwk.Error, ErrorInsuffientPods.Error()),
+		},
+		{
+			name: "Invalid state with placeholder and insufficient pods",
+			fields: fields{
+				Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(newGangJobSet("foo")).Build(),
+				ctx:    context.Background(),
+			},
+			args: args{
+				pod: func() *corev1.Pod {
+					pod := newJobSetPod("foo", "foo", "0", corev1.PodPending)
+					pod.Labels[wellknown.LabelPlaceholderJobId] = "1"
+					return pod
+				}(),
+				slurmJobIR: &SlurmJobIR{RootPOM: rootPOM, Pods: newPods("1", "1", "")},
+			},
+			want: fwk.NewStatus(fwk.Error, ErrorPlaceholderJobInvalid.Error()),
+		},
+		{
+			name: "Enough pods for gang",
+			fields: fields{
+				Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(newGangJobSet("foo")).Build(),
+				ctx:    context.Background(),
+			},
+			args: args{
+				pod:        newJobSetPod("foo", "foo", "0", corev1.PodPending),
+				slurmJobIR: &SlurmJobIR{RootPOM: rootPOM, Pods: newPods("", "", "")},
+			},
+			want: fwk.NewStatus(fwk.Success),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &translator{
+				Reader: tt.fields.Reader,
+				ctx:    tt.fields.ctx,
+			}
+			got := tr.PreFilterJobSet(tt.args.pod, tt.args.slurmJobIR)
+			if got.Code() != tt.want.Code() || got.Message() != tt.want.Message() {
+				t.Errorf("translator.PreFilterJobSet() = %v, want %v", got.AsError(), tt.want.AsError())
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	fwk "k8s.io/kube-scheduler/framework"
@@ -70,5 +71,30 @@ func (t *translator) fromLws(pod *corev1.Pod, rootPOM *metav1.PartialObjectMetad
 	slurmJobIR.JobInfo.MinNodes = ptr.To(int32(*lws.Spec.LeaderWorkerTemplate.Size))
 	slurmJobIR.JobInfo.TasksPerNode = ptr.To(int32(1))
 
+	template := lws.Spec.LeaderWorkerTemplate
+	if template.LeaderTemplate != nil && *template.Size > 1 &&
+		!apiequality.Semantic.DeepEqual(template.LeaderTemplate.Spec, template.WorkerTemplate.Spec) {
+		slurmJobIR.Components = lwsComponents(slurmJobIR.Pods.Items, *template.Size)
+	}
+
 	return slurmJobIR, nil
 }
+
+// lwsComponents splits the pods of a LeaderWorkerSet group into a component
+// for the leader and a component for the workers.
+func lwsComponents(pods []corev1.Pod, size int32) []SlurmJobIRComponent {
+	leader := SlurmJobIRComponent{}
+	leader.JobInfo.MinNodes = ptr.To(int32(1))
+	leader.JobInfo.MaxNodes = ptr.To(int32(1))
+	workers := SlurmJobIRComponent{}
+	workers.JobInfo.MinNodes = ptr.To(size - 1)
+	workers.JobInfo.MaxNodes = ptr.To(size - 1)
+	for _, p := range pods {
+		if p.Labels[lwsv1.WorkerIndexLabelKey] == "0" {
+			leader.Pods.Items = append(leader.Pods.Items, p)
+		} else {
+			workers.Pods.Items = append(workers.Pods.Items, p)
+		}
+	}
+	return []SlurmJobIRComponent{leader, workers}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -194,6 +195,33 @@ func Test_translator_PreFilterLWS(t *testing.T) {
 	}
 }
 
+// newHetLWS returns a LeaderWorkerSet with a CPU-only leader and GPU workers.
+func newHetLWS(name string, size int32) *lwsv1.LeaderWorkerSet {
+	lws := newLWS(name, size)
+	lws.Spec.LeaderWorkerTemplate.LeaderTemplate = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "leader"}},
+		},
+	}
+	lws.Spec.LeaderWorkerTemplate.WorkerTemplate = corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "worker",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("8")},
+				},
+			}},
+		},
+	}
+	return lws
+}
+
+func newLWSWorkerPod(name, groupHash, workerIndex string) *corev1.Pod {
+	pod := newLWSPod(name, groupHash)
+	pod.Labels[lwsv1.WorkerIndexLabelKey] = workerIndex
+	return pod
+}
+
 func Test_translator_fromLws(t *testing.T) {
 	type fields struct {
 		Reader client.Reader
@@ -341,6 +369,77 @@ func Test_translator_fromLws(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "LWS with leader template to heterogeneous SlurmJobIR",
+			fields: fields{
+				Reader: func() client.Client {
+					scheme := runtime.NewScheme()
+					utilruntime.Must(corev1.AddToScheme(scheme))
+					utilruntime.Must(lwsv1.AddToScheme(scheme))
+					return fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+						newHetLWS("lws", 3),
+						newLWSWorkerPod("pod1", "lws", "0"),
+						newLWSWorkerPod("pod2", "lws", "1"),
+						newLWSWorkerPod("pod3", "lws", "2"),
+					).Build()
+				}(),
+				ctx: context.Background(),
+			},
+			args: args{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							lwsv1.GroupUniqueHashLabelKey: "lws",
+							lwsv1.SetNameLabelKey:         "foo",
+							lwsv1.GroupIndexLabelKey:      "1",
+						},
+					},
+				},
+				rootPOM: &metav1.PartialObjectMetadata{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "lws",
+						Namespace: metav1.NamespaceDefault,
+					},
+				},
+			},
+			want: &SlurmJobIR{
+				JobInfo: SlurmJobIRJobInfo{
+					JobName:      ptr.To("foo-1"),
+					MaxNodes:     ptr.To(int32(3)),
+					MinNodes:     ptr.To(int32(3)),
+					TasksPerNode: ptr.To(int32(1)),
+				},
+				Pods: corev1.PodList{
+					Items: []corev1.Pod{
+						*newLWSWorkerPod("pod1", "lws", "0"),
+						*newLWSWorkerPod("pod2", "lws", "1"),
+						*newLWSWorkerPod("pod3", "lws", "2"),
+					},
+				},
+				Components: []SlurmJobIRComponent{
+					{
+						Pods: corev1.PodList{Items: []corev1.Pod{
+							*newLWSWorkerPod("pod1", "lws", "0"),
+						}},
+						JobInfo: SlurmJobIRJobInfo{
+							MaxNodes: ptr.To(int32(1)),
+							MinNodes: ptr.To(int32(1)),
+						},
+					},
+					{
+						Pods: corev1.PodList{Items: []corev1.Pod{
+							*newLWSWorkerPod("pod2", "lws", "1"),
+							*newLWSWorkerPod("pod3", "lws", "2"),
+						}},
+						JobInfo: SlurmJobIRJobInfo{
+							MaxNodes: ptr.To(int32(2)),
+							MinNodes: ptr.To(int32(2)),
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+	"k8s.io/utils/ptr"
+
+	nodeutils "github.com/SlinkyProject/slurm-bridge/internal/controller/node/utils"
+	"github.com/SlinkyProject/slurm-bridge/internal/utils"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+// parseNodeSelection resolves the nodeSelector and required nodeAffinity of the
+// pods against the bridged Kubernetes nodes. Bridged nodes which do not match
+// every pod are excluded from the placeholder job. When no more nodes match than
+// the job requires, the matching nodes are required by the placeholder job. If
+// no bridged node matches, the pods can not be placed and an error is returned.
+// Slurm features required through node labels become constraints of the job.
+func (t *translator) parseNodeSelection(slurmJobIR *SlurmJobIR) error {
+	requiredAffinities := []nodeaffinity.RequiredNodeAffinity{}
+	features := sets.New[string]()
+	for _, p := range slurmJobIR.Pods.Items {
+		if !hasRequiredNodeSelection(&p) {
+			continue
+		}
+		requiredAffinities = append(requiredAffinities, nodeaffinity.GetRequiredNodeAffinity(&p))
+		features.Insert(requiredFeatures(&p)...)
+	}
+	if len(requiredAffinities) == 0 {
+		return nil
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := t.List(t.ctx, nodeList); err != nil {
+		return err
+	}
+	included := []string{}
+	excluded := []string{}
+	for _, node := range nodeList.Items {
+		if !utils.IsBridgedNode(&node) {
+			continue
+		}
+		slurmNodeName := nodeutils.GetSlurmNodeName(&node)
+		if matchesAll(requiredAffinities, &node) {
+			included = append(included, slurmNodeName)
+		} else {
+			excluded = append(excluded, slurmNodeName)
+		}
+	}
+	if len(included) == 0 {
+		return ErrorNoMatchingNodes
+	}
+	slices.Sort(included)
+	slices.Sort(excluded)
+
+	if len(excluded) > 0 {
+		slurmJobIR.JobInfo.ExcludeNodes = ptr.To(strings.Join(excluded, ","))
+	}
+	if len(included) <= int(ptr.Deref(slurmJobIR.JobInfo.MinNodes, 1)) {
+		slurmJobIR.JobInfo.NodeList = ptr.To(strings.Join(included, ","))
+	}
+	if features.Len() > 0 {
+		constraints := strings.Join(sets.List(features), "&")
+		if c := ptr.Deref(slurmJobIR.JobInfo.Constraints, ""); c != "" {
+			constraints = "(" + c + ")&" + constraints
+		}
+		slurmJobIR.JobInfo.Constraints = ptr.To(constraints)
+	}
+	return nil
+}
+
+// requiredFeatures returns the Slurm features which the pod requires through
+// the feature labels of nodes, in its nodeSelector or in the single term of its
+// required nodeAffinity. Features of alternative terms can not be expressed as
+// a conjunction, and are left to the node list of the placeholder job.
+func requiredFeatures(pod *corev1.Pod) []string {
+	features := []string{}
+	for key, value := range pod.Spec.NodeSelector {
+		if feature, ok := strings.CutPrefix(key, wellknown.LabelPrefixSlurmFeature); ok && value == "true" {
+			features = append(features, feature)
+		}
+	}
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil ||
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return features
+	}
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 {
+		return features
+	}
+	for _, req := range terms[0].MatchExpressions {
+		feature, ok := strings.CutPrefix(req.Key, wellknown.LabelPrefixSlurmFeature)
+		if !ok {
+			continue
+		}
+		switch req.Operator {
+		case corev1.NodeSelectorOpExists:
+			features = append(features, feature)
+		case corev1.NodeSelectorOpIn:
+			if slices.Equal(req.Values, []string{"true"}) {
+				features = append(features, feature)
+			}
+		}
+	}
+	return features
+}
+
+// hasRequiredNodeSelection returns true if the pod constrains which nodes it
+// may be scheduled on.
+func hasRequiredNodeSelection(pod *corev1.Pod) bool {
+	if len(pod.Spec.NodeSelector) > 0 {
+		return true
+	}
+	affinity := pod.Spec.Affinity
+	return affinity != nil && affinity.NodeAffinity != nil &&
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil
+}
+
+// MatchesNodeSelection returns true if the node is bridged and matches the
+// required node selection of the pod, so it may be included in the placeholder
+// job of the pod.
+func MatchesNodeSelection(pod *corev1.Pod, node *corev1.Node) bool {
+	if !utils.IsBridgedNode(node) {
+		return false
+	}
+	if !hasRequiredNodeSelection(pod) {
+		return true
+	}
+	return matchesAll([]nodeaffinity.RequiredNodeAffinity{nodeaffinity.GetRequiredNodeAffinity(pod)}, node)
+}
+
+func matchesAll(requiredAffinities []nodeaffinity.RequiredNodeAffinity, node *corev1.Node) bool {
+	for _, requiredAffinity := range requiredAffinities {
+		if match, err := requiredAffinity.Match(node); err != nil || !match {
+			return false
+		}
+	}
+	return true
+}
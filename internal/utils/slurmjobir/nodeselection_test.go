@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/utils"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+func newBridgedNode(name string, labels map[string]string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{
+				{Key: utils.TaintKeyBridgedNode, Effect: corev1.TaintEffectNoExecute},
+			},
+		},
+	}
+}
+
+func Test_translator_parseNodeSelection(t *testing.T) {
+	nodes := []client.Object{
+		newBridgedNode("node1", map[string]string{"zone": "a"}),
+		newBridgedNode("node2", map[string]string{"zone": "a", wellknown.LabelSlurmNodeName: "slurm2"}),
+		newBridgedNode("node3", map[string]string{
+			"zone": "b",
+			wellknown.LabelPrefixSlurmFeature + "a100": "true",
+			wellknown.LabelPrefixSlurmFeature + "ib":   "true",
+		}),
+		newBridgedNode("node4", map[string]string{"zone": "b"}),
+		// Not a bridged node
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "kube1", Labels: map[string]string{"zone": "b"}}},
+	}
+	zoneAffinity := func(zones ...string) *corev1.Affinity {
+		return &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key:      "zone",
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   zones,
+						}},
+					}},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name        string
+		pods        []corev1.Pod
+		minNodes    *int32
+		constraints *string
+		want        SlurmJobIRJobInfo
+		wantErr     bool
+	}{
+		{
+			name: "No node selection",
+			pods: []corev1.Pod{{}},
+			want: SlurmJobIRJobInfo{},
+		},
+		{
+			name: "Node selector",
+			pods: []corev1.Pod{{Spec: corev1.PodSpec{NodeSelector: map[string]string{"zone": "a"}}}},
+			want: SlurmJobIRJobInfo{
+				ExcludeNodes: ptr.To("node3,node4"),
+			},
+		},
+		{
+			name:     "Node selector matches required nodes",
+			pods:     []corev1.Pod{{Spec: corev1.PodSpec{NodeSelector: map[string]string{"zone": "a"}}}},
+			minNodes: ptr.To[int32](2),
+			want: SlurmJobIRJobInfo{
+				MinNodes:     ptr.To[int32](2),
+				NodeList:     ptr.To("node1,slurm2"),
+				ExcludeNodes: ptr.To("node3,node4"),
+			},
+		},
+		{
+			name: "Node affinity of every pod",
+			pods: []corev1.Pod{
+				{Spec: corev1.PodSpec{Affinity: zoneAffinity("a", "b")}},
+				{Spec: corev1.PodSpec{Affinity: zoneAffinity("b")}},
+			},
+			want: SlurmJobIRJobInfo{
+				ExcludeNodes: ptr.To("node1,slurm2"),
+			},
+		},
+		{
+			name:    "No matching nodes",
+			pods:    []corev1.Pod{{Spec: corev1.PodSpec{NodeSelector: map[string]string{"zone": "c"}}}},
+			wantErr: true,
+		},
+		{
+			name: "Feature node selector",
+			pods: []corev1.Pod{{Spec: corev1.PodSpec{NodeSelector: map[string]string{
+				wellknown.LabelPrefixSlurmFeature + "a100": "true",
+			}}}},
+			want: SlurmJobIRJobInfo{
+				NodeList:     ptr.To("node3"),
+				ExcludeNodes: ptr.To("node1,node4,slurm2"),
+				Constraints:  ptr.To("a100"),
+			},
+		},
+		{
+			name: "Feature node affinity combined with constraints",
+			pods: []corev1.Pod{{Spec: corev1.PodSpec{Affinity: &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+							MatchExpressions: []corev1.NodeSelectorRequirement{
+								{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}},
+								{Key: wellknown.LabelPrefixSlurmFeature + "a100", Operator: corev1.NodeSelectorOpExists},
+								{Key: wellknown.LabelPrefixSlurmFeature + "ib", Operator: corev1.NodeSelectorOpIn, Values: []string{"true"}},
+							},
+						}},
+					},
+				},
+			}}}},
+			constraints: ptr.To("bigmem|hugemem"),
+			want: SlurmJobIRJobInfo{
+				NodeList:     ptr.To("node3"),
+				ExcludeNodes: ptr.To("node1,node4,slurm2"),
+				Constraints:  ptr.To("(bigmem|hugemem)&a100&ib"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &translator{
+				Reader: fake.NewClientBuilder().WithObjects(nodes...).Build(),
+				ctx:    context.Background(),
+			}
+			slurmJobIR := &SlurmJobIR{
+				Pods:    corev1.PodList{Items: tt.pods},
+				JobInfo: SlurmJobIRJobInfo{MinNodes: tt.minNodes, Constraints: tt.constraints},
+			}
+			err := tr.parseNodeSelection(slurmJobIR)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("translator.parseNodeSelection() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !apiequality.Semantic.DeepEqual(slurmJobIR.JobInfo, tt.want) {
+				t.Errorf("translator.parseNodeSelection() = %v, want %v", slurmJobIR.JobInfo, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	"errors"
+
+	"k8s.io/utils/ptr"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
+)
+
+var (
+	ErrorPolicyUserNotPinned = errors.New("no policy restricts the user of the workload")
+	ErrorPolicyUserRequired  = errors.New("the user of the workload is not set")
+)
+
+/*
+Apply a policy to the job info translated from the annotations of a workload,
+its namespace and the defaults of the configuration. The identity and resources
+which are not set are defaulted by the policy, then validated against it. The
+admission webhook only sees the annotations of pods, which are not the ones
+translated for pods owned by a workload.
+*/
+func ApplyPolicy(jobInfo *SlurmJobIRJobInfo, policy *config.Policy) error {
+	if policy == nil {
+		return nil
+	}
+	fields := map[string]**string{
+		wellknown.AnnotationAccount:   &jobInfo.Account,
+		wellknown.AnnotationGroupId:   &jobInfo.GroupId,
+		wellknown.AnnotationPartition: &jobInfo.Partition,
+		wellknown.AnnotationQOS:       &jobInfo.QOS,
+		wellknown.AnnotationUserId:    &jobInfo.UserId,
+	}
+	values := map[string]string{}
+	for annotation, field := range fields {
+		if *field != nil {
+			values[annotation] = **field
+		}
+	}
+	policy.ApplyDefaults(values)
+	for annotation, field := range fields {
+		if value := values[annotation]; value != "" && ptr.Deref(*field, "") == "" {
+			*field = ptr.To(value)
+		}
+	}
+	return policy.Validate(values)
+}
+
+/*
+Apply a policy to the job info of a workload which runs its own batch script,
+such as a SlurmJob. Its user is only trusted when a policy restricts it, and
+the job must resolve to a user so it never runs as the user of the token.
+*/
+func ApplyUserPolicy(jobInfo *SlurmJobIRJobInfo, policy *config.Policy) error {
+	if !policy.PinsUser() {
+		return ErrorPolicyUserNotPinned
+	}
+	if err := ApplyPolicy(jobInfo, policy); err != nil {
+		return err
+	}
+	if ptr.Deref(jobInfo.UserId, "") == "" {
+		return ErrorPolicyUserRequired
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	"errors"
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/utils/ptr"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+)
+
+func TestApplyPolicy(t *testing.T) {
+	policy := &config.Policy{
+		UserId:  config.PolicyRule{Allowed: []string{"1000", "1001"}, Default: "1000"},
+		Account: config.PolicyRule{Allowed: []string{"team-a"}},
+		QOS:     config.PolicyRule{Default: "normal"},
+	}
+	tests := []struct {
+		name    string
+		jobInfo SlurmJobIRJobInfo
+		policy  *config.Policy
+		want    SlurmJobIRJobInfo
+		wantErr bool
+	}{
+		{
+			name: "No policy",
+			jobInfo: SlurmJobIRJobInfo{
+				UserId: ptr.To("0"),
+			},
+			policy: nil,
+			want: SlurmJobIRJobInfo{
+				UserId: ptr.To("0"),
+			},
+		},
+		{
+			name:    "Defaults",
+			jobInfo: SlurmJobIRJobInfo{},
+			policy:  policy,
+			want: SlurmJobIRJobInfo{
+				UserId: ptr.To("1000"),
+				QOS:    ptr.To("normal"),
+			},
+		},
+		{
+			name: "Allowed",
+			jobInfo: SlurmJobIRJobInfo{
+				UserId:  ptr.To("1001"),
+				Account: ptr.To("team-a"),
+				QOS:     ptr.To("high"),
+			},
+			policy: policy,
+			want: SlurmJobIRJobInfo{
+				UserId:  ptr.To("1001"),
+				Account: ptr.To("team-a"),
+				QOS:     ptr.To("high"),
+			},
+		},
+		{
+			name: "User not allowed",
+			jobInfo: SlurmJobIRJobInfo{
+				UserId: ptr.To("0"),
+			},
+			policy:  policy,
+			wantErr: true,
+		},
+		{
+			name: "Account not allowed",
+			jobInfo: SlurmJobIRJobInfo{
+				Account: ptr.To("team-b"),
+			},
+			policy:  policy,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobInfo := tt.jobInfo
+			err := ApplyPolicy(&jobInfo, tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !apiequality.Semantic.DeepEqual(jobInfo, tt.want) {
+				t.Errorf("ApplyPolicy() = %v, want %v", jobInfo, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyUserPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		jobInfo SlurmJobIRJobInfo
+		policy  *config.Policy
+		want    SlurmJobIRJobInfo
+		wantErr error
+	}{
+		{
+			name: "No policy",
+			jobInfo: SlurmJobIRJobInfo{
+				UserId: ptr.To("1000"),
+			},
+			policy:  nil,
+			wantErr: ErrorPolicyUserNotPinned,
+		},
+		{
+			name: "User not restricted",
+			jobInfo: SlurmJobIRJobInfo{
+				UserId: ptr.To("0"),
+			},
+			policy: &config.Policy{
+				UserId: config.PolicyRule{Default: "1000"},
+			},
+			wantErr: ErrorPolicyUserNotPinned,
+		},
+		{
+			name:    "No user",
+			jobInfo: SlurmJobIRJobInfo{},
+			policy: &config.Policy{
+				UserId: config.PolicyRule{Allowed: []string{"1000"}},
+			},
+			wantErr: ErrorPolicyUserRequired,
+		},
+		{
+			name: "User not allowed",
+			jobInfo: SlurmJobIRJobInfo{
+				UserId: ptr.To("0"),
+			},
+			policy: &config.Policy{
+				UserId: config.PolicyRule{Allowed: []string{"1000"}},
+			},
+			wantErr: config.ErrorPolicyNotAllowed,
+		},
+		{
+			name:    "Default user",
+			jobInfo: SlurmJobIRJobInfo{},
+			policy: &config.Policy{
+				UserId: config.PolicyRule{Allowed: []string{"1000"}, Default: "1000"},
+			},
+			want: SlurmJobIRJobInfo{
+				UserId: ptr.To("1000"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobInfo := tt.jobInfo
+			err := ApplyUserPolicy(&jobInfo, tt.policy)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApplyUserPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !apiequality.Semantic.DeepEqual(jobInfo, tt.want) {
+				t.Errorf("ApplyUserPolicy() = %v, want %v", jobInfo, tt.want)
+			}
+		})
+	}
+}
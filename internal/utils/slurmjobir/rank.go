@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	jobset "sigs.k8s.io/jobset/api/jobset/v1alpha2"
+	lwsv1 "sigs.k8s.io/lws/api/leaderworkerset/v1"
+)
+
+// rankKeys are the pod labels (or annotations), in order of precedence, which
+// hold the index of a pod within its workload.
+var rankKeys = []string{
+	jobset.JobGlobalIndexKey,
+	jobset.JobIndexKey,
+	batchv1.JobCompletionIndexAnnotation,
+	lwsv1.WorkerIndexLabelKey,
+}
+
+// SortPodsByRank sorts pods by their index within the workload (e.g. LWS
+// worker index, Job completion index), so the leader is first. Pods without an
+// index are sorted last, ordered by name.
+func SortPodsByRank(pods []corev1.Pod) {
+	slices.SortStableFunc(pods, comparePodRank)
+}
+
+func comparePodRank(a, b corev1.Pod) int {
+	for _, key := range rankKeys {
+		if c := cmp.Compare(podIndex(&a, key), podIndex(&b, key)); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(a.Name, b.Name)
+}
+
+// podIndex returns the index stored in the pod label or annotation key, or
+// math.MaxInt if the pod has no valid index.
+func podIndex(pod *corev1.Pod, key string) int {
+	value, ok := pod.Labels[key]
+	if !ok {
+		value, ok = pod.Annotations[key]
+	}
+	if !ok {
+		return math.MaxInt
+	}
+	index, err := strconv.Atoi(value)
+	if err != nil || index < 0 {
+		return math.MaxInt
+	}
+	return index
+}
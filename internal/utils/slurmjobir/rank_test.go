@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	"reflect"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	jobset "sigs.k8s.io/jobset/api/jobset/v1alpha2"
+	lwsv1 "sigs.k8s.io/lws/api/leaderworkerset/v1"
+)
+
+func TestSortPodsByRank(t *testing.T) {
+	makePod := func(name string, labels, annotations map[string]string) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations}}
+	}
+	tests := []struct {
+		name string
+		pods []corev1.Pod
+		want []string
+	}{
+		{
+			name: "No index sorts by name",
+			pods: []corev1.Pod{
+				makePod("c", nil, nil),
+				makePod("a", nil, nil),
+				makePod("b", nil, nil),
+			},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "LWS worker index",
+			pods: []corev1.Pod{
+				makePod("lws-0-2", map[string]string{lwsv1.WorkerIndexLabelKey: "2"}, nil),
+				makePod("lws-0-10", map[string]string{lwsv1.WorkerIndexLabelKey: "10"}, nil),
+				makePod("lws-0", map[string]string{lwsv1.WorkerIndexLabelKey: "0"}, nil),
+				makePod("lws-0-1", map[string]string{lwsv1.WorkerIndexLabelKey: "1"}, nil),
+			},
+			want: []string{"lws-0", "lws-0-1", "lws-0-2", "lws-0-10"},
+		},
+		{
+			name: "Job completion index from annotation",
+			pods: []corev1.Pod{
+				makePod("job-1", nil, map[string]string{batchv1.JobCompletionIndexAnnotation: "1"}),
+				makePod("job-0", nil, map[string]string{batchv1.JobCompletionIndexAnnotation: "0"}),
+				makePod("other", nil, nil),
+			},
+			want: []string{"job-0", "job-1", "other"},
+		},
+		{
+			name: "JobSet job index before completion index",
+			pods: []corev1.Pod{
+				makePod("js-1-0", map[string]string{jobset.JobIndexKey: "1", batchv1.JobCompletionIndexAnnotation: "0"}, nil),
+				makePod("js-0-1", map[string]string{jobset.JobIndexKey: "0", batchv1.JobCompletionIndexAnnotation: "1"}, nil),
+				makePod("js-0-0", map[string]string{jobset.JobIndexKey: "0", batchv1.JobCompletionIndexAnnotation: "0"}, nil),
+			},
+			want: []string{"js-0-0", "js-0-1", "js-1-0"},
+		},
+		{
+			name: "JobSet global job index across ReplicatedJobs",
+			pods: []corev1.Pod{
+				makePod("js-worker-0", map[string]string{jobset.JobGlobalIndexKey: "1", jobset.JobIndexKey: "0"}, nil),
+				makePod("js-driver-0", map[string]string{jobset.JobGlobalIndexKey: "0", jobset.JobIndexKey: "0"}, nil),
+				makePod("js-worker-1", map[string]string{jobset.JobGlobalIndexKey: "2", jobset.JobIndexKey: "1"}, nil),
+			},
+			want: []string{"js-driver-0", "js-worker-0", "js-worker-1"},
+		},
+		{
+			name: "Invalid index sorts last",
+			pods: []corev1.Pod{
+				makePod("bad", map[string]string{lwsv1.WorkerIndexLabelKey: "foo"}, nil),
+				makePod("good", map[string]string{lwsv1.WorkerIndexLabelKey: "3"}, nil),
+			},
+			want: []string{"good", "bad"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SortPodsByRank(tt.pods)
+			got := []string{}
+			for _, p := range tt.pods {
+				got = append(got, p.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortPodsByRank() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
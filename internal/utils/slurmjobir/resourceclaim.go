@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// podDeviceCounts returns the number of devices requested by the
+// ResourceClaims of the pod, by DeviceClass name.
+func (t *translator) podDeviceCounts(pod *corev1.Pod) (map[string]int64, error) {
+	counts := map[string]int64{}
+	for _, podClaim := range pod.Spec.ResourceClaims {
+		spec, err := t.getResourceClaimSpec(pod.Namespace, podClaim)
+		if err != nil {
+			return nil, err
+		}
+		if spec == nil {
+			continue
+		}
+		for _, request := range spec.Devices.Requests {
+			deviceClassName, count := deviceRequestCount(request)
+			if deviceClassName != "" {
+				counts[deviceClassName] += count
+			}
+		}
+	}
+	return counts, nil
+}
+
+// getResourceClaimSpec returns the spec of the ResourceClaim, or of the
+// ResourceClaimTemplate from which the claim will be generated.
+func (t *translator) getResourceClaimSpec(namespace string, podClaim corev1.PodResourceClaim) (*resourcev1.ResourceClaimSpec, error) {
+	switch {
+	case podClaim.ResourceClaimName != nil:
+		claim := &resourcev1.ResourceClaim{}
+		key := client.ObjectKey{Namespace: namespace, Name: *podClaim.ResourceClaimName}
+		if err := t.Get(t.ctx, key, claim); err != nil {
+			return nil, err
+		}
+		return &claim.Spec, nil
+	case podClaim.ResourceClaimTemplateName != nil:
+		template := &resourcev1.ResourceClaimTemplate{}
+		key := client.ObjectKey{Namespace: namespace, Name: *podClaim.ResourceClaimTemplateName}
+		if err := t.Get(t.ctx, key, template); err != nil {
+			return nil, err
+		}
+		return &template.Spec.Spec, nil
+	default:
+		return nil, nil
+	}
+}
+
+// deviceRequestCount returns the DeviceClass name and number of devices of the
+// request. The first of prioritized alternatives is used. Requests for all
+// matching devices do not have a count known ahead of allocation and are
+// ignored.
+func deviceRequestCount(request resourcev1.DeviceRequest) (string, int64) {
+	var deviceClassName string
+	var allocationMode resourcev1.DeviceAllocationMode
+	var count int64
+	switch {
+	case request.Exactly != nil:
+		deviceClassName = request.Exactly.DeviceClassName
+		allocationMode = request.Exactly.AllocationMode
+		count = request.Exactly.Count
+	case len(request.FirstAvailable) > 0:
+		deviceClassName = request.FirstAvailable[0].DeviceClassName
+		allocationMode = request.FirstAvailable[0].AllocationMode
+		count = request.FirstAvailable[0].Count
+	default:
+		return "", 0
+	}
+	if allocationMode == resourcev1.DeviceAllocationModeAll {
+		return "", 0
+	}
+	return deviceClassName, max(count, 1)
+}
@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjobir
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+)
+
+func newResourceClaimSpec(requests ...resourcev1.DeviceRequest) resourcev1.ResourceClaimSpec {
+	return resourcev1.ResourceClaimSpec{
+		Devices: resourcev1.DeviceClaim{Requests: requests},
+	}
+}
+
+func exactDeviceRequest(deviceClassName string, count int64) resourcev1.DeviceRequest {
+	return resourcev1.DeviceRequest{
+		Name: "req",
+		Exactly: &resourcev1.ExactDeviceRequest{
+			DeviceClassName: deviceClassName,
+			AllocationMode:  resourcev1.DeviceAllocationModeExactCount,
+			Count:           count,
+		},
+	}
+}
+
+func podWithResourceClaims(claims ...corev1.PodResourceClaim) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "pod"},
+		Spec:       corev1.PodSpec{ResourceClaims: claims},
+	}
+}
+
+func Test_translator_podDeviceCounts(t *testing.T) {
+	objects := []client.Object{
+		&resourcev1.ResourceClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "claim"},
+			Spec: newResourceClaimSpec(
+				exactDeviceRequest("gpu.example.com", 2),
+				exactDeviceRequest("nic.example.com", 0),
+			),
+		},
+		&resourcev1.ResourceClaimTemplate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "template"},
+			Spec: resourcev1.ResourceClaimTemplateSpec{
+				Spec: newResourceClaimSpec(
+					resourcev1.DeviceRequest{
+						Name: "first-available",
+						FirstAvailable: []resourcev1.DeviceSubRequest{
+							{Name: "large", DeviceClassName: "gpu.example.com", Count: 4},
+							{Name: "small", DeviceClassName: "mig.example.com", Count: 1},
+						},
+					},
+					resourcev1.DeviceRequest{
+						Name: "all",
+						Exactly: &resourcev1.ExactDeviceRequest{
+							DeviceClassName: "fpga.example.com",
+							AllocationMode:  resourcev1.DeviceAllocationModeAll,
+						},
+					},
+				),
+			},
+		},
+	}
+	tests := []struct {
+		name    string
+		pod     corev1.Pod
+		want    map[string]int64
+		wantErr bool
+	}{
+		{
+			name: "No claims",
+			pod:  podWithResourceClaims(),
+			want: map[string]int64{},
+		},
+		{
+			name: "ResourceClaim",
+			pod:  podWithResourceClaims(corev1.PodResourceClaim{Name: "a", ResourceClaimName: ptr.To("claim")}),
+			want: map[string]int64{"gpu.example.com": 2, "nic.example.com": 1},
+		},
+		{
+			name: "ResourceClaimTemplate",
+			pod:  podWithResourceClaims(corev1.PodResourceClaim{Name: "a", ResourceClaimTemplateName: ptr.To("template")}),
+			want: map[string]int64{"gpu.example.com": 4},
+		},
+		{
+			name: "Claim and template",
+			pod: podWithResourceClaims(
+				corev1.PodResourceClaim{Name: "a", ResourceClaimName: ptr.To("claim")},
+				corev1.PodResourceClaim{Name: "b", ResourceClaimTemplateName: ptr.To("template")},
+			),
+			want: map[string]int64{"gpu.example.com": 6, "nic.example.com": 1},
+		},
+		{
+			name:    "ResourceClaim not found",
+			pod:     podWithResourceClaims(corev1.PodResourceClaim{Name: "a", ResourceClaimName: ptr.To("missing")}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &translator{
+				Reader: fake.NewClientBuilder().WithObjects(objects...).Build(),
+				ctx:    context.Background(),
+			}
+			got, err := tr.podDeviceCounts(&tt.pod)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("translator.podDeviceCounts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("translator.podDeviceCounts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_translator_parseGres_resourceClaims(t *testing.T) {
+	claim := &resourcev1.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "claim"},
+		Spec:       newResourceClaimSpec(exactDeviceRequest("gpu.example.com", 2)),
+	}
+	tr := &translator{
+		Reader: fake.NewClientBuilder().WithObjects(claim).Build(),
+		ctx:    context.Background(),
+	}
+	pod := podWithResourceClaims(corev1.PodResourceClaim{Name: "a", ResourceClaimName: ptr.To("claim")})
+	slurmJobIR := &SlurmJobIR{Pods: corev1.PodList{Items: []corev1.Pod{pod}}}
+	gresMappings := []config.GresMapping{
+		{DeviceClassName: "gpu.example.com", Name: "gpu", Type: "h100"},
+	}
+	if err := tr.parseGres(slurmJobIR, gresMappings); err != nil {
+		t.Fatalf("translator.parseGres() error = %v", err)
+	}
+	if got, want := ptr.Deref(slurmJobIR.JobInfo.Gres, ""), "gres/gpu:h100=2"; got != want {
+		t.Errorf("translator.parseGres() Gres = %v, want %v", got, want)
+	}
+}
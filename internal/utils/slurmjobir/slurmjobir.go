@@ -5,6 +5,10 @@ package slurmjobir
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -14,28 +18,35 @@ import (
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
 	"github.com/SlinkyProject/slurm-bridge/internal/utils"
 	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 )
 
 type SlurmJobIRJobInfo struct {
-	Account      *string
-	CpuPerTask   *int32
-	Constraints  *string
-	Gres         *string
-	GroupId      *string
-	JobName      *string
-	Licenses     *string
-	MemPerNode   *int64 // memory in megabytes
-	MinNodes     *int32
-	MaxNodes     *int32
-	Partition    *string
-	QOS          *string
-	Reservation  *string
-	TasksPerNode *int32
-	TimeLimit    *int32
-	UserId       *string
-	Wckey        *string
+	Account        *string
+	AllocationPool *string // AllocationPool to claim an allocation of
+	AttachJobId    *int32  // running Slurm job to attach the pods to
+	CpuPerTask     *int32
+	Constraints    *string
+	ExcludeNodes   *string // comma separated Slurm node names
+	Gres           *string
+	GroupId        *string
+	JobName        *string
+	Licenses       *string
+	MemPerNode     *int64 // memory in megabytes
+	MinNodes       *int32
+	MaxNodes       *int32
+	NodeList       *string // comma separated Slurm node names
+	Partition      *string
+	QOS            *string
+	Reservation    *string
+	Shared         *string
+	Tasks          *int32
+	TasksPerNode   *int32
+	TimeLimit      *int32
+	UserId         *string
+	Wckey          *string
 }
 
 // Slurm Job Intermediate Representation (IR)
@@ -43,6 +54,11 @@ type SlurmJobIR struct {
 	RootPOM metav1.PartialObjectMetadata
 	Pods    corev1.PodList
 	JobInfo SlurmJobIRJobInfo
+	// Components, if set, make the placeholder job a heterogeneous job. Each
+	// pod in Pods belongs to exactly one component.
+	Components []SlurmJobIRComponent
+	// Policy the workload is subject to, or nil if none applies.
+	Policy *config.Policy
 }
 
 type translator struct {
@@ -57,12 +73,16 @@ func PreFilter(c client.Client, ctx context.Context, pod *corev1.Pod, slurmJobIR
 		return t.PreFilterPodGroup(pod, slurmJobIR)
 	case lws_v1:
 		return t.PreFilterLWS(pod, slurmJobIR)
+	case job_v1:
+		return t.PreFilterJob(pod, slurmJobIR)
+	case jobSet_v1alpha2:
+		return t.PreFilterJobSet(pod, slurmJobIR)
 	default:
 		return fwk.NewStatus(fwk.Success)
 	}
 }
 
-func TranslateToSlurmJobIR(c client.Client, ctx context.Context, pod *corev1.Pod) (slurmJobIR *SlurmJobIR, err error) {
+func TranslateToSlurmJobIR(c client.Client, ctx context.Context, pod *corev1.Pod, gresMappings []config.GresMapping, namespaceDefaults []config.NamespaceDefaults) (slurmJobIR *SlurmJobIR, err error) {
 	rootPOM, err := utils.GetRootOwnerMetadata(c, ctx, pod)
 	if err != nil {
 		return nil, err
@@ -99,62 +119,179 @@ func TranslateToSlurmJobIR(c client.Client, ctx context.Context, pod *corev1.Pod
 		return nil, err
 	}
 	slurmJobIR.RootPOM = *rootPOM
+	anno, err := t.withDefaults(pod.Namespace, rootPOM.Annotations, namespaceDefaults)
+	if err != nil {
+		return nil, err
+	}
+	if err := parseTasksPerNode(slurmJobIR, anno); err != nil {
+		return nil, err
+	}
 	parsePodsCpuAndMemory(slurmJobIR)
-	parseGPUDevicePlugin(slurmJobIR)
-	err = parseAnnotations(slurmJobIR, rootPOM.Annotations)
-	return slurmJobIR, err
+	if err := t.parseGres(slurmJobIR, gresMappings); err != nil {
+		return nil, err
+	}
+	if err := parseAnnotations(slurmJobIR, anno); err != nil {
+		return slurmJobIR, err
+	}
+	// Node selection is resolved once the node counts of the annotations apply
+	if err := t.parseNodeSelection(slurmJobIR); err != nil {
+		return nil, err
+	}
+	parseSecurityContext(slurmJobIR, pod)
+	if err := t.parseComponents(slurmJobIR, gresMappings); err != nil {
+		return nil, err
+	}
+	return slurmJobIR, nil
+}
+
+// TranslateAnnotationsToJobInfo translates the annotations of a workload without
+// pods, such as a SlurmJob, merged over the defaults of its namespace.
+func TranslateAnnotationsToJobInfo(c client.Client, ctx context.Context, obj metav1.Object, namespaceDefaults []config.NamespaceDefaults) (*SlurmJobIRJobInfo, error) {
+	t := translator{Reader: c, ctx: ctx}
+	anno, err := t.withDefaults(obj.GetNamespace(), obj.GetAnnotations(), namespaceDefaults)
+	if err != nil {
+		return nil, err
+	}
+	slurmJobIR := &SlurmJobIR{}
+	if err := parseAnnotations(slurmJobIR, anno); err != nil {
+		return nil, err
+	}
+	return &slurmJobIR.JobInfo, nil
+}
+
+/*
+Set the task layout for the placeholder job, where each pod is a task. When
+more than one task is requested per node, the node counts are reduced so the
+pods are packed onto ceil(pods/tasksPerNode) nodes. The node counts and CPUs
+per task are derived from the packing, so annotations which set them are
+rejected rather than silently overriding it.
+*/
+func parseTasksPerNode(slurmJobIR *SlurmJobIR, anno map[string]string) error {
+	value, ok := anno[wellknown.AnnotationTasksPerNode]
+	if !ok {
+		return nil
+	}
+	for _, key := range []string{wellknown.AnnotationMinNodes, wellknown.AnnotationMaxNodes, wellknown.AnnotationCpuPerTask} {
+		if _, ok := anno[key]; ok {
+			return fmt.Errorf("%w: %s", ErrorTasksPerNodeConflict, key)
+		}
+	}
+	tasksPerNode, err := ConvStrTo32(value)
+	if err != nil || *tasksPerNode < 1 {
+		return ErrorTasksPerNodeInvalid
+	}
+	tasks := int32(len(slurmJobIR.Pods.Items)) //nolint:gosec // disable G115
+	if tasks == 0 {
+		return nil
+	}
+	*tasksPerNode = min(*tasksPerNode, tasks)
+	slurmJobIR.JobInfo.Tasks = ptr.To(tasks)
+	slurmJobIR.JobInfo.TasksPerNode = tasksPerNode
+	slurmJobIR.JobInfo.MaxNodes = ptr.To(divideRoundUp(tasks, *tasksPerNode))
+	if slurmJobIR.JobInfo.MinNodes != nil {
+		slurmJobIR.JobInfo.MinNodes = ptr.To(divideRoundUp(*slurmJobIR.JobInfo.MinNodes, *tasksPerNode))
+	}
+	return nil
 }
 
-/* Set CPU and Memory for the placeholder job based on the maximum Pod CPU and Memory (including overhead) */
+/*
+Set CPU and Memory for the placeholder job based on the largest Pod CPU and
+Memory (including overhead). When multiple tasks are packed onto a node, each
+node must fit the sum of its co-located pods, so the largest TasksPerNode pods
+are summed instead.
+*/
 func parsePodsCpuAndMemory(slurmJobIR *SlurmJobIR) {
-	var cpuMax resource.Quantity
-	var memMax resource.Quantity
+	cpus := make([]resource.Quantity, 0, len(slurmJobIR.Pods.Items))
+	mems := make([]resource.Quantity, 0, len(slurmJobIR.Pods.Items))
 	for _, p := range slurmJobIR.Pods.Items {
 		lim := resourcehelper.PodLimits(&p, resourcehelper.PodResourcesOptions{})
 		req := resourcehelper.PodRequests(&p, resourcehelper.PodResourcesOptions{})
-		if req.Cpu().Cmp(cpuMax) == 1 {
-			cpuMax = *req.Cpu()
-		}
-		if lim.Cpu().Cmp(cpuMax) == 1 {
-			cpuMax = *lim.Cpu()
-		}
-		if req.Memory().Cmp(memMax) == 1 {
-			memMax = *req.Memory()
-		}
-		if lim.Memory().Cmp(memMax) == 1 {
-			memMax = *lim.Memory()
-		}
+		cpus = append(cpus, maxQuantity(*req.Cpu(), *lim.Cpu()))
+		mems = append(mems, maxQuantity(*req.Memory(), *lim.Memory()))
 	}
+	tasksPerNode := ptr.Deref(slurmJobIR.JobInfo.TasksPerNode, 1)
+	cpuPerNode := sumLargest(cpus, tasksPerNode)
+	memPerNode := sumLargest(mems, tasksPerNode)
 	// If either CPU or Memory is set to 0, leave that value unset so Slurm
 	// will use the default values of the partition. Slurm does not support
 	// unbounded cpu or memory.
-	if cpuMax.Value() > 0 {
-		slurmJobIR.JobInfo.CpuPerTask = ptr.To(int32(cpuMax.Value())) //nolint:gosec
+	if cpuPerNode.Value() > 0 {
+		// CpusPerTask is multiplied by TasksPerNode in Slurm, so divide the
+		// node total evenly, rounding up.
+		cpuPerTask := divideRoundUp(cpuPerNode.MilliValue(), int64(max(tasksPerNode, 1))*1000)
+		slurmJobIR.JobInfo.CpuPerTask = ptr.To(int32(cpuPerTask)) //nolint:gosec
 	}
-	if memMax.Value() > 0 {
-		slurmJobIR.JobInfo.MemPerNode = ptr.To(GetMemoryFromQuantity(&memMax))
+	if memPerNode.Value() > 0 {
+		slurmJobIR.JobInfo.MemPerNode = ptr.To(GetMemoryFromQuantity(&memPerNode))
 	}
 }
 
-/* Set GRES for the placeholder job to the maximum quantity of GPUs requested */
-func parseGPUDevicePlugin(slurmJobIR *SlurmJobIR) {
-
-	var gresMax resource.Quantity
-	gpuTypes := []corev1.ResourceName{
-		"nvidia.com/gpu",
-		"amd.com/gpu",
-	}
+/*
+Set GRES for the placeholder job from the extended resources and DRA devices of
+the pods that are mapped to a Slurm GRES. Each GRES is sized to the maximum
+count requested, or the sum of the largest requests when multiple tasks are
+packed onto a node.
+*/
+func (t *translator) parseGres(slurmJobIR *SlurmJobIR, gresMappings []config.GresMapping) error {
+	mappings := withDefaultGresMappings(gresMappings)
+	gresCounts := map[string][]resource.Quantity{}
 	for _, p := range slurmJobIR.Pods.Items {
+		deviceCounts, err := t.podDeviceCounts(&p)
+		if err != nil {
+			return err
+		}
+		podCounts := map[string]int64{}
 		lim := resourcehelper.PodLimits(&p, resourcehelper.PodResourcesOptions{})
-		for _, vendor := range gpuTypes {
-			if quantity, exists := lim[vendor]; exists && quantity.Cmp(gresMax) > 0 {
-				gresMax = quantity
+		for _, m := range mappings {
+			var count int64
+			if quantity, exists := lim[corev1.ResourceName(m.ResourceName)]; exists && m.ResourceName != "" {
+				count += quantity.Value()
+			}
+			if m.DeviceClassName != "" {
+				count += deviceCounts[m.DeviceClassName]
 			}
+			if count > 0 {
+				podCounts[gresTres(m)] += count * max(m.Multiplier, 1)
+			}
+		}
+		for tres, count := range podCounts {
+			gresCounts[tres] = append(gresCounts[tres], *resource.NewQuantity(count, resource.DecimalSI))
 		}
 	}
-	if !gresMax.IsZero() {
-		slurmJobIR.JobInfo.Gres = ptr.To("gres/gpu=" + gresMax.String())
+	tasksPerNode := ptr.Deref(slurmJobIR.JobInfo.TasksPerNode, 1)
+	gres := make([]string, 0, len(gresCounts))
+	for _, tres := range slices.Sorted(maps.Keys(gresCounts)) {
+		gresPerNode := sumLargest(gresCounts[tres], tasksPerNode)
+		if !gresPerNode.IsZero() {
+			gres = append(gres, tres+"="+gresPerNode.String())
+		}
 	}
+	if len(gres) > 0 {
+		slurmJobIR.JobInfo.Gres = ptr.To(strings.Join(gres, ","))
+	}
+	return nil
+}
+
+// withDefaultGresMappings returns the configured GRES mappings followed by the
+// default mappings for resources which are not configured.
+func withDefaultGresMappings(gresMappings []config.GresMapping) []config.GresMapping {
+	mappings := slices.Clone(gresMappings)
+	for _, d := range config.DefaultGresMappings {
+		if !slices.ContainsFunc(gresMappings, func(m config.GresMapping) bool {
+			return m.ResourceName == d.ResourceName
+		}) {
+			mappings = append(mappings, d)
+		}
+	}
+	return mappings
+}
+
+// gresTres returns the TRES name of the GRES (e.g. gres/gpu:a100).
+func gresTres(m config.GresMapping) string {
+	if m.Type == "" {
+		return "gres/" + m.Name
+	}
+	return "gres/" + m.Name + ":" + m.Type
 }
 
 func parseAnnotations(slurmJobIR *SlurmJobIR, anno map[string]string) error {
@@ -166,6 +303,14 @@ func parseAnnotations(slurmJobIR *SlurmJobIR, anno map[string]string) error {
 		switch key {
 		case wellknown.AnnotationAccount:
 			slurmJobIR.JobInfo.Account = &value
+		case wellknown.AnnotationAllocationPool:
+			slurmJobIR.JobInfo.AllocationPool = &value
+		case wellknown.AnnotationAttachJobId:
+			num, err := ConvStrTo32(value)
+			if err != nil {
+				return err
+			}
+			slurmJobIR.JobInfo.AttachJobId = num
 		case wellknown.AnnotationConstraints:
 			slurmJobIR.JobInfo.Constraints = &value
 		case wellknown.AnnotationGres:
@@ -209,6 +354,11 @@ func parseAnnotations(slurmJobIR *SlurmJobIR, anno map[string]string) error {
 			slurmJobIR.JobInfo.QOS = &value
 		case wellknown.AnnotationReservation:
 			slurmJobIR.JobInfo.Reservation = &value
+		case wellknown.AnnotationShared:
+			if !IsValidShared(value) {
+				return ErrorSharedInvalid
+			}
+			slurmJobIR.JobInfo.Shared = &value
 		case wellknown.AnnotationTimeLimit:
 			num, err := ConvStrTo32(value)
 			if err != nil {
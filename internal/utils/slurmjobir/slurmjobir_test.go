@@ -7,6 +7,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
 	"github.com/SlinkyProject/slurm-bridge/internal/wellknown"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
@@ -160,7 +161,7 @@ func TestTranslateToSlurmJobIR(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := TranslateToSlurmJobIR(tt.args.client, tt.args.ctx, tt.args.pod)
+			got, err := TranslateToSlurmJobIR(tt.args.client, tt.args.ctx, tt.args.pod, nil, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("TranslateToSlurmJobIR() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -172,6 +173,71 @@ func TestTranslateToSlurmJobIR(t *testing.T) {
 	}
 }
 
+func TestTranslateAnnotationsToJobInfo(t *testing.T) {
+	namespaceDefaults := []config.NamespaceDefaults{
+		{
+			Annotations: map[string]string{
+				wellknown.AnnotationAccount: "default",
+				wellknown.AnnotationUserId:  "1000",
+			},
+		},
+	}
+	tests := []struct {
+		name    string
+		obj     metav1.Object
+		want    *SlurmJobIRJobInfo
+		wantErr bool
+	}{
+		{
+			name: "Defaults",
+			obj:  &metav1.ObjectMeta{Namespace: "slurm", Name: "foo"},
+			want: &SlurmJobIRJobInfo{
+				Account: ptr.To("default"),
+				UserId:  ptr.To("1000"),
+			},
+		},
+		{
+			name: "Annotations",
+			obj: &metav1.ObjectMeta{
+				Namespace: "slurm",
+				Name:      "foo",
+				Annotations: map[string]string{
+					wellknown.AnnotationAccount:   "foo",
+					wellknown.AnnotationTimeLimit: "5",
+				},
+			},
+			want: &SlurmJobIRJobInfo{
+				Account:   ptr.To("foo"),
+				TimeLimit: ptr.To[int32](5),
+				UserId:    ptr.To("1000"),
+			},
+		},
+		{
+			name: "Bad annotation",
+			obj: &metav1.ObjectMeta{
+				Namespace: "slurm",
+				Name:      "foo",
+				Annotations: map[string]string{
+					wellknown.AnnotationTimeLimit: "foo",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TranslateAnnotationsToJobInfo(fake.NewFakeClient(), context.Background(), tt.obj, namespaceDefaults)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TranslateAnnotationsToJobInfo() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !apiequality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("TranslateAnnotationsToJobInfo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_parsePodsCpuAndMemory(t *testing.T) {
 	type args struct {
 		slurmJobIR *SlurmJobIR
@@ -226,6 +292,25 @@ func Test_parsePodsCpuAndMemory(t *testing.T) {
 			cpuPerTask: ptr.To(int32(8)),
 			memPerNode: ptr.To(int64(400)),
 		},
+		{
+			name: "requests summed over tasks per node",
+			args: args{
+				slurmJobIR: &SlurmJobIR{
+					Pods: corev1.PodList{
+						Items: []corev1.Pod{
+							podWithResources("1", "100Mi", "1", "400Mi"),
+							podWithResources("1", "100Mi", "2", "200Mi"),
+							podWithResources("1", "100Mi", "3", "100Mi"),
+						},
+					},
+					JobInfo: SlurmJobIRJobInfo{
+						TasksPerNode: ptr.To(int32(2)),
+					},
+				},
+			},
+			cpuPerTask: ptr.To(int32(3)),
+			memPerNode: ptr.To(int64(600)),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -262,9 +347,10 @@ func Test_parsePodsCpuAndMemory(t *testing.T) {
 	}
 }
 
-func Test_parseGPUDevicePlugin(t *testing.T) {
+func Test_parseGres(t *testing.T) {
 	type args struct {
-		slurmJobIR *SlurmJobIR
+		slurmJobIR   *SlurmJobIR
+		gresMappings []config.GresMapping
 	}
 	tests := []struct {
 		name string
@@ -322,10 +408,96 @@ func Test_parseGPUDevicePlugin(t *testing.T) {
 			},
 			want: ptr.To("gres/gpu=2"),
 		},
+		{
+			name: "Multiple pods per node, GPUs summed",
+			args: args{
+				slurmJobIR: &SlurmJobIR{
+					Pods: corev1.PodList{
+						Items: []corev1.Pod{
+							podWithGPU("nvidia.com/gpu", "1"),
+							podWithGPU("nvidia.com/gpu", "1"),
+							podWithGPU("nvidia.com/gpu", "1"),
+							podWithGPU("nvidia.com/gpu", "1"),
+						},
+					},
+					JobInfo: SlurmJobIRJobInfo{
+						TasksPerNode: ptr.To(int32(4)),
+					},
+				},
+			},
+			want: ptr.To("gres/gpu=4"),
+		},
+		{
+			name: "Typed GPUs and MIG slices",
+			args: args{
+				slurmJobIR: &SlurmJobIR{
+					Pods: corev1.PodList{
+						Items: []corev1.Pod{
+							podWithGPU("nvidia.com/gpu", "2"),
+							podWithGPU("nvidia.com/mig-1g.10gb", "3"),
+						},
+					},
+				},
+				gresMappings: []config.GresMapping{
+					{ResourceName: "nvidia.com/gpu", Name: "gpu", Type: "a100"},
+					{ResourceName: "nvidia.com/mig-1g.10gb", Name: "gpu", Type: "1g.10gb"},
+				},
+			},
+			want: ptr.To("gres/gpu:1g.10gb=3,gres/gpu:a100=2"),
+		},
+		{
+			name: "Multiplier",
+			args: args{
+				slurmJobIR: &SlurmJobIR{
+					Pods: corev1.PodList{
+						Items: []corev1.Pod{
+							podWithGPU("habana.ai/gaudi", "2"),
+						},
+					},
+				},
+				gresMappings: []config.GresMapping{
+					{ResourceName: "habana.ai/gaudi", Name: "gpu", Type: "gaudi", Multiplier: 2},
+				},
+			},
+			want: ptr.To("gres/gpu:gaudi=4"),
+		},
+		{
+			name: "Defaults with configured mappings",
+			args: args{
+				slurmJobIR: &SlurmJobIR{
+					Pods: corev1.PodList{
+						Items: []corev1.Pod{
+							podWithGPU("amd.com/gpu", "1"),
+							podWithGPU("gpu.intel.com/i915", "1"),
+						},
+					},
+				},
+				gresMappings: []config.GresMapping{
+					{ResourceName: "gpu.intel.com/i915", Name: "gpu", Type: "i915"},
+				},
+			},
+			want: ptr.To("gres/gpu=1,gres/gpu:i915=1"),
+		},
+		{
+			name: "Unmapped resource",
+			args: args{
+				slurmJobIR: &SlurmJobIR{
+					Pods: corev1.PodList{
+						Items: []corev1.Pod{
+							podWithGPU("example.com/foo", "1"),
+						},
+					},
+				},
+			},
+			want: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			parseGPUDevicePlugin(tt.args.slurmJobIR)
+			tr := &translator{Reader: fake.NewFakeClient(), ctx: context.Background()}
+			if err := tr.parseGres(tt.args.slurmJobIR, tt.args.gresMappings); err != nil {
+				t.Fatalf("translator.parseGres() error = %v", err)
+			}
 			if !apiequality.Semantic.DeepEqual(tt.want, tt.args.slurmJobIR.JobInfo.Gres) {
 				var gotGres, wantGres interface{}
 				if tt.args.slurmJobIR.JobInfo.Gres != nil {
@@ -338,7 +510,131 @@ func Test_parseGPUDevicePlugin(t *testing.T) {
 				} else {
 					wantGres = nil
 				}
-				t.Errorf("parseGPUDevicePlugin() Gres = %v, want %v", gotGres, wantGres)
+				t.Errorf("translator.parseGres() Gres = %v, want %v", gotGres, wantGres)
+			}
+		})
+	}
+}
+
+func Test_parseTasksPerNode(t *testing.T) {
+	pods := corev1.PodList{Items: []corev1.Pod{{}, {}, {}, {}, {}}}
+	type args struct {
+		slurmJobIR *SlurmJobIR
+		anno       map[string]string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		want    SlurmJobIRJobInfo
+	}{
+		{
+			name: "No annotation",
+			args: args{
+				slurmJobIR: &SlurmJobIR{
+					Pods:    pods,
+					JobInfo: SlurmJobIRJobInfo{MaxNodes: ptr.To(int32(5)), TasksPerNode: ptr.To(int32(1))},
+				},
+				anno: nil,
+			},
+			want: SlurmJobIRJobInfo{MaxNodes: ptr.To(int32(5)), TasksPerNode: ptr.To(int32(1))},
+		},
+		{
+			name: "Pods packed onto nodes",
+			args: args{
+				slurmJobIR: &SlurmJobIR{
+					Pods: pods,
+					JobInfo: SlurmJobIRJobInfo{
+						MinNodes:     ptr.To(int32(5)),
+						MaxNodes:     ptr.To(int32(5)),
+						TasksPerNode: ptr.To(int32(1)),
+					},
+				},
+				anno: map[string]string{wellknown.AnnotationTasksPerNode: "2"},
+			},
+			want: SlurmJobIRJobInfo{
+				MinNodes:     ptr.To(int32(3)),
+				MaxNodes:     ptr.To(int32(3)),
+				Tasks:        ptr.To(int32(5)),
+				TasksPerNode: ptr.To(int32(2)),
+			},
+		},
+		{
+			name: "Tasks per node limited to pod count",
+			args: args{
+				slurmJobIR: &SlurmJobIR{
+					Pods: pods,
+				},
+				anno: map[string]string{wellknown.AnnotationTasksPerNode: "8"},
+			},
+			want: SlurmJobIRJobInfo{
+				MaxNodes:     ptr.To(int32(1)),
+				Tasks:        ptr.To(int32(5)),
+				TasksPerNode: ptr.To(int32(5)),
+			},
+		},
+		{
+			name: "Bad annotation",
+			args: args{
+				slurmJobIR: &SlurmJobIR{Pods: pods},
+				anno:       map[string]string{wellknown.AnnotationTasksPerNode: "foo"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Zero tasks per node",
+			args: args{
+				slurmJobIR: &SlurmJobIR{Pods: pods},
+				anno:       map[string]string{wellknown.AnnotationTasksPerNode: "0"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Conflicts with min nodes",
+			args: args{
+				slurmJobIR: &SlurmJobIR{Pods: pods},
+				anno: map[string]string{
+					wellknown.AnnotationTasksPerNode: "2",
+					wellknown.AnnotationMinNodes:     "1",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Conflicts with max nodes",
+			args: args{
+				slurmJobIR: &SlurmJobIR{Pods: pods},
+				anno: map[string]string{
+					wellknown.AnnotationTasksPerNode: "2",
+					wellknown.AnnotationMaxNodes:     "4",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Conflicts with cpu per task",
+			args: args{
+				slurmJobIR: &SlurmJobIR{Pods: pods},
+				anno: map[string]string{
+					wellknown.AnnotationTasksPerNode: "2",
+					wellknown.AnnotationCpuPerTask:   "4",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseTasksPerNode(tt.args.slurmJobIR, tt.args.anno)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseTasksPerNode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !apiequality.Semantic.DeepEqual(tt.want, tt.args.slurmJobIR.JobInfo) {
+				t.Errorf("parseTasksPerNode() = %v, want %v", tt.args.slurmJobIR.JobInfo, tt.want)
 			}
 		})
 	}
@@ -369,43 +665,49 @@ func Test_parseAnnotations(t *testing.T) {
 			args: args{
 				slurmJobIR: &SlurmJobIR{},
 				anno: map[string]string{
-					wellknown.AnnotationAccount:     "slurm",
-					wellknown.AnnotationConstraints: "foo",
-					wellknown.AnnotationCpuPerTask:  "200m",
-					wellknown.AnnotationGres:        "gres/gpu=2",
-					wellknown.AnnotationGroupId:     "1000",
-					wellknown.AnnotationJobName:     "jobname",
-					wellknown.AnnotationLicenses:    "mathlib",
-					wellknown.AnnotationMaxNodes:    "4",
-					wellknown.AnnotationMemPerNode:  "1Gi",
-					wellknown.AnnotationMinNodes:    "2",
-					wellknown.AnnotationPartition:   "slurm-bridge",
-					wellknown.AnnotationQOS:         "high",
-					wellknown.AnnotationReservation: "training",
-					wellknown.AnnotationTimeLimit:   "30",
-					wellknown.AnnotationUserId:      "1000",
-					wellknown.AnnotationWckey:       "key",
+					wellknown.AnnotationAccount:        "slurm",
+					wellknown.AnnotationAllocationPool: "pool",
+					wellknown.AnnotationAttachJobId:    "42",
+					wellknown.AnnotationConstraints:    "foo",
+					wellknown.AnnotationCpuPerTask:     "200m",
+					wellknown.AnnotationGres:           "gres/gpu=2",
+					wellknown.AnnotationGroupId:        "1000",
+					wellknown.AnnotationJobName:        "jobname",
+					wellknown.AnnotationLicenses:       "mathlib",
+					wellknown.AnnotationMaxNodes:       "4",
+					wellknown.AnnotationMemPerNode:     "1Gi",
+					wellknown.AnnotationMinNodes:       "2",
+					wellknown.AnnotationPartition:      "slurm-bridge",
+					wellknown.AnnotationQOS:            "high",
+					wellknown.AnnotationReservation:    "training",
+					wellknown.AnnotationShared:         "oversubscribe",
+					wellknown.AnnotationTimeLimit:      "30",
+					wellknown.AnnotationUserId:         "1000",
+					wellknown.AnnotationWckey:          "key",
 				},
 			},
 			wantErr: false,
 			wantRes: SlurmJobIR{
 				JobInfo: SlurmJobIRJobInfo{
-					Account:     ptr.To("slurm"),
-					Constraints: ptr.To("foo"),
-					CpuPerTask:  ptr.To(int32(1)),
-					Gres:        ptr.To("gres/gpu=2"),
-					GroupId:     ptr.To("1000"),
-					JobName:     ptr.To("jobname"),
-					Licenses:    ptr.To("mathlib"),
-					MemPerNode:  ptr.To(int64(1024)),
-					MinNodes:    ptr.To(int32(2)),
-					MaxNodes:    ptr.To(int32(4)),
-					Partition:   ptr.To("slurm-bridge"),
-					QOS:         ptr.To("high"),
-					Reservation: ptr.To("training"),
-					TimeLimit:   ptr.To(int32(30)),
-					UserId:      ptr.To("1000"),
-					Wckey:       ptr.To("key"),
+					Account:        ptr.To("slurm"),
+					AllocationPool: ptr.To("pool"),
+					AttachJobId:    ptr.To(int32(42)),
+					Constraints:    ptr.To("foo"),
+					CpuPerTask:     ptr.To(int32(1)),
+					Gres:           ptr.To("gres/gpu=2"),
+					GroupId:        ptr.To("1000"),
+					JobName:        ptr.To("jobname"),
+					Licenses:       ptr.To("mathlib"),
+					MemPerNode:     ptr.To(int64(1024)),
+					MinNodes:       ptr.To(int32(2)),
+					MaxNodes:       ptr.To(int32(4)),
+					Partition:      ptr.To("slurm-bridge"),
+					QOS:            ptr.To("high"),
+					Reservation:    ptr.To("training"),
+					Shared:         ptr.To("oversubscribe"),
+					TimeLimit:      ptr.To(int32(30)),
+					UserId:         ptr.To("1000"),
+					Wckey:          ptr.To("key"),
 				},
 			},
 		},
@@ -439,6 +741,16 @@ func Test_parseAnnotations(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "BadAttachJobIdAnnotation",
+			args: args{
+				slurmJobIR: &SlurmJobIR{},
+				anno: map[string]string{
+					wellknown.AnnotationAttachJobId: "foo",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "BadTimeLimitAnnotation",
 			args: args{
@@ -449,6 +761,16 @@ func Test_parseAnnotations(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "BadSharedAnnotation",
+			args: args{
+				slurmJobIR: &SlurmJobIR{},
+				anno: map[string]string{
+					wellknown.AnnotationShared: "foo",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "BadNTasksAnnotation",
 			args: args{
@@ -0,0 +1,336 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+)
+
+const (
+	// EnvSlurmJwt is the environment variable which holds a static token.
+	EnvSlurmJwt = "SLURM_JWT"
+
+	// headerSlurmUserToken is the header slurmrestd reads the token from.
+	headerSlurmUserToken = "X-SLURM-USER-TOKEN" //nolint:gosec // disable G101
+	// headerSlurmUserName is the header slurmrestd reads the user to
+	// impersonate from.
+	headerSlurmUserName = "X-SLURM-USER-NAME"
+
+	// defaultInterval is how often the source is checked for a new token.
+	defaultInterval = 10 * time.Second
+
+	// defaultUsername is the user which minted tokens are issued for.
+	defaultUsername = "slurm"
+	// defaultLifetime is how long a minted token is valid.
+	defaultLifetime = 5 * time.Minute
+)
+
+var ErrorNoToken = errors.New("no Slurm JWT is configured")
+
+// TokenSource provides the token used to authenticate with the Slurm REST API.
+type TokenSource interface {
+	// Token returns the current token, reloading it if the source changed.
+	Token(ctx context.Context) (string, error)
+	// Refresh reloads the token from the source, e.g. after it was rejected.
+	Refresh(ctx context.Context) (string, error)
+}
+
+// NewTokenSource returns the TokenSource for the configuration. The reader is
+// only used when the token is read from a Secret.
+func NewTokenSource(cfg config.SlurmJwt, reader client.Reader) (TokenSource, error) {
+	switch {
+	case cfg.KeySecretRef != nil:
+		if reader == nil {
+			return nil, fmt.Errorf("a client is required to read the Slurm JWT key from a Secret")
+		}
+		source := &keySource{
+			reader:   reader,
+			ref:      *cfg.KeySecretRef,
+			username: cfg.Username,
+			lifetime: time.Duration(cfg.LifetimeSeconds) * time.Second,
+		}
+		if source.username == "" {
+			source.username = defaultUsername
+		}
+		if source.lifetime <= 0 {
+			source.lifetime = defaultLifetime
+		}
+		return source, nil
+	case cfg.File != "":
+		return &fileSource{path: cfg.File, interval: defaultInterval}, nil
+	case cfg.SecretRef != nil:
+		if reader == nil {
+			return nil, fmt.Errorf("a client is required to read the Slurm JWT from a Secret")
+		}
+		return &secretSource{reader: reader, ref: *cfg.SecretRef, interval: defaultInterval}, nil
+	default:
+		token, _ := os.LookupEnv(EnvSlurmJwt)
+		return &staticSource{token: token}, nil
+	}
+}
+
+type staticSource struct {
+	token string
+}
+
+// Token implements TokenSource.
+func (s *staticSource) Token(ctx context.Context) (string, error) {
+	if s.token == "" {
+		return "", ErrorNoToken
+	}
+	return s.token, nil
+}
+
+// Refresh implements TokenSource.
+func (s *staticSource) Refresh(ctx context.Context) (string, error) {
+	return s.Token(ctx)
+}
+
+// fileSource reads the token from a file. The file is checked for changes at
+// most once per interval, which picks up a rotated Secret volume.
+type fileSource struct {
+	path     string
+	interval time.Duration
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	checked time.Time
+}
+
+// Token implements TokenSource.
+func (s *fileSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Since(s.checked) < s.interval {
+		return s.token, nil
+	}
+	s.checked = time.Now()
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return "", err
+	}
+	if s.token != "" && info.ModTime().Equal(s.modTime) {
+		return s.token, nil
+	}
+	return s.readLocked(info.ModTime())
+}
+
+// Refresh implements TokenSource.
+func (s *fileSource) Refresh(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checked = time.Now()
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return "", err
+	}
+	return s.readLocked(info.ModTime())
+}
+
+func (s *fileSource) readLocked(modTime time.Time) (string, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", ErrorNoToken
+	}
+	s.token = token
+	s.modTime = modTime
+	return s.token, nil
+}
+
+// secretSource reads the token from a key of a Secret. The Secret is fetched
+// at most once per interval.
+type secretSource struct {
+	reader   client.Reader
+	ref      config.SecretKeyRef
+	interval time.Duration
+
+	mu      sync.Mutex
+	token   string
+	checked time.Time
+}
+
+// Token implements TokenSource.
+func (s *secretSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Since(s.checked) < s.interval {
+		return s.token, nil
+	}
+	return s.readLocked(ctx)
+}
+
+// Refresh implements TokenSource.
+func (s *secretSource) Refresh(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readLocked(ctx)
+}
+
+func (s *secretSource) readLocked(ctx context.Context) (string, error) {
+	s.checked = time.Now()
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: s.ref.Namespace, Name: s.ref.Name}
+	if err := s.reader.Get(ctx, key, secret); err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(secret.Data[s.ref.Key]))
+	if token == "" {
+		return "", fmt.Errorf("%w: key %q of Secret %s is empty", ErrorNoToken, s.ref.Key, key)
+	}
+	s.token = token
+	return s.token, nil
+}
+
+// keySource mints tokens with the Slurm jwt_hs256.key read from a Secret. A
+// token is minted again once half of its lifetime has passed.
+type keySource struct {
+	reader   client.Reader
+	ref      config.SecretKeyRef
+	username string
+	lifetime time.Duration
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// Token implements TokenSource.
+func (s *keySource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Until(s.expiry) > s.lifetime/2 {
+		return s.token, nil
+	}
+	return s.mintLocked(ctx)
+}
+
+// Refresh implements TokenSource.
+func (s *keySource) Refresh(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mintLocked(ctx)
+}
+
+func (s *keySource) mintLocked(ctx context.Context) (string, error) {
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: s.ref.Namespace, Name: s.ref.Name}
+	if err := s.reader.Get(ctx, key, secret); err != nil {
+		return "", err
+	}
+	signingKey := secret.Data[s.ref.Key]
+	if len(signingKey) == 0 {
+		return "", fmt.Errorf("%w: key %q of Secret %s is empty", ErrorNoToken, s.ref.Key, key)
+	}
+	now := time.Now()
+	token, err := Mint(signingKey, s.username, now, s.lifetime)
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	s.expiry = now.Add(s.lifetime)
+	return s.token, nil
+}
+
+// Mint returns a token for the user signed with the Slurm jwt_hs256.key,
+// which is valid for lifetime from now.
+func Mint(key []byte, username string, now time.Time, lifetime time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"iat": now.Unix(),
+		"exp": now.Add(lifetime).Unix(),
+		"sun": username,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+type userKey struct{}
+
+// WithUser returns a context which impersonates the user for Slurm REST API
+// requests made with it. slurmrestd only allows impersonation when the token
+// belongs to the SlurmUser or root.
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+func userFrom(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// NewTransport returns a RoundTripper which authenticates each request with
+// the current token of the source. A request which is rejected as
+// unauthorized is retried once if refreshing the token yields a new token.
+func NewTransport(source TokenSource, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{source: source, next: next}
+}
+
+type transport struct {
+	source TokenSource
+	next   http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	token, err := t.source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(withToken(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		// The request body cannot be replayed.
+		return resp, nil
+	}
+	newToken, err := t.source.Refresh(ctx)
+	if err != nil || newToken == token {
+		return resp, nil
+	}
+	retry := withToken(req, newToken)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return t.next.RoundTrip(retry)
+}
+
+// withToken returns a copy of the request which carries the token, and the
+// user to impersonate if there is one.
+func withToken(req *http.Request, token string) *http.Request {
+	out := req.Clone(req.Context())
+	out.Header.Set(headerSlurmUserToken, token)
+	if user := userFrom(req.Context()); user != "" {
+		out.Header.Set(headerSlurmUserName, user)
+	}
+	return out
+}
@@ -0,0 +1,371 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurmjwt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/SlinkyProject/slurm-bridge/internal/config"
+)
+
+func TestNewTokenSource(t *testing.T) {
+	secretRef := &config.SecretKeyRef{Name: "slurm-bridge-token", Key: "auth-token"}
+	tests := []struct {
+		name    string
+		cfg     config.SlurmJwt
+		reader  client.Reader
+		want    TokenSource
+		wantErr bool
+	}{
+		{
+			name: "Environment",
+			cfg:  config.SlurmJwt{},
+			want: &staticSource{token: "foo"},
+		},
+		{
+			name: "File",
+			cfg:  config.SlurmJwt{File: "/var/run/secrets/slurm-bridge/auth-token"},
+			want: &fileSource{path: "/var/run/secrets/slurm-bridge/auth-token", interval: defaultInterval},
+		},
+		{
+			name:   "Secret",
+			cfg:    config.SlurmJwt{SecretRef: secretRef},
+			reader: fake.NewFakeClient(),
+			want:   &secretSource{reader: fake.NewFakeClient(), ref: *secretRef, interval: defaultInterval},
+		},
+		{
+			name:   "Key",
+			cfg:    config.SlurmJwt{KeySecretRef: secretRef},
+			reader: fake.NewFakeClient(),
+			want:   &keySource{ref: *secretRef, username: defaultUsername, lifetime: defaultLifetime},
+		},
+		{
+			name:    "Key without client",
+			cfg:     config.SlurmJwt{KeySecretRef: secretRef},
+			wantErr: true,
+		},
+		{
+			name:    "Secret without client",
+			cfg:     config.SlurmJwt{SecretRef: secretRef},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvSlurmJwt, "foo")
+			got, err := NewTokenSource(tt.cfg, tt.reader)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTokenSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if reflect.TypeOf(got) != reflect.TypeOf(tt.want) {
+				t.Errorf("NewTokenSource() = %T, want %T", got, tt.want)
+			}
+			switch want := tt.want.(type) {
+			case *staticSource:
+				if got.(*staticSource).token != want.token {
+					t.Errorf("NewTokenSource() token = %v, want %v", got.(*staticSource).token, want.token)
+				}
+			case *fileSource:
+				if got.(*fileSource).path != want.path {
+					t.Errorf("NewTokenSource() path = %v, want %v", got.(*fileSource).path, want.path)
+				}
+			case *secretSource:
+				if got.(*secretSource).ref != want.ref {
+					t.Errorf("NewTokenSource() ref = %v, want %v", got.(*secretSource).ref, want.ref)
+				}
+			case *keySource:
+				got := got.(*keySource)
+				if got.ref != want.ref || got.username != want.username || got.lifetime != want.lifetime {
+					t.Errorf("NewTokenSource() = %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_staticSource_Token(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{
+			name:  "Token",
+			token: "foo",
+		},
+		{
+			name:    "Empty",
+			token:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &staticSource{token: tt.token}
+			got, err := s.Token(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("staticSource.Token() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.token {
+				t.Errorf("staticSource.Token() = %v, want %v", got, tt.token)
+			}
+		})
+	}
+}
+
+func Test_fileSource(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "auth-token")
+	if err := os.WriteFile(path, []byte("foo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := &fileSource{path: path, interval: time.Hour}
+	if got, err := s.Token(ctx); err != nil || got != "foo" {
+		t.Fatalf("fileSource.Token() = %v, %v, want %v", got, err, "foo")
+	}
+
+	// A rotated token is not read again until the interval passes.
+	if err := os.WriteFile(path, []byte("bar"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Token(ctx); got != "foo" {
+		t.Errorf("fileSource.Token() = %v, want %v", got, "foo")
+	}
+	s.interval = 0
+	if got, _ := s.Token(ctx); got != "bar" {
+		t.Errorf("fileSource.Token() = %v, want %v", got, "bar")
+	}
+
+	// Refresh always reads the file.
+	if err := os.WriteFile(path, []byte("baz"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s.interval = time.Hour
+	if got, _ := s.Refresh(ctx); got != "baz" {
+		t.Errorf("fileSource.Refresh() = %v, want %v", got, "baz")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh(ctx); err == nil {
+		t.Errorf("fileSource.Refresh() expected error for missing file")
+	}
+}
+
+func Test_secretSource(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "slinky", Name: "slurm-bridge-token"},
+		Data:       map[string][]byte{"auth-token": []byte("foo")},
+	}
+	c := fake.NewFakeClient(secret)
+	s := &secretSource{
+		reader:   c,
+		ref:      config.SecretKeyRef{Namespace: "slinky", Name: "slurm-bridge-token", Key: "auth-token"},
+		interval: time.Hour,
+	}
+	if got, err := s.Token(ctx); err != nil || got != "foo" {
+		t.Fatalf("secretSource.Token() = %v, %v, want %v", got, err, "foo")
+	}
+
+	secret.Data["auth-token"] = []byte("bar")
+	if err := c.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Token(ctx); got != "foo" {
+		t.Errorf("secretSource.Token() = %v, want %v", got, "foo")
+	}
+	if got, _ := s.Refresh(ctx); got != "bar" {
+		t.Errorf("secretSource.Refresh() = %v, want %v", got, "bar")
+	}
+
+	s.ref.Key = "missing"
+	if _, err := s.Refresh(ctx); !errors.Is(err, ErrorNoToken) {
+		t.Errorf("secretSource.Refresh() error = %v, want %v", err, ErrorNoToken)
+	}
+}
+
+func TestMint(t *testing.T) {
+	key := []byte("jwt_hs256.key")
+	now := time.Now()
+	token, err := Mint(key, "slurm", now, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})); err != nil {
+		t.Fatalf("jwt.ParseWithClaims() error = %v", err)
+	}
+	if claims["sun"] != "slurm" {
+		t.Errorf("sun = %v, want %v", claims["sun"], "slurm")
+	}
+	if exp, _ := claims.GetExpirationTime(); exp == nil || exp.Unix() != now.Add(time.Minute).Unix() {
+		t.Errorf("exp = %v, want %v", exp, now.Add(time.Minute))
+	}
+}
+
+func Test_keySource(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "slurm", Name: "slurm-auth-jwths256"},
+		Data:       map[string][]byte{"jwt_hs256.key": []byte("foo")},
+	}
+	c := fake.NewFakeClient(secret)
+	s := &keySource{
+		reader:   c,
+		ref:      config.SecretKeyRef{Namespace: "slurm", Name: "slurm-auth-jwths256", Key: "jwt_hs256.key"},
+		username: "slurm",
+		lifetime: time.Hour,
+	}
+	token, err := s.Token(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Token(ctx); got != token {
+		t.Errorf("keySource.Token() = %v, want cached %v", got, token)
+	}
+
+	// A rotated key is used once the token is minted again.
+	secret.Data["jwt_hs256.key"] = []byte("bar")
+	if err := c.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Refresh(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(got, func(*jwt.Token) (any, error) {
+		return []byte("bar"), nil
+	}); err != nil {
+		t.Errorf("keySource.Refresh() token not signed with rotated key: %v", err)
+	}
+
+	s.ref.Key = "missing"
+	if _, err := s.Refresh(ctx); !errors.Is(err, ErrorNoToken) {
+		t.Errorf("keySource.Refresh() error = %v, want %v", err, ErrorNoToken)
+	}
+}
+
+// rotatingSource returns the old token until it is refreshed.
+type rotatingSource struct {
+	token     string
+	refreshed string
+}
+
+func (s *rotatingSource) Token(ctx context.Context) (string, error) {
+	return s.token, nil
+}
+
+func (s *rotatingSource) Refresh(ctx context.Context) (string, error) {
+	s.token = s.refreshed
+	return s.token, nil
+}
+
+func TestNewTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		refreshed string
+		body      string
+		user      string
+		wantCode  int
+		wantCalls int
+	}{
+		{
+			name:      "Valid token",
+			token:     "valid",
+			refreshed: "valid",
+			wantCode:  http.StatusOK,
+			wantCalls: 1,
+		},
+		{
+			name:      "Rotated token",
+			token:     "expired",
+			refreshed: "valid",
+			body:      `{"foo":"bar"}`,
+			wantCode:  http.StatusOK,
+			wantCalls: 2,
+		},
+		{
+			name:      "Impersonate user",
+			token:     "valid",
+			refreshed: "valid",
+			user:      "1000",
+			wantCode:  http.StatusOK,
+			wantCalls: 1,
+		},
+		{
+			name:      "Token not rotated",
+			token:     "expired",
+			refreshed: "expired",
+			wantCode:  http.StatusUnauthorized,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				calls++
+				if req.Header.Get(headerSlurmUserToken) != "valid" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if got := req.Header.Get(headerSlurmUserName); got != tt.user {
+					t.Errorf("%s = %v, want %v", headerSlurmUserName, got, tt.user)
+				}
+				body, _ := io.ReadAll(req.Body)
+				if string(body) != tt.body {
+					t.Errorf("body = %v, want %v", string(body), tt.body)
+				}
+			}))
+			defer server.Close()
+
+			source := &rotatingSource{token: tt.token, refreshed: tt.refreshed}
+			httpClient := &http.Client{Transport: NewTransport(source, nil)}
+			ctx := context.Background()
+			if tt.user != "" {
+				ctx = WithUser(ctx, tt.user)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("StatusCode = %v, want %v", resp.StatusCode, tt.wantCode)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
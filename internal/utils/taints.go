@@ -4,6 +4,8 @@
 package utils
 
 import (
+	"slices"
+
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -31,6 +33,13 @@ func NewTaintNodeBridged(schedulerName string) *corev1.Taint {
 	return &taint
 }
 
+// IsBridgedNode returns true if the node is also a Slurm node.
+func IsBridgedNode(node *corev1.Node) bool {
+	return slices.ContainsFunc(node.Spec.Taints, func(taint corev1.Taint) bool {
+		return taint.Key == TaintKeyBridgedNode
+	})
+}
+
 var (
 	// TolerationNodeBridged is used to mark pods such that they can run on the
 	// slurm-bridge marked nodes.
@@ -93,3 +93,42 @@ func TestNewTolerationNodeBridged(t *testing.T) {
 		})
 	}
 }
+
+func TestIsBridgedNode(t *testing.T) {
+	tests := []struct {
+		name string
+		node *corev1.Node
+		want bool
+	}{
+		{
+			name: "No taints",
+			node: &corev1.Node{},
+			want: false,
+		},
+		{
+			name: "Other taint",
+			node: &corev1.Node{
+				Spec: corev1.NodeSpec{
+					Taints: []corev1.Taint{{Key: "foo", Effect: corev1.TaintEffectNoSchedule}},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "Bridged",
+			node: &corev1.Node{
+				Spec: corev1.NodeSpec{
+					Taints: []corev1.Taint{*NewTaintNodeBridged("foo")},
+				},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBridgedNode(tt.node); got != tt.want {
+				t.Errorf("IsBridgedNode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,16 +7,33 @@ const (
 	// AnnotationPlaceholderNode indicates the Node which corresponds to the
 	// the pod's placeholder job.
 	AnnotationPlaceholderNode = "slinky.slurm.net/slurm-node"
+	// AnnotationPendingReason indicates why the pod's placeholder job is
+	// pending in Slurm (e.g. Priority, Resources).
+	AnnotationPendingReason = "slinky.slurm.net/pending-reason"
+	// AnnotationExpectedStartTime indicates when Slurm expects the pod's
+	// placeholder job to start, in RFC 3339 format.
+	AnnotationExpectedStartTime = "slinky.slurm.net/expected-start-time"
 
 	// AnnotationAccount overrides the default account
 	// for the Slurm placeholder job.
 	AnnotationAccount = "slinky.slurm.net/account"
+	// AnnotationAllocationPool claims an idle allocation of the named
+	// AllocationPool, in the namespace of the pods, instead of submitting
+	// a placeholder job.
+	AnnotationAllocationPool = "slinky.slurm.net/allocation-pool"
+	// AnnotationAttachJobId attaches the pods to the running Slurm job
+	// instead of submitting a placeholder job.
+	AnnotationAttachJobId = "slinky.slurm.net/attach-job-id"
 	// AnnotationConstraint sets the constraint
 	// for the Slurm placeholder job.
 	AnnotationConstraints = "slinky.slurm.net/constraints"
 	// AnnotationCpuPerTask sets the number of cpus
 	// per task
 	AnnotationCpuPerTask = "slinky.slurm.net/cpu-per-task"
+	// AnnotationGang, when "true", schedules the pods of a Job or JobSet
+	// together in a single placeholder job instead of one placeholder job
+	// per pod.
+	AnnotationGang = "slinky.slurm.net/gang"
 	// AnnotationGres overrides the default gres
 	// for the Slurm placeholder job.
 	AnnotationGres = "slinky.slurm.net/gres"
@@ -47,6 +64,12 @@ const (
 	// AnnotationReservation sets the reservation
 	// for the Slurm placeholder job.
 	AnnotationReservation = "slinky.slurm.net/reservation"
+	// AnnotationShared sets the node sharing mode (exclusive, oversubscribe,
+	// user, mcs) for the Slurm placeholder job.
+	AnnotationShared = "slinky.slurm.net/shared"
+	// AnnotationTasksPerNode sets the number of pods packed onto each node
+	// for the Slurm placeholder job.
+	AnnotationTasksPerNode = "slinky.slurm.net/tasks-per-node"
 	// AnnotationTimelimit sets the Time Limit in minutes
 	// for the Slurm placeholder job.
 	AnnotationTimeLimit = "slinky.slurm.net/timelimit"
@@ -7,4 +7,10 @@ const (
 	// FinalizerScheduler exists to process pod deletion events. Once a pod processes
 	// if a placeholder job can be deleted, the finalizer is removed.
 	FinalizerScheduler = "scheduler.slurm.net/finalizer"
+	// FinalizerAllocationPool exists to cancel the allocations of an
+	// AllocationPool before it is deleted.
+	FinalizerAllocationPool = "bridge.slinky.slurm.net/allocationpool"
+	// FinalizerSlurmJob exists to cancel the Slurm job of a SlurmJob before it
+	// is deleted.
+	FinalizerSlurmJob = "bridge.slinky.slurm.net/slurmjob"
 )
@@ -11,4 +11,9 @@ const (
 	// LabelPlaceholderJobId indicates the Slurm JobId which corresponds to the
 	// the pod's placeholder job.
 	LabelPlaceholderJobId = "scheduler.slinky.slurm.net/slurm-jobid"
+
+	// LabelPrefixSlurmFeature prefixes the labels of a Kubernetes node which
+	// indicate the active features of the corresponding Slurm node, with the
+	// value "true" (e.g. feature.slinky.slurm.net/a100: "true").
+	LabelPrefixSlurmFeature = "feature.slinky.slurm.net/"
 )
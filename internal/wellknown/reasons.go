@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package wellknown
+
+const (
+	// ReasonSlurmJobTimeout indicates the pod is terminated because its
+	// placeholder job reached its time limit.
+	ReasonSlurmJobTimeout = "SlurmJobTimeout"
+	// ReasonSlurmJobPreempted indicates the pod is terminated because its
+	// placeholder job was preempted.
+	ReasonSlurmJobPreempted = "SlurmJobPreempted"
+	// ReasonSlurmJobNodeFail indicates the pod is terminated because a node
+	// of its placeholder job failed.
+	ReasonSlurmJobNodeFail = "SlurmJobNodeFail"
+	// ReasonSlurmJobCancelled indicates the pod is terminated because its
+	// placeholder job was cancelled.
+	ReasonSlurmJobCancelled = "SlurmJobCancelled"
+	// ReasonSlurmJobEnded indicates the pod is terminated because its
+	// placeholder job is no longer running.
+	ReasonSlurmJobEnded = "SlurmJobEnded"
+)
+
+const (
+	// ReasonSlurmJobSubmitted indicates a placeholder job was submitted to
+	// Slurm for the pod, or the batch job of a SlurmJob was submitted.
+	ReasonSlurmJobSubmitted = "SlurmJobSubmitted"
+	// ReasonSlurmJobSubmitFailed indicates the batch job of a SlurmJob could
+	// not be submitted to Slurm.
+	ReasonSlurmJobSubmitFailed = "SlurmJobSubmitFailed"
+	// ReasonSlurmJobAttached indicates the pods were attached to an existing
+	// Slurm job instead of a placeholder job.
+	ReasonSlurmJobAttached = "SlurmJobAttached"
+	// ReasonSlurmJobUpdated indicates the pod's placeholder job was updated
+	// to include additional pods.
+	ReasonSlurmJobUpdated = "SlurmJobUpdated"
+	// ReasonSlurmJobPending indicates the pod's placeholder job is pending
+	// in Slurm.
+	ReasonSlurmJobPending = "SlurmJobPending"
+	// ReasonSlurmJobSignaled indicates the pod's placeholder job was signaled
+	// to end with the outcome of its pods, or before being cancelled.
+	ReasonSlurmJobSignaled = "SlurmJobSignaled"
+	// ReasonSlurmJobTerminated indicates the pod's placeholder job was
+	// cancelled because its pods ended.
+	ReasonSlurmJobTerminated = "SlurmJobTerminated"
+	// ReasonSlurmJobDeleted indicates the pod's placeholder job was deleted
+	// by the scheduler so the pod can be scheduled again.
+	ReasonSlurmJobDeleted = "SlurmJobDeleted"
+	// ReasonAllocationSubmitted indicates an allocation of the AllocationPool
+	// was submitted to Slurm.
+	ReasonAllocationSubmitted = "AllocationSubmitted"
+	// ReasonAllocationReleased indicates an allocation of the AllocationPool
+	// was returned to it because the pods which claimed it ended.
+	ReasonAllocationReleased = "AllocationReleased"
+	// ReasonAllocationCancelled indicates an idle allocation of the
+	// AllocationPool was cancelled.
+	ReasonAllocationCancelled = "AllocationCancelled"
+	// ReasonPolicyViolation indicates the AllocationPool or SlurmJob requests
+	// a user, account, QOS or partition which the policy of its namespace does
+	// not allow.
+	ReasonPolicyViolation = "PolicyViolation"
+	// ReasonNodeTainted indicates the node was tainted because it corresponds
+	// to a Slurm node.
+	ReasonNodeTainted = "NodeTainted"
+	// ReasonNodeUntainted indicates the taint was removed from the node
+	// because it no longer corresponds to a Slurm node.
+	ReasonNodeUntainted = "NodeUntainted"
+	// ReasonSlurmNodeDrained indicates the corresponding Slurm node was
+	// drained because the node is unschedulable.
+	ReasonSlurmNodeDrained = "SlurmNodeDrained"
+	// ReasonSlurmNodeUndrained indicates the corresponding Slurm node was
+	// undrained because the node is schedulable.
+	ReasonSlurmNodeUndrained = "SlurmNodeUndrained"
+)
@@ -0,0 +1,15 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package wellknown
+
+const (
+	// SignalPodsSucceeded is sent to the batch step of a placeholder job once
+	// its pods succeeded, which ends the job as COMPLETED.
+	SignalPodsSucceeded = "SIGUSR1"
+
+	// SignalPodsFailed is sent to the batch step of a placeholder job once its
+	// pods failed, which ends the job as FAILED with the exit code recorded in
+	// the comment of the job.
+	SignalPodsFailed = "SIGUSR2"
+)